@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -12,15 +13,23 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/donaldgifford/repo-guardian/internal/admin"
 	"github.com/donaldgifford/repo-guardian/internal/checker"
 	"github.com/donaldgifford/repo-guardian/internal/config"
 	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/owners"
 	"github.com/donaldgifford/repo-guardian/internal/rules"
+	"github.com/donaldgifford/repo-guardian/internal/schedule"
 	"github.com/donaldgifford/repo-guardian/internal/scheduler"
 	"github.com/donaldgifford/repo-guardian/internal/webhook"
 )
 
-const shutdownTimeout = 15 * time.Second
+// Shutdown budget: deploy/base/deployment.yaml sets
+// terminationGracePeriodSeconds to cover both plus JOB_SHUTDOWN_GRACE.
+const (
+	shutdownTimeout    = 15 * time.Second
+	intakeDrainTimeout = 10 * time.Second
+)
 
 func main() {
 	// Load configuration.
@@ -37,28 +46,118 @@ func main() {
 	logger.Info("starting repo-guardian",
 		"listen_addr", cfg.ListenAddr,
 		"metrics_addr", cfg.MetricsAddr,
+		"admin_addr", cfg.AdminAddr,
 		"dry_run", cfg.DryRun,
 		"worker_count", cfg.WorkerCount,
 		"custom_properties_mode", cfg.CustomPropertiesMode,
 	)
 
 	// Initialize GitHub client.
-	client, err := ghclient.NewClient(cfg.GitHubAppID, cfg.GitHubPrivateKeyPath, logger, cfg.RateLimitThreshold)
+	client, err := ghclient.NewClient(
+		cfg.GitHubAppID,
+		cfg.GitHubPrivateKeyPath,
+		logger,
+		cfg.RateLimitThreshold,
+		cfg.GitHubCallTimeout,
+	)
 	if err != nil {
 		logger.Error("failed to create GitHub client", "error", err)
 		os.Exit(1)
 	}
 
-	// Initialize rule registry and template store.
-	registry := rules.NewRegistry(rules.DefaultRules)
+	// Initialize checker engine.
+	engine, err := newEngine(cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize checker engine", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize work queue.
+	queue, err := newQueue(cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize work queue", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize webhook handler.
+	webhookHandler, err := newWebhookHandler(cfg, queue, engine, client, logger)
+	if err != nil {
+		logger.Error("failed to initialize webhook handler", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize scheduler.
+	sched, err := newScheduler(cfg, client, queue, logger)
+	if err != nil {
+		logger.Error("failed to initialize scheduler", "error", err)
+		os.Exit(1)
+	}
+
+	webhookHandler.SetReconciler(sched)
+
+	// Set up context for graceful shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start work queue workers and the webhook intake.
+	queue.Start(ctx, cfg.WorkerCount, engine, client)
+	startWebhookHandler(ctx, cfg, webhookHandler)
+
+	// Start scheduler in background.
+	go sched.Start(ctx)
+
+	// Set up and start HTTP servers.
+	mainServer := newMainServer(cfg.ListenAddr, webhookHandler, queue)
+	metricsServer := newMetricsServer(cfg.MetricsAddr)
+	adminServer := newAdminServer(cfg.AdminAddr, admin.NewHandler(queue, webhookHandler, logger))
+
+	startServer(logger, mainServer, "main", cfg.ListenAddr, cancel)
+	startServer(logger, metricsServer, "metrics", cfg.MetricsAddr, cancel)
+	startServer(logger, adminServer, "admin", cfg.AdminAddr, cancel)
+
+	// Wait for shutdown signal.
+	awaitShutdown(ctx, logger)
+	cancel()
+
+	// Graceful shutdown.
+	gracefulShutdown(logger, queue, webhookHandler, mainServer, metricsServer, adminServer)
+}
+
+// newEngine loads the rules, templates and owner team mapping and creates
+// the checker engine.
+func newEngine(cfg *config.Config, logger *slog.Logger) (*checker.Engine, error) {
+	fileRules, err := rules.LoadRules(cfg.RulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading rules: %w", err)
+	}
+
+	registry := rules.NewRegistry(fileRules)
 
 	templates := rules.NewTemplateStore()
 	if err := templates.Load(cfg.TemplateDir); err != nil {
-		logger.Error("failed to load templates", "error", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("loading templates: %w", err)
+	}
+
+	if err := registry.ValidateTemplates(templates); err != nil {
+		return nil, fmt.Errorf("rules reference missing templates: %w", err)
+	}
+
+	logger.Info("loaded rules",
+		"rules_file", cfg.RulesFile,
+		"total", len(registry.AllRules()),
+		"enabled", len(registry.EnabledRules()),
+	)
+
+	ownerTeams, err := owners.Load(cfg.OwnerTeamsFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading owner team mapping: %w", err)
+	}
+
+	prWindows, err := schedule.ParseWindows(cfg.PRWindows)
+	if err != nil {
+		return nil, fmt.Errorf("parsing PR windows: %w", err)
 	}
 
-	// Initialize checker engine.
 	engine := checker.NewEngine(
 		registry,
 		templates,
@@ -67,15 +166,23 @@ func main() {
 		cfg.SkipArchived,
 		cfg.DryRun,
 		cfg.CustomPropertiesMode,
+		ownerTeams,
+		cfg.DeclineBackoff,
 	)
+	engine.SetPRWindows(prWindows)
 
-	// Initialize work queue.
-	queue := checker.NewQueue(cfg.QueueSize, logger)
-
-	// Initialize webhook handler.
-	webhookHandler := webhook.NewHandler(cfg.GitHubWebhookSecret, queue, logger)
+	return engine, nil
+}
 
-	// Initialize scheduler.
+// newScheduler creates the reconciliation scheduler, using the cron
+// schedule when one is configured and pacing passes by each installation's
+// rate limit.
+func newScheduler(
+	cfg *config.Config,
+	client *ghclient.GitHubClient,
+	queue *checker.Queue,
+	logger *slog.Logger,
+) (*scheduler.Scheduler, error) {
 	sched := scheduler.NewScheduler(
 		client,
 		queue,
@@ -84,30 +191,101 @@ func main() {
 		cfg.SkipForks,
 		cfg.SkipArchived,
 	)
+	sched.SetStatePath(cfg.ScheduleStatePath)
+	sched.SetPacing(scheduler.Pacing{
+		RepoCost: cfg.ReconcileRepoCost,
+		Reserve:  cfg.RateLimitThreshold,
+		Spread:   cfg.ReconcileSpread,
+		Limits:   client,
+	})
 
-	// Set up context for graceful shutdown.
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if cfg.ScheduleCron != "" {
+		cron, err := schedule.ParseCron(cfg.ScheduleCron)
+		if err != nil {
+			return nil, fmt.Errorf("parsing schedule: %w", err)
+		}
 
-	// Start work queue workers.
-	queue.Start(ctx, cfg.WorkerCount, engine, client)
+		sched.SetSchedule(cron)
+	}
 
-	// Start scheduler in background.
-	go sched.Start(ctx)
+	return sched, nil
+}
 
-	// Set up and start HTTP servers.
-	mainServer := newMainServer(cfg.ListenAddr, webhookHandler, queue)
-	metricsServer := newMetricsServer(cfg.MetricsAddr)
+// newQueue creates the work queue for the configured backend.
+func newQueue(cfg *config.Config, logger *slog.Logger) (*checker.Queue, error) {
+	var store checker.JobStore = checker.NewMemoryStore()
 
-	startServer(logger, mainServer, "main", cfg.ListenAddr, cancel)
-	startServer(logger, metricsServer, "metrics", cfg.MetricsAddr, cancel)
+	if cfg.QueueBackend == "bolt" {
+		boltStore, err := checker.OpenBoltStore(cfg.QueuePath)
+		if err != nil {
+			return nil, err
+		}
 
-	// Wait for shutdown signal.
-	awaitShutdown(ctx, logger)
-	cancel()
+		store = boltStore
+	}
 
-	// Graceful shutdown.
-	gracefulShutdown(logger, queue, mainServer, metricsServer)
+	queue, err := checker.NewQueueWithStore(cfg.QueueSize, store, logger)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+
+	queue.SetRetryPolicy(checker.RetryPolicy{
+		MaxAttempts: cfg.JobMaxAttempts,
+		BaseDelay:   cfg.JobRetryBaseDelay,
+		MaxDelay:    cfg.JobRetryMaxDelay,
+	})
+	queue.SetInstallationLimit(cfg.InstallationMaxWorkers)
+	queue.SetJobTimeout(cfg.JobTimeout)
+	queue.SetShutdownGrace(cfg.JobShutdownGrace)
+
+	return queue, nil
+}
+
+// newWebhookHandler creates the webhook handler with its secrets,
+// deduplication and, when a payload directory is configured, the payload
+// store.
+func newWebhookHandler(
+	cfg *config.Config,
+	queue *checker.Queue,
+	engine *checker.Engine,
+	client ghclient.Client,
+	logger *slog.Logger,
+) (*webhook.Handler, error) {
+	secrets := []string{cfg.GitHubWebhookSecret}
+
+	if cfg.GitHubWebhookSecretFile != "" {
+		fileSecrets, err := webhook.ReadSecretsFile(cfg.GitHubWebhookSecretFile)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets = fileSecrets
+	}
+
+	handler := webhook.NewHandler(secrets, queue, engine, client, logger)
+	handler.SetDedupeWindow(cfg.WebhookDedupeWindow)
+
+	if cfg.WebhookPayloadDir != "" {
+		store, err := webhook.OpenPayloadStore(cfg.WebhookPayloadDir, cfg.WebhookPayloadLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		handler.SetPayloadStore(store)
+	}
+
+	return handler, nil
+}
+
+// startWebhookHandler starts the webhook intake and, when secrets come from
+// a file, reloading them.
+func startWebhookHandler(ctx context.Context, cfg *config.Config, handler *webhook.Handler) {
+	handler.Start(ctx, cfg.WebhookIntakeSize, cfg.WebhookIntakeWorkers)
+
+	if cfg.GitHubWebhookSecretFile != "" {
+		go handler.WatchSecretsFile(ctx, cfg.GitHubWebhookSecretFile, cfg.WebhookSecretReloadInterval)
+	}
 }
 
 func newMainServer(addr string, webhookHandler http.Handler, queue *checker.Queue) *http.Server {
@@ -134,6 +312,20 @@ func newMetricsServer(addr string) *http.Server {
 	}
 }
 
+// newAdminServer serves the admin endpoints. They are not authenticated and
+// can replay webhooks and requeue jobs, so addr must not be reachable from
+// outside the pod; the Service does not expose it.
+func newAdminServer(addr string, adminHandler http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", adminHandler)
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
 func startServer(logger *slog.Logger, srv *http.Server, name, addr string, cancel context.CancelFunc) {
 	go func() {
 		logger.Info("server listening", "name", name, "addr", addr)
@@ -157,7 +349,14 @@ func awaitShutdown(ctx context.Context, logger *slog.Logger) {
 	}
 }
 
-func gracefulShutdown(logger *slog.Logger, queue *checker.Queue, servers ...*http.Server) {
+// gracefulShutdown stops the servers, then lets accepted webhook deliveries
+// reach the queue before stopping it.
+func gracefulShutdown(
+	logger *slog.Logger,
+	queue *checker.Queue,
+	webhookHandler *webhook.Handler,
+	servers ...*http.Server,
+) {
 	logger.Info("shutting down")
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
@@ -169,6 +368,10 @@ func gracefulShutdown(logger *slog.Logger, queue *checker.Queue, servers ...*htt
 		}
 	}
 
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), intakeDrainTimeout)
+	defer drainCancel()
+
+	webhookHandler.Stop(drainCtx)
 	queue.Stop()
 	logger.Info("repo-guardian stopped")
 }
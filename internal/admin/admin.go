@@ -0,0 +1,147 @@
+// Package admin provides operator HTTP endpoints. They are not
+// authenticated, so they are served on their own listener, bound to
+// localhost by default and not exposed by the Service.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/donaldgifford/repo-guardian/internal/checker"
+	"github.com/donaldgifford/repo-guardian/internal/webhook"
+)
+
+// Handler serves the admin endpoints:
+//
+//	GET  /admin/dead-letters              list jobs that exhausted their retries
+//	POST /admin/dead-letters/{id}/requeue move a dead-letter job back onto the queue
+//	GET  /admin/webhooks                  list stored webhook deliveries
+//	GET  /admin/webhooks/{id}             show a stored delivery with its payload
+//	POST /admin/webhooks/{id}/replay      process a stored delivery again
+type Handler struct {
+	queue    *checker.Queue
+	webhooks *webhook.Handler
+	logger   *slog.Logger
+	mux      *http.ServeMux
+}
+
+// NewHandler creates a new admin Handler. The webhook endpoints are only
+// served when webhooks is not nil.
+func NewHandler(queue *checker.Queue, webhooks *webhook.Handler, logger *slog.Logger) *Handler {
+	h := &Handler{
+		queue:    queue,
+		webhooks: webhooks,
+		logger:   logger,
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /admin/dead-letters", h.listDeadLetters)
+	h.mux.HandleFunc("POST /admin/dead-letters/{id}/requeue", h.requeueDeadLetter)
+
+	if webhooks != nil {
+		h.mux.HandleFunc("GET /admin/webhooks", h.listDeliveries)
+		h.mux.HandleFunc("GET /admin/webhooks/{id}", h.getDelivery)
+		h.mux.HandleFunc("POST /admin/webhooks/{id}/replay", h.replayDelivery)
+	}
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) listDeadLetters(w http.ResponseWriter, _ *http.Request) {
+	dead, err := h.queue.DeadLetters()
+	if err != nil {
+		h.logger.Error("failed to list dead letters", "error", err)
+		http.Error(w, "failed to list dead letters", http.StatusInternalServerError)
+
+		return
+	}
+
+	if dead == nil {
+		dead = []checker.DeadJob{}
+	}
+
+	h.writeJSON(w, http.StatusOK, dead)
+}
+
+func (h *Handler) requeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.queue.Requeue(id)
+
+	switch {
+	case errors.Is(err, checker.ErrDeadJobNotFound):
+		http.Error(w, "dead-letter job not found", http.StatusNotFound)
+	case err != nil:
+		h.logger.Error("failed to requeue dead letter", "job_id", id, "error", err)
+		http.Error(w, "failed to requeue job", http.StatusServiceUnavailable)
+	default:
+		h.writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+func (h *Handler) listDeliveries(w http.ResponseWriter, _ *http.Request) {
+	deliveries, err := h.webhooks.Deliveries()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []webhook.Delivery{}
+	}
+
+	h.writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *Handler) getDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhooks.Delivery(r.PathValue("id"))
+	if err != nil {
+		h.deliveryError(w, r.PathValue("id"), err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, delivery)
+}
+
+// replayDelivery feeds a stored delivery through the webhook handler, which
+// writes the response.
+func (h *Handler) replayDelivery(w http.ResponseWriter, r *http.Request) {
+	req, err := h.webhooks.ReplayRequest(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.deliveryError(w, r.PathValue("id"), err)
+		return
+	}
+
+	h.webhooks.ServeHTTP(w, req)
+}
+
+func (h *Handler) deliveryError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrDeliveryNotFound), errors.Is(err, webhook.ErrPayloadStoreDisabled):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.logger.Error("failed to load webhook delivery", "delivery_id", id, "error", err)
+		http.Error(w, "failed to load delivery", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to write admin response", "error", err)
+	}
+}
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/donaldgifford/repo-guardian/internal/checker"
+	"github.com/donaldgifford/repo-guardian/internal/webhook"
+)
+
+func testHandler(t *testing.T) (*Handler, *checker.Queue, uint64) {
+	t.Helper()
+
+	store := checker.NewMemoryStore()
+	job := checker.RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: checker.TriggerScheduler, Attempt: 5}
+
+	id, err := store.Add(job)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if err := store.Bury(checker.DeadJob{ID: id, Job: job, Error: "502 Bad Gateway", FailedAt: time.Now()}); err != nil {
+		t.Fatalf("Bury: %v", err)
+	}
+
+	q, err := checker.NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	return NewHandler(q, nil, slog.Default()), q, id
+}
+
+func TestListDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	h, _, id := testHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/dead-letters", http.NoBody))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var dead []checker.DeadJob
+	if err := json.NewDecoder(rec.Body).Decode(&dead); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	if len(dead) != 1 || dead[0].ID != id || dead[0].Error != "502 Bad Gateway" {
+		t.Errorf("unexpected dead letters: %+v", dead)
+	}
+}
+
+func TestRequeueDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	h, q, id := testHandler(t)
+
+	// Cases run in order: the second requeues the job the first already moved.
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "requeued", path: "/admin/dead-letters/" + strconv.FormatUint(id, 10) + "/requeue", want: http.StatusAccepted},
+		{name: "already requeued", path: "/admin/dead-letters/" + strconv.FormatUint(id, 10) + "/requeue", want: http.StatusNotFound},
+		{name: "invalid id", path: "/admin/dead-letters/abc/requeue", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, http.NoBody))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+
+	if q.Len() != 1 {
+		t.Errorf("expected requeued job to be pending, got %d", q.Len())
+	}
+
+	dead, err := q.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters: %v", err)
+	}
+
+	if len(dead) != 0 {
+		t.Errorf("expected empty dead-letter list, got %+v", dead)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	t.Parallel()
+
+	store, err := webhook.OpenPayloadStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("OpenPayloadStore: %v", err)
+	}
+
+	err = store.Save(webhook.Delivery{
+		ID:         "delivery-1",
+		Event:      "repository",
+		ReceivedAt: time.Now(),
+		Payload: json.RawMessage(`{"action":"created","repository":{"name":"repo","owner":{"login":"org"}},` +
+			`"installation":{"id":1}}`),
+	})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	q := checker.NewQueue(10, slog.Default())
+	webhooks := webhook.NewHandler([]string{"secret"}, q, nil, nil, slog.Default())
+	webhooks.SetPayloadStore(store)
+	webhooks.Start(t.Context(), 10, 1)
+
+	h := NewHandler(q, webhooks, slog.Default())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{name: "list", method: http.MethodGet, path: "/admin/webhooks", want: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/admin/webhooks/delivery-1", want: http.StatusOK},
+		{name: "get missing", method: http.MethodGet, path: "/admin/webhooks/delivery-2", want: http.StatusNotFound},
+		{name: "replay", method: http.MethodPost, path: "/admin/webhooks/delivery-1/replay", want: http.StatusAccepted},
+		{name: "replay missing", method: http.MethodPost, path: "/admin/webhooks/delivery-2/replay", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, http.NoBody))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+
+	// Stop returns once the accepted replay has been handled.
+	webhooks.Stop(t.Context())
+
+	if q.Len() != 1 {
+		t.Errorf("expected the replayed delivery to enqueue a job, got %d", q.Len())
+	}
+}
@@ -0,0 +1,214 @@
+package checker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// jobsBucket holds pending jobs keyed by big-endian ID, so iteration
+	// order is enqueue order.
+	jobsBucket = []byte("jobs")
+
+	// deadBucket holds dead-letter jobs, keyed like jobsBucket.
+	deadBucket = []byte("dead")
+)
+
+// BoltStore is a JobStore backed by a BoltDB file, intended to live on a
+// persistent volume so pending jobs survive pod restarts. Only one process
+// may open the file at a time.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens or creates the BoltDB file at path, creating its parent
+// directory if needed.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating queue directory: %w", err)
+	}
+
+	// A timeout stops a second replica from blocking forever on the lock.
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening queue file %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{jobsBucket, deadBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("initializing queue file %s: %w", path, err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Add persists job and returns its ID.
+func (s *BoltStore) Add(job RepoJob) (uint64, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return 0, fmt.Errorf("encoding job: %w", err)
+	}
+
+	var id uint64
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+
+		id, err = b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return b.Put(idKey(id), data)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storing job: %w", err)
+	}
+
+	return id, nil
+}
+
+// Remove deletes the job with the given ID.
+func (s *BoltStore) Remove(id uint64) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Delete(idKey(id))
+	})
+	if err != nil {
+		return fmt.Errorf("removing job %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// Pending returns the stored jobs in ID order.
+func (s *BoltStore) Pending() ([]StoredJob, error) {
+	var pending []StoredJob
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+			var job RepoJob
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("decoding job %x: %w", k, err)
+			}
+
+			pending = append(pending, StoredJob{ID: binary.BigEndian.Uint64(k), Job: job})
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading pending jobs: %w", err)
+	}
+
+	return pending, nil
+}
+
+// Bury moves a pending job to the dead-letter list in one transaction.
+func (s *BoltStore) Bury(dead DeadJob) error {
+	data, err := json.Marshal(dead)
+	if err != nil {
+		return fmt.Errorf("encoding dead-letter job: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(jobsBucket).Delete(idKey(dead.ID)); err != nil {
+			return err
+		}
+
+		return tx.Bucket(deadBucket).Put(idKey(dead.ID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("moving job %d to dead letters: %w", dead.ID, err)
+	}
+
+	return nil
+}
+
+// DeadLetters returns the dead-letter list in ID order.
+func (s *BoltStore) DeadLetters() ([]DeadJob, error) {
+	var dead []DeadJob
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadBucket).ForEach(func(k, v []byte) error {
+			var d DeadJob
+			if err := json.Unmarshal(v, &d); err != nil {
+				return fmt.Errorf("decoding dead-letter job %x: %w", k, err)
+			}
+
+			dead = append(dead, d)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading dead letters: %w", err)
+	}
+
+	return dead, nil
+}
+
+// Unbury removes and returns a dead-letter job.
+func (s *BoltStore) Unbury(id uint64) (DeadJob, bool, error) {
+	var (
+		dead  DeadJob
+		found bool
+	)
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deadBucket)
+
+		v := b.Get(idKey(id))
+		if v == nil {
+			return nil
+		}
+
+		if err := json.Unmarshal(v, &dead); err != nil {
+			return fmt.Errorf("decoding dead-letter job %d: %w", id, err)
+		}
+
+		found = true
+
+		return b.Delete(idKey(id))
+	})
+	if err != nil {
+		return DeadJob{}, false, fmt.Errorf("removing dead-letter job %d: %w", id, err)
+	}
+
+	return dead, found, nil
+}
+
+// Persistent returns true.
+func (*BoltStore) Persistent() bool {
+	return true
+}
+
+// Close closes the BoltDB file.
+func (s *BoltStore) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("closing queue file: %w", err)
+	}
+
+	return nil
+}
+
+func idKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+
+	return key
+}
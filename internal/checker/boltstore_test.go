@@ -0,0 +1,248 @@
+package checker
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+)
+
+func openTestBoltStore(t *testing.T, path string) *BoltStore {
+	t.Helper()
+
+	store, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatalf("OpenBoltStore: %v", err)
+	}
+
+	return store
+}
+
+func TestBoltStore_AddRemovePending(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queue", "queue.db")
+	store := openTestBoltStore(t, path)
+
+	job1 := RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerWebhook}
+	job2 := RepoJob{Owner: "org", Repo: "b", InstallationID: 2, Trigger: TriggerScheduler}
+
+	id1, err := store.Add(job1)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	id2, err := store.Add(job2)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if id2 <= id1 {
+		t.Errorf("IDs should increase, got %d then %d", id1, id2)
+	}
+
+	if err := store.Remove(id1); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Reopening sees only the job that was not removed.
+	store = openTestBoltStore(t, path)
+	defer store.Close()
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	if len(pending) != 1 || pending[0].ID != id2 || pending[0].Job != job2 {
+		t.Errorf("Pending = %+v, want job %d %+v", pending, id2, job2)
+	}
+
+	// IDs keep increasing across restarts.
+	id3, err := store.Add(job1)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if id3 <= id2 {
+		t.Errorf("ID after reopen should be greater than %d, got %d", id2, id3)
+	}
+}
+
+func TestQueue_ResumesAfterCrash(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queue.db")
+
+	// First process: enqueue jobs, then die before any worker runs. Closing
+	// the store without Stop mimics the process being killed.
+	store := openTestBoltStore(t, path)
+
+	q, err := NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	for _, repo := range []string{"a", "b", "c"} {
+		if err := q.Enqueue(RepoJob{Owner: "org", Repo: repo, InstallationID: 1, Trigger: TriggerScheduler}); err != nil {
+			t.Fatalf("Enqueue %s: %v", repo, err)
+		}
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Second process: the jobs are reloaded and processed.
+	q, err = NewQueueWithStore(10, openTestBoltStore(t, path), slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore after restart: %v", err)
+	}
+
+	if q.Len() != 3 {
+		t.Fatalf("expected 3 resumed jobs, got %d", q.Len())
+	}
+
+	engine := testEngine(true)
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+
+	q.Start(context.Background(), 2, engine, client)
+
+	deadline := time.After(5 * time.Second)
+	for client.processedJobs.Load() < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for resumed jobs: processed %d of 3", client.processedJobs.Load())
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	q.Stop()
+
+	// Third process: everything was acknowledged, nothing is resumed.
+	store = openTestBoltStore(t, path)
+	defer store.Close()
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	if len(pending) != 0 {
+		t.Errorf("expected no pending jobs after processing, got %+v", pending)
+	}
+}
+
+func TestQueue_UnprocessedJobsSurviveStop(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queue.db")
+
+	q, err := NewQueueWithStore(10, openTestBoltStore(t, path), slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerWebhook}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Stop without ever starting workers, as during a deploy that begins
+	// before the queue drains.
+	q.Stop()
+
+	store := openTestBoltStore(t, path)
+	defer store.Close()
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	if len(pending) != 1 || pending[0].Job.Repo != "a" {
+		t.Errorf("expected job for repo a to survive Stop, got %+v", pending)
+	}
+}
+
+func TestQueue_FullJobIsNotPersisted(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(1, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "a"})
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "b"}); err == nil {
+		t.Fatal("expected error when queue is full")
+	}
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	if len(pending) != 1 {
+		t.Errorf("rejected job should not be stored, got %+v", pending)
+	}
+}
+
+func TestBoltStore_DeadLetters(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queue.db")
+	store := openTestBoltStore(t, path)
+
+	job := RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerWebhook, Attempt: 5}
+
+	id, err := store.Add(job)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	failedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := store.Bury(DeadJob{ID: id, Job: job, Error: "502", FailedAt: failedAt}); err != nil {
+		t.Fatalf("Bury: %v", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	store = openTestBoltStore(t, path)
+	defer store.Close()
+
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("buried job should not be pending, got %+v", pending)
+	}
+
+	dead, err := store.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters: %v", err)
+	}
+
+	if len(dead) != 1 || dead[0].ID != id || !dead[0].FailedAt.Equal(failedAt) {
+		t.Fatalf("DeadLetters = %+v", dead)
+	}
+
+	got, ok, err := store.Unbury(id)
+	if err != nil || !ok || got.Job.Repo != "a" {
+		t.Fatalf("Unbury = %+v, %v, %v", got, ok, err)
+	}
+
+	if _, ok, _ := store.Unbury(id); ok {
+		t.Error("second Unbury should find nothing")
+	}
+}
@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"github.com/donaldgifford/repo-guardian/internal/catalog"
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+// CodeOwnerPlaceholder is written to CODEOWNERS when no team could be
+// resolved from the repository's catalog-info.yaml owner.
+const CodeOwnerPlaceholder = "@org/CHANGEME"
+
+// CodeOwner describes how the CODEOWNERS team for a repository was resolved.
+type CodeOwner struct {
+	// Handle is the resolved team handle (e.g. "@org/payments"), or empty
+	// when the placeholder is used.
+	Handle string
+
+	// CatalogOwner is spec.owner from catalog-info.yaml, if any.
+	CatalogOwner string
+
+	// Reason explains why the placeholder was used. Empty when Handle is set.
+	Reason string
+}
+
+// resolveCodeOwner maps the catalog owner to a GitHub team via the owner
+// mapping and verifies the team exists in the org. A failed lookup, e.g.
+// because the app lacks the Members read permission, is treated like a
+// missing team, so the PR is still opened with the placeholder.
+func (e *Engine) resolveCodeOwner(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner string,
+	props *catalog.Properties,
+) (*CodeOwner, error) {
+	if props.Owner == "" || props.Owner == catalog.DefaultOwner {
+		return &CodeOwner{Reason: "catalog-info.yaml does not define `spec.owner`"}, nil
+	}
+
+	result := &CodeOwner{CatalogOwner: props.Owner}
+
+	slug, ok := e.ownerTeams.TeamFor(props.Owner)
+	if !ok {
+		result.Reason = fmt.Sprintf("catalog owner `%s` has no GitHub team mapping", props.Owner)
+		return result, nil
+	}
+
+	exists, err := client.TeamExists(ctx, owner, slug)
+	if err != nil {
+		log.Warn("failed to look up CODEOWNERS team, using placeholder", "catalog_owner", props.Owner, "team", slug, "error", err)
+		metrics.ErrorsTotal.WithLabelValues("team_lookup").Inc()
+		result.Reason = fmt.Sprintf("team `@%s/%s`, mapped from catalog owner `%s`, could not be looked up", owner, slug, props.Owner)
+
+		return result, nil
+	}
+
+	if !exists {
+		log.Warn("mapped CODEOWNERS team does not exist", "catalog_owner", props.Owner, "team", slug)
+		result.Reason = fmt.Sprintf("catalog owner `%s` maps to team `@%s/%s`, which does not exist", props.Owner, owner, slug)
+
+		return result, nil
+	}
+
+	result.Handle = fmt.Sprintf("@%s/%s", owner, slug)
+
+	return result, nil
+}
+
+// needsCodeOwner reports whether any missing rule creates a CODEOWNERS file.
+func needsCodeOwner(missing []rules.FileRule) bool {
+	for i := range missing {
+		if isCodeOwnersRule(&missing[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isCodeOwnersRule(rule *rules.FileRule) bool {
+	return path.Base(rule.TargetPath) == "CODEOWNERS"
+}
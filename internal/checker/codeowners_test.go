@@ -0,0 +1,142 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/owners"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+const paymentsCatalogInfo = `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: repo
+spec:
+  owner: group:default/payments-team
+`
+
+func codeOwnersEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	ts := rules.NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	mapping, err := owners.Parse([]byte("teams:\n  group:default/payments-team: payments\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	return NewEngine(rules.NewRegistry(rules.DefaultRules), ts, slog.Default(), true, true, false, "", mapping, 0)
+}
+
+func codeOwnersClient(catalogInfo string) *mockClient {
+	client := newMockClient()
+	client.repo = &ghclient.Repository{
+		Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main",
+	}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.contents["org/repo/.github/dependabot.yml"] = true
+
+	if catalogInfo != "" {
+		client.fileContents["org/repo/catalog-info.yaml"] = catalogInfo
+	}
+
+	return client
+}
+
+func TestCheckRepo_CodeOwnersFromCatalogOwner(t *testing.T) {
+	t.Parallel()
+
+	engine := codeOwnersEngine(t)
+	client := codeOwnersClient(paymentsCatalogInfo)
+	client.teams["org/payments"] = true
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	content := client.fileWrites[".github/CODEOWNERS"]
+	if !strings.Contains(content, "* @org/payments") || strings.Contains(content, CodeOwnerPlaceholder) {
+		t.Errorf("CODEOWNERS should assign the mapped team, got:\n%s", content)
+	}
+
+	if client.createdPR == nil {
+		t.Fatal("expected PR to be created")
+	}
+
+	if strings.Contains(client.createdPRBody, CodeOwnerPlaceholder) {
+		t.Error("PR body should not mention the placeholder when a team was resolved")
+	}
+
+	if !strings.Contains(client.createdPRBody, "@org/payments") {
+		t.Error("PR body should name the resolved team")
+	}
+}
+
+func TestCheckRepo_CodeOwnersPlaceholderFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		catalogInfo string
+		teamErr     error
+		wantReason  string
+	}{
+		{name: "team missing", catalogInfo: paymentsCatalogInfo, wantReason: "does not exist"},
+		{
+			name:        "team lookup fails",
+			catalogInfo: paymentsCatalogInfo,
+			teamErr:     errors.New("403 Resource not accessible by integration"),
+			wantReason:  "could not be looked up",
+		},
+		{name: "no catalog owner", catalogInfo: "", wantReason: "does not define `spec.owner`"},
+		{
+			name:        "user owner",
+			catalogInfo: strings.Replace(paymentsCatalogInfo, "group:default/payments-team", "user:default/jdoe", 1),
+			wantReason:  "has no GitHub team mapping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := codeOwnersEngine(t)
+			client := codeOwnersClient(tt.catalogInfo)
+			client.teamErr = tt.teamErr
+
+			if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+				t.Fatalf("CheckRepo: %v", err)
+			}
+
+			if !strings.Contains(client.fileWrites[".github/CODEOWNERS"], "* "+CodeOwnerPlaceholder) {
+				t.Errorf("CODEOWNERS should use the placeholder, got:\n%s", client.fileWrites[".github/CODEOWNERS"])
+			}
+
+			if client.createdPR == nil {
+				t.Fatal("expected PR to be created")
+			}
+
+			if !strings.Contains(client.createdPRBody, tt.wantReason) {
+				t.Errorf("PR body should explain %q, got:\n%s", tt.wantReason, client.createdPRBody)
+			}
+		})
+	}
+}
+
+func TestBuildPRBody_NoCodeOwnersNote(t *testing.T) {
+	t.Parallel()
+
+	missing := []rules.FileRule{{Name: "Dependabot", TargetPath: ".github/dependabot.yml"}}
+
+	if body := BuildPRBody(missing, nil, nil); strings.Contains(body, CodeOwnerPlaceholder) {
+		t.Error("PR body should not mention CODEOWNERS when it is not being added")
+	}
+}
@@ -0,0 +1,120 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+// FilePatch is a proposed change to an existing file, either because it
+// fails content assertions or because a managed file has drifted.
+type FilePatch struct {
+	// Rule is the rule whose assertions failed.
+	Rule rules.FileRule
+
+	// Path is the existing file being patched.
+	Path string
+
+	// Content is the patched file content.
+	Content string
+
+	// Failed lists every assertion that failed, including those without a
+	// fix that need manual attention.
+	Failed []rules.Assertion
+
+	// Managed is true when the patch updates a managed file to the current
+	// template rather than fixing assertions.
+	Managed bool
+
+	// Invalid is why the file could not be fully checked, e.g. a YAML syntax
+	// error.
+	Invalid error
+
+	// ReportOnly is true when nothing could be fixed and the patch only
+	// reports Failed and Invalid in the PR body. It is not committed.
+	ReportOnly bool
+}
+
+// checkContent evaluates the rule's assertions against the existing file at
+// path. It returns false when the file satisfies every assertion or none of
+// the failures can be fixed automatically.
+func (*Engine) checkContent(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+	rule *rules.FileRule,
+	path string,
+) (FilePatch, bool, error) {
+	if len(rule.Assertions) == 0 {
+		return FilePatch{}, false, nil
+	}
+
+	content, err := client.GetFileContent(ctx, owner, repo, path)
+	if err != nil {
+		return FilePatch{}, false, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	result, err := rules.CheckAssertions(content, rule.Assertions)
+	if err != nil {
+		return FilePatch{}, false, fmt.Errorf("checking content of %s: %w", path, err)
+	}
+
+	if len(result.Failed) == 0 {
+		log.Debug("content assertions passed", "path", path)
+		return FilePatch{}, false, nil
+	}
+
+	if result.Invalid != nil {
+		log.Warn("file is not valid YAML, its YAML assertions failed", "path", path, "error", result.Invalid)
+	}
+
+	for i := range result.Failed {
+		metrics.ContentAssertionsFailedTotal.WithLabelValues(rule.Name).Inc()
+		log.Info("content assertion failed",
+			"path", path,
+			"assertion", result.Failed[i].Description,
+			"fixable", result.Failed[i].Fix != "",
+		)
+	}
+
+	patch := FilePatch{
+		Rule:    *rule,
+		Path:    path,
+		Content: result.Content,
+		Failed:  result.Failed,
+		Invalid: result.Invalid,
+	}
+
+	if !result.Patched(content) {
+		log.Warn("content assertions failed with no automatic fix", "path", path)
+
+		// An invalid file is still reported in the PR body.
+		patch.ReportOnly = true
+
+		return patch, result.Invalid != nil, nil
+	}
+
+	return patch, true, nil
+}
+
+// hasChanges reports whether any of patches changes a file.
+func hasChanges(patches []FilePatch) bool {
+	return slices.ContainsFunc(patches, func(p FilePatch) bool { return !p.ReportOnly })
+}
+
+func patchPaths(patches []FilePatch) []string {
+	paths := make([]string, 0, len(patches))
+	for i := range patches {
+		if !patches[i].ReportOnly {
+			paths = append(paths, patches[i].Path)
+		}
+	}
+
+	return paths
+}
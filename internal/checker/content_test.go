@@ -0,0 +1,205 @@
+package checker
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+func contentRulesEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	ts := rules.NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	rr := []rules.FileRule{
+		{
+			Name:                "CODEOWNERS",
+			Paths:               []string{"CODEOWNERS", ".github/CODEOWNERS"},
+			PRSearchTerms:       []string{"codeowners"},
+			DefaultTemplateName: "codeowners",
+			TargetPath:          ".github/CODEOWNERS",
+			Assertions: []rules.Assertion{
+				{Description: "catch-all `*` owner", Pattern: `^\*\s+@`},
+			},
+			Enabled: true,
+		},
+		{
+			Name:                "Dependabot",
+			Paths:               []string{".github/dependabot.yml"},
+			PRSearchTerms:       []string{"dependabot"},
+			DefaultTemplateName: "dependabot",
+			TargetPath:          ".github/dependabot.yml",
+			Assertions: []rules.Assertion{
+				{
+					Description: "github-actions ecosystem",
+					YAMLPath:    "updates[].package-ecosystem",
+					Equals:      "github-actions",
+					Fix:         "package-ecosystem: \"github-actions\"\ndirectory: \"/\"\nschedule:\n  interval: \"weekly\"\n",
+				},
+			},
+			Enabled: true,
+		},
+	}
+
+	return NewEngine(rules.NewRegistry(rr), ts, slog.Default(), true, true, false, "", nil, 0)
+}
+
+func contentRulesClient() *mockClient {
+	client := newMockClient()
+	client.repo = &ghclient.Repository{
+		Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main",
+	}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.contents["org/repo/.github/CODEOWNERS"] = true
+	client.contents["org/repo/.github/dependabot.yml"] = true
+	client.fileContents["org/repo/.github/CODEOWNERS"] = "* @org/team\n"
+	client.fileContents["org/repo/.github/dependabot.yml"] =
+		"version: 2\nupdates:\n  - package-ecosystem: \"gomod\"\n    directory: \"/\"\n"
+
+	return client
+}
+
+func TestCheckRepo_ContentAssertionPatchesExistingFile(t *testing.T) {
+	t.Parallel()
+
+	engine := contentRulesEngine(t)
+	client := contentRulesClient()
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if len(client.createdFiles) != 1 || client.createdFiles[0] != ".github/dependabot.yml" {
+		t.Fatalf("expected only dependabot.yml to be patched, got %v", client.createdFiles)
+	}
+
+	patched := client.fileWrites[".github/dependabot.yml"]
+	if !strings.Contains(patched, `package-ecosystem: "gomod"`) ||
+		!strings.Contains(patched, `package-ecosystem: "github-actions"`) {
+		t.Errorf("patch should keep gomod and add github-actions, got:\n%s", patched)
+	}
+
+	if client.createdPR == nil {
+		t.Fatal("expected PR to be created")
+	}
+
+	if !strings.Contains(client.createdPRBody, "### Updated Files") ||
+		!strings.Contains(client.createdPRBody, "github-actions ecosystem") {
+		t.Errorf("PR body should describe the patch, got:\n%s", client.createdPRBody)
+	}
+
+	if strings.Contains(client.createdPRBody, "### Added Files") {
+		t.Error("PR body should not list added files when nothing is missing")
+	}
+}
+
+func TestCheckRepo_ContentAssertionsSatisfied(t *testing.T) {
+	t.Parallel()
+
+	engine := contentRulesEngine(t)
+	client := contentRulesClient()
+	client.fileContents["org/repo/.github/dependabot.yml"] =
+		"version: 2\nupdates:\n  - package-ecosystem: \"github-actions\"\n    directory: \"/\"\n"
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if client.createdPR != nil {
+		t.Error("should not create PR when all assertions pass")
+	}
+}
+
+func TestCheckRepo_ContentAssertionWithoutFix(t *testing.T) {
+	t.Parallel()
+
+	engine := contentRulesEngine(t)
+	client := contentRulesClient()
+	client.fileContents["org/repo/.github/CODEOWNERS"] = "/docs @org/docs\n"
+	client.fileContents["org/repo/.github/dependabot.yml"] =
+		"version: 2\nupdates:\n  - package-ecosystem: \"github-actions\"\n    directory: \"/\"\n"
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if client.createdPR != nil {
+		t.Error("should not create PR when the failed assertion has no fix")
+	}
+}
+
+func TestCheckRepo_ContentAssertionInvalidYAML(t *testing.T) {
+	t.Parallel()
+
+	engine := contentRulesEngine(t)
+	client := contentRulesClient()
+	client.contents["org/repo/.github/CODEOWNERS"] = false
+	client.fileContents["org/repo/.github/dependabot.yml"] = "version: 2\nupdates: [unclosed\n"
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	// The other rules are still checked.
+	if len(client.createdFiles) != 1 || client.createdFiles[0] != ".github/CODEOWNERS" {
+		t.Fatalf("expected only CODEOWNERS to be committed, got %v", client.createdFiles)
+	}
+
+	if client.createdPR == nil {
+		t.Fatal("expected PR to be created")
+	}
+
+	for _, want := range []string{"### Files Needing Attention", "`.github/dependabot.yml` — Dependabot: not valid YAML"} {
+		if !strings.Contains(client.createdPRBody, want) {
+			t.Errorf("PR body should contain %q, got:\n%s", want, client.createdPRBody)
+		}
+	}
+
+	if strings.Contains(client.createdPRBody, "### Updated Files") {
+		t.Error("PR body should not list the invalid file as updated")
+	}
+}
+
+func TestCheckRepo_ContentAssertionInvalidYAMLOnly(t *testing.T) {
+	t.Parallel()
+
+	engine := contentRulesEngine(t)
+	client := contentRulesClient()
+	client.fileContents["org/repo/.github/dependabot.yml"] = "version: 2\nupdates: [unclosed\n"
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if client.createdPR != nil {
+		t.Error("should not create PR when there is nothing to change")
+	}
+}
+
+func TestBuildPRBody_UpdatedFiles(t *testing.T) {
+	t.Parallel()
+
+	patches := []FilePatch{{
+		Rule: rules.FileRule{Name: "Gitignore"},
+		Path: ".gitignore",
+		Failed: []rules.Assertion{
+			{Description: "ignores .env", Fix: ".env"},
+			{Description: "ignores build output"},
+		},
+	}}
+
+	body := BuildPRBody(nil, patches, nil)
+
+	for _, want := range []string{"`.gitignore` — Gitignore", "  - ignores .env\n", "ignores build output (not fixed automatically"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body should contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+)
+
+// SetJobTimeout bounds how long one job may run; a job still running at the
+// deadline is canceled and fails with a retryable error. 0, the default,
+// means no deadline. It must be called before Start.
+func (q *Queue) SetJobTimeout(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.jobTimeout = d
+}
+
+// SetShutdownGrace sets how long Stop waits for in-flight jobs before
+// aborting them. 0, the default, aborts them immediately. It must be called
+// before Start.
+func (q *Queue) SetShutdownGrace(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.shutdownGrace = d
+}
+
+// runJob processes job under the per-job deadline.
+func (q *Queue) runJob(
+	ctx context.Context,
+	log *slog.Logger,
+	engine *Engine,
+	ghClient ghclient.Client,
+	job RepoJob,
+) error {
+	if q.jobTimeout <= 0 {
+		return processJob(ctx, log, engine, ghClient, job)
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, q.jobTimeout)
+	defer cancel()
+
+	err := processJob(jobCtx, log, engine, ghClient, job)
+	if err == nil || ctx.Err() != nil || !errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	metrics.JobsTimedOutTotal.WithLabelValues(string(job.Trigger)).Inc()
+	log.Error("job timed out",
+		"owner", job.Owner,
+		"repo", job.Repo,
+		"trigger", job.Trigger,
+		"timeout", q.jobTimeout,
+	)
+
+	return fmt.Errorf("job exceeded its %s deadline: %w", q.jobTimeout, err)
+}
+
+// drain waits for the workers to exit. Once the shutdown grace period has
+// passed, jobs still in flight are aborted.
+func (q *Queue) drain() {
+	finished := make(chan struct{})
+
+	go func() {
+		q.wg.Wait()
+		close(finished)
+	}()
+
+	q.mu.Lock()
+	grace, abort := q.shutdownGrace, q.abortJobs
+	q.mu.Unlock()
+
+	if abort == nil {
+		<-finished
+		return
+	}
+
+	defer abort()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-finished:
+		return
+	case <-timer.C:
+	}
+
+	q.mu.Lock()
+	q.aborted = true
+	inFlight := len(q.inFlight)
+	q.mu.Unlock()
+
+	q.logger.Warn("shutdown grace period expired, aborting in-flight jobs", "in_flight", inFlight, "grace", grace)
+	abort()
+	<-finished
+}
+
+// abandon handles a job that failed because shutdown aborted it. A persistent
+// store keeps the job, unchanged, for the next process to resume; otherwise
+// the job is lost. The caller must hold q.mu.
+func (q *Queue) abandon(log *slog.Logger, qj *queuedJob, jobErr error) error {
+	if q.store.Persistent() {
+		metrics.JobsAbortedTotal.WithLabelValues("requeued").Inc()
+		log.Warn("job aborted by shutdown, it will resume after restart",
+			"owner", qj.job.Owner,
+			"repo", qj.job.Repo,
+			"job_id", qj.id,
+		)
+
+		return nil
+	}
+
+	metrics.JobsAbortedTotal.WithLabelValues("dropped").Inc()
+	log.Error("job aborted by shutdown",
+		"owner", qj.job.Owner,
+		"repo", qj.job.Repo,
+		"error", jobErr,
+	)
+
+	return q.store.Remove(qj.id)
+}
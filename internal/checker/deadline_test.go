@@ -0,0 +1,130 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+)
+
+func TestRunJob_TimesOut(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	client.hangGetRepo = true
+
+	q := NewQueue(10, slog.Default())
+	q.SetJobTimeout(50 * time.Millisecond)
+
+	job := RepoJob{Owner: "org", Repo: "hung", InstallationID: 1, Trigger: TriggerWebhook}
+
+	err := q.runJob(context.Background(), slog.Default(), testEngine(true), client, job)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	// A timed-out job is retried like any other transient failure.
+	if !ghclient.IsRetryable(err) {
+		t.Errorf("timed-out job should be retryable: %v", err)
+	}
+}
+
+// startHungJob starts q with one worker and waits until a job that never
+// finishes on its own is in flight.
+func startHungJob(t *testing.T, q *Queue) {
+	t.Helper()
+
+	client := newMockClient()
+	client.hangGetRepo = true
+
+	q.SetShutdownGrace(50 * time.Millisecond)
+	q.Start(context.Background(), 1, testEngine(true), client)
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "hung", InstallationID: 1, Trigger: TriggerWebhook}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	deadline := time.After(5 * time.Second)
+
+	for {
+		q.mu.Lock()
+		inFlight := len(q.inFlight)
+		q.mu.Unlock()
+
+		if inFlight == 1 {
+			return
+		}
+
+		select {
+		case <-deadline:
+			t.Fatal("job was never dispatched")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func stopWithin(t *testing.T, q *Queue, limit time.Duration) {
+	t.Helper()
+
+	done := make(chan struct{})
+
+	go func() {
+		q.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(limit):
+		t.Fatal("Stop did not abort the hung job")
+	}
+}
+
+func TestStop_RequeuesAbortedJobInPersistentStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queue.db")
+
+	q, err := NewQueueWithStore(10, openTestBoltStore(t, path), slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	startHungJob(t, q)
+	stopWithin(t, q, 5*time.Second)
+
+	store := openTestBoltStore(t, path)
+	defer store.Close()
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	// The job resumes after restart without using up a retry attempt.
+	if len(pending) != 1 || pending[0].Job.Repo != "hung" || pending[0].Job.Attempt != 0 {
+		t.Errorf("expected aborted job to stay pending unchanged, got %+v", pending)
+	}
+}
+
+func TestStop_DropsAbortedJobInMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	startHungJob(t, q)
+	stopWithin(t, q, 5*time.Second)
+
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("aborted job should not be retried, got %+v", pending)
+	}
+}
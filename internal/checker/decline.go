@@ -0,0 +1,193 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+// PRLabel is applied to every pull request repo-guardian opens. While a PR
+// that was closed without merging still carries it, the rules it proposed
+// are treated as declined; removing the label lifts the decline.
+const PRLabel = "repo-guardian"
+
+// WithdrawnLabel marks a PR that repo-guardian closed itself because it no
+// longer looks after the repository. Such a PR is never a decline.
+const WithdrawnLabel = "repo-guardian-withdrawn"
+
+// rulesMarkerPattern extracts the rule names recorded in a PR body by
+// rulesMarker.
+var rulesMarkerPattern = regexp.MustCompile(`<!-- repo-guardian:rules (\[.*?\]) -->`)
+
+// Decline records that a team closed a repo-guardian PR without merging it.
+type Decline struct {
+	// PRNumber is the closed pull request.
+	PRNumber int
+
+	// ClosedAt is when the pull request was closed.
+	ClosedAt time.Time
+
+	// Until is when the decline expires. Zero means it never expires and
+	// only removing PRLabel from the PR lifts it.
+	Until time.Time
+}
+
+// Active reports whether the decline still applies at now.
+func (d *Decline) Active(now time.Time) bool {
+	return d.Until.IsZero() || now.Before(d.Until)
+}
+
+// expiry formats Until for logs.
+func (d *Decline) expiry() string {
+	if d.Until.IsZero() {
+		return "never"
+	}
+
+	return d.Until.Format(time.RFC3339)
+}
+
+// findDeclines returns the most recent decline for each rule named in a
+// closed, unmerged, still-labeled repo-guardian PR, keyed by rule name.
+func (e *Engine) findDeclines(ctx context.Context, client ghclient.Client, owner, repo string) (map[string]*Decline, error) {
+	closed, err := client.ListClosedPullRequests(ctx, owner, repo, BranchName)
+	if err != nil {
+		return nil, fmt.Errorf("listing closed PRs: %w", err)
+	}
+
+	declines := make(map[string]*Decline)
+
+	for _, pr := range closed {
+		if !isDecline(pr) {
+			continue
+		}
+
+		d := &Decline{PRNumber: pr.Number, ClosedAt: pr.ClosedAt}
+		if e.declineBackoff > 0 {
+			d.Until = pr.ClosedAt.Add(e.declineBackoff)
+		}
+
+		for _, name := range parseRulesMarker(pr.Body) {
+			if prev, ok := declines[name]; ok && !prev.ClosedAt.Before(d.ClosedAt) {
+				continue
+			}
+
+			declines[name] = d
+		}
+	}
+
+	return declines, nil
+}
+
+// isDecline reports whether a closed PR records a decline: it was not
+// merged, still carries PRLabel and was not withdrawn by repo-guardian.
+func isDecline(pr *ghclient.PullRequest) bool {
+	return !pr.Merged && slices.Contains(pr.Labels, PRLabel) && !slices.Contains(pr.Labels, WithdrawnLabel)
+}
+
+// skipDeclined removes missing rules and patches whose rule has an active
+// decline. Closed PRs are only listed when there is something to propose.
+func (e *Engine) skipDeclined(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+	missing []rules.FileRule,
+	patches []FilePatch,
+) ([]rules.FileRule, []FilePatch, error) {
+	if len(missing) == 0 && len(patches) == 0 {
+		return missing, patches, nil
+	}
+
+	declines, err := e.findDeclines(ctx, client, owner, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(declines) == 0 {
+		return missing, patches, nil
+	}
+
+	now := e.now()
+
+	declined := func(rule *rules.FileRule) bool {
+		d, ok := declines[rule.Name]
+		if !ok {
+			return false
+		}
+
+		ruleLog := log.With("rule", rule.Name, "pr_number", d.PRNumber, "declined_at", d.ClosedAt, "expires", d.expiry())
+
+		if !d.Active(now) {
+			ruleLog.Info("decline expired, proposing rule again")
+			metrics.RuleDeclinesTotal.WithLabelValues(rule.Name, "expired").Inc()
+
+			return false
+		}
+
+		ruleLog.Info("rule declined in closed PR, skipping")
+		metrics.RuleDeclinesTotal.WithLabelValues(rule.Name, "active").Inc()
+
+		return true
+	}
+
+	keptMissing := make([]rules.FileRule, 0, len(missing))
+
+	for i := range missing {
+		if !declined(&missing[i]) {
+			keptMissing = append(keptMissing, missing[i])
+		}
+	}
+
+	keptPatches := make([]FilePatch, 0, len(patches))
+
+	for i := range patches {
+		if !declined(&patches[i].Rule) {
+			keptPatches = append(keptPatches, patches[i])
+		}
+	}
+
+	return keptMissing, keptPatches, nil
+}
+
+// rulesMarker returns a hidden comment recording which rules a PR proposes,
+// so a later decline can be attributed to them.
+func rulesMarker(missing []rules.FileRule, patches []FilePatch) string {
+	names := ruleNames(missing)
+	for i := range patches {
+		// Report-only patches propose nothing that could be declined.
+		if !patches[i].ReportOnly && !slices.Contains(names, patches[i].Rule.Name) {
+			names = append(names, patches[i].Rule.Name)
+		}
+	}
+
+	data, err := json.Marshal(names)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("<!-- repo-guardian:rules %s -->\n", data)
+}
+
+// parseRulesMarker returns the rule names recorded by rulesMarker, or nil if
+// the body has no marker.
+func parseRulesMarker(body string) []string {
+	m := rulesMarkerPattern.FindStringSubmatch(body)
+	if m == nil {
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal([]byte(m[1]), &names); err != nil {
+		return nil
+	}
+
+	return names
+}
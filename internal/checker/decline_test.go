@@ -0,0 +1,239 @@
+package checker
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+var declineNow = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func declineEngine(backoff time.Duration) *Engine {
+	e := testEngine(false)
+	e.declineBackoff = backoff
+	e.now = func() time.Time { return declineNow }
+
+	return e
+}
+
+func declinedPR(number int, closedAt time.Time, labels []string, ruleNames ...string) *ghclient.PullRequest {
+	missing := make([]rules.FileRule, len(ruleNames))
+	for i, name := range ruleNames {
+		missing[i] = rules.FileRule{Name: name}
+	}
+
+	return &ghclient.PullRequest{
+		Number:   number,
+		Head:     BranchName,
+		State:    "closed",
+		Body:     "body\n" + rulesMarker(missing, nil),
+		ClosedAt: closedAt,
+		Labels:   labels,
+	}
+}
+
+func TestCheckRepo_DeclinedRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		backoff   time.Duration
+		closedPR  *ghclient.PullRequest
+		wantFiles []string
+	}{
+		{
+			name:      "no closed PRs",
+			backoff:   24 * time.Hour,
+			wantFiles: []string{".github/CODEOWNERS", ".github/dependabot.yml"},
+		},
+		{
+			name:      "active decline skips rule",
+			backoff:   24 * time.Hour,
+			closedPR:  declinedPR(4, declineNow.Add(-time.Hour), []string{PRLabel}, "CODEOWNERS"),
+			wantFiles: []string{".github/dependabot.yml"},
+		},
+		{
+			name:      "expired decline proposes rule again",
+			backoff:   24 * time.Hour,
+			closedPR:  declinedPR(4, declineNow.Add(-48*time.Hour), []string{PRLabel}, "CODEOWNERS"),
+			wantFiles: []string{".github/CODEOWNERS", ".github/dependabot.yml"},
+		},
+		{
+			name:      "forever while labeled",
+			backoff:   0,
+			closedPR:  declinedPR(4, declineNow.Add(-365*24*time.Hour), []string{PRLabel}, "CODEOWNERS"),
+			wantFiles: []string{".github/dependabot.yml"},
+		},
+		{
+			name:      "withdrawn PR is not a decline",
+			backoff:   0,
+			closedPR:  declinedPR(4, declineNow.Add(-time.Hour), []string{PRLabel, WithdrawnLabel}, "CODEOWNERS"),
+			wantFiles: []string{".github/CODEOWNERS", ".github/dependabot.yml"},
+		},
+		{
+			name:      "label removed lifts decline",
+			backoff:   0,
+			closedPR:  declinedPR(4, declineNow.Add(-time.Hour), nil, "CODEOWNERS"),
+			wantFiles: []string{".github/CODEOWNERS", ".github/dependabot.yml"},
+		},
+		{
+			name:    "merged PR is not a decline",
+			backoff: 0,
+			closedPR: func() *ghclient.PullRequest {
+				pr := declinedPR(4, declineNow.Add(-time.Hour), []string{PRLabel}, "CODEOWNERS")
+				pr.Merged = true
+
+				return pr
+			}(),
+			wantFiles: []string{".github/CODEOWNERS", ".github/dependabot.yml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := declineEngine(tt.backoff)
+			client := newMockClient()
+			client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+			client.branchSHAs["org/repo/main"] = "abc123"
+
+			if tt.closedPR != nil {
+				client.closedPRs = []*ghclient.PullRequest{tt.closedPR}
+			}
+
+			if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+				t.Fatalf("CheckRepo: %v", err)
+			}
+
+			if !slices.Equal(client.createdFiles, tt.wantFiles) {
+				t.Errorf("created files = %v, want %v", client.createdFiles, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestCheckRepo_AllRulesDeclined(t *testing.T) {
+	t.Parallel()
+
+	engine := declineEngine(0)
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.closedPRs = []*ghclient.PullRequest{
+		declinedPR(4, declineNow.Add(-time.Hour), []string{PRLabel}, "CODEOWNERS", "Dependabot"),
+	}
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if client.createdPR != nil || len(client.createdBranches) != 0 {
+		t.Error("expected no branch or PR when every rule is declined")
+	}
+}
+
+func TestCheckRepo_RuleAddedToOpenPRDeclined(t *testing.T) {
+	t.Parallel()
+
+	engine := declineEngine(0)
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.branchSHAs["org/repo/"+BranchName] = "def456"
+
+	// The open PR was created when only CODEOWNERS was missing.
+	pr := declinedPR(4, time.Time{}, []string{PRLabel}, "CODEOWNERS")
+	pr.Title, pr.State = PRTitle, "open"
+	client.openPRs = []*ghclient.PullRequest{pr}
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	body, ok := client.updatedPRBodies[4]
+	if !ok {
+		t.Fatal("expected the PR body to be updated with the added rule")
+	}
+
+	if got := parseRulesMarker(body); !slices.Equal(got, []string{"CODEOWNERS", "Dependabot"}) {
+		t.Errorf("rules marker = %v, want [CODEOWNERS Dependabot]", got)
+	}
+
+	// The team closes the updated PR: neither rule is proposed again.
+	pr.Body, pr.State, pr.ClosedAt = body, "closed", declineNow.Add(-time.Hour)
+	client.openPRs, client.closedPRs = nil, []*ghclient.PullRequest{pr}
+	client.createdFiles = nil
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if client.createdPR != nil || len(client.createdFiles) != 0 {
+		t.Errorf("declined rules were proposed again: PR %+v, files %v", client.createdPR, client.createdFiles)
+	}
+}
+
+func TestFindDeclines_NewestWins(t *testing.T) {
+	t.Parallel()
+
+	engine := declineEngine(24 * time.Hour)
+	client := newMockClient()
+	client.closedPRs = []*ghclient.PullRequest{
+		declinedPR(3, declineNow.Add(-72*time.Hour), []string{PRLabel}, "CODEOWNERS"),
+		declinedPR(7, declineNow.Add(-time.Hour), []string{PRLabel}, "CODEOWNERS"),
+	}
+
+	declines, err := engine.findDeclines(context.Background(), client, "org", "repo")
+	if err != nil {
+		t.Fatalf("findDeclines: %v", err)
+	}
+
+	d, ok := declines["CODEOWNERS"]
+	if !ok {
+		t.Fatal("expected CODEOWNERS decline")
+	}
+
+	if d.PRNumber != 7 || !d.Active(declineNow) {
+		t.Errorf("expected active decline from PR 7, got %+v", d)
+	}
+}
+
+func TestCreateOrUpdatePR_LabelsNewPR(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+	client.branchSHAs["org/repo/main"] = "abc123"
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if !slices.Contains(client.addedLabels[client.createdPR.Number], PRLabel) {
+		t.Errorf("expected PR to be labeled %q, got %v", PRLabel, client.addedLabels)
+	}
+
+	if got := parseRulesMarker(client.createdPRBody); !slices.Equal(got, []string{"CODEOWNERS", "Dependabot"}) {
+		t.Errorf("PR body rules marker = %v", got)
+	}
+}
+
+func TestParseRulesMarker(t *testing.T) {
+	t.Parallel()
+
+	body := rulesMarker([]rules.FileRule{{Name: "a --> b"}}, []FilePatch{{Rule: rules.FileRule{Name: "c"}}})
+
+	if got := parseRulesMarker(body); !slices.Equal(got, []string{"a --> b", "c"}) {
+		t.Errorf("parseRulesMarker = %v", got)
+	}
+
+	if got := parseRulesMarker("no marker"); got != nil {
+		t.Errorf("expected nil without marker, got %v", got)
+	}
+}
@@ -8,10 +8,15 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/donaldgifford/repo-guardian/internal/catalog"
 	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
 	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/owners"
+	"github.com/donaldgifford/repo-guardian/internal/repoconfig"
 	"github.com/donaldgifford/repo-guardian/internal/rules"
+	"github.com/donaldgifford/repo-guardian/internal/schedule"
 )
 
 const (
@@ -32,15 +37,24 @@ type Engine struct {
 	skipArchived         bool
 	dryRun               bool
 	customPropertiesMode string
+	ownerTeams           *owners.Mapping
+	declineBackoff       time.Duration
+	prWindows            schedule.Windows
+	now                  func() time.Time
 }
 
-// NewEngine creates a new checker Engine.
+// NewEngine creates a new checker Engine. ownerTeams maps catalog owners to
+// GitHub teams for generated CODEOWNERS files; nil always uses the placeholder.
+// declineBackoff is how long rules from a PR closed without merging are left
+// alone; zero means until PRLabel is removed from that PR.
 func NewEngine(
 	registry *rules.Registry,
 	templates *rules.TemplateStore,
 	logger *slog.Logger,
 	skipForks, skipArchived, dryRun bool,
 	customPropertiesMode string,
+	ownerTeams *owners.Mapping,
+	declineBackoff time.Duration,
 ) *Engine {
 	return &Engine{
 		registry:             registry,
@@ -50,6 +64,9 @@ func NewEngine(
 		skipArchived:         skipArchived,
 		dryRun:               dryRun,
 		customPropertiesMode: customPropertiesMode,
+		ownerTeams:           ownerTeams,
+		declineBackoff:       declineBackoff,
+		now:                  time.Now,
 	}
 }
 
@@ -58,42 +75,123 @@ func NewEngine(
 func (e *Engine) CheckRepo(ctx context.Context, client ghclient.Client, owner, repo string) error {
 	log := e.logger.With("owner", owner, "repo", repo)
 
-	// Get repository metadata.
-	repoInfo, err := client.GetRepository(ctx, owner, repo)
-	if err != nil {
-		return fmt.Errorf("getting repository info: %w", err)
-	}
-
-	// Authoritative skip checks — the scheduler pre-filters as an
-	// optimization, but the engine is the single source of truth.
-	if skip, reason := e.shouldSkip(repoInfo); skip {
-		log.Info(reason)
-		return nil
+	repoInfo, repoCfg, ok, err := e.loadRepo(ctx, log, client, owner, repo)
+	if err != nil || !ok {
+		return err
 	}
 
-	// Check each enabled rule.
+	// Check each applicable rule.
 	openPRs, err := client.ListOpenPullRequests(ctx, owner, repo)
 	if err != nil {
 		return fmt.Errorf("listing open PRs: %w", err)
 	}
 
-	missing, err := e.findMissingFiles(ctx, log, client, owner, repo, openPRs)
+	render := e.newRuleRenderer(log, client, owner, repo, repoInfo.DefaultRef)
+
+	missing, patches, err := e.evaluateRules(ctx, log, client, owner, repo, render, e.applicableRules(log, repoCfg), openPRs)
 	if err != nil {
 		return err
 	}
 
+	missing, patches, err = e.skipDeclined(ctx, log, client, owner, repo, missing, patches)
+	if err != nil {
+		return err
+	}
+
+	// A PR deferred until its window opens does not hold up the properties
+	// check; the deferral is returned once that has run.
+	var deferred error
+
 	switch {
-	case len(missing) == 0:
+	case len(missing) == 0 && !hasChanges(patches):
 		log.Info("all required files present")
 	case e.dryRun:
-		log.Info("dry run: would create PR", "missing_files", ruleNames(missing))
+		log.Info("dry run: would create PR", "missing_files", ruleNames(missing), "patched_files", patchPaths(patches))
 	default:
-		if err := e.createOrUpdatePR(ctx, client, owner, repo, repoInfo.DefaultRef, missing, openPRs); err != nil {
-			return err
+		if err := e.createOrUpdatePR(ctx, client, owner, repo, repoInfo.DefaultRef, render, missing, patches, openPRs); err != nil {
+			if !isDeferred(err) {
+				return err
+			}
+
+			deferred = err
 		}
 	}
 
-	return e.checkCustomPropertiesIfEnabled(ctx, log, client, owner, repo, repoInfo.DefaultRef, openPRs)
+	if repoCfg.DisableCustomProperties {
+		log.Info("custom properties disabled by repo config")
+		return deferred
+	}
+
+	if err := e.checkCustomPropertiesIfEnabled(ctx, log, client, owner, repo, repoInfo.DefaultRef, openPRs); err != nil {
+		return err
+	}
+
+	return deferred
+}
+
+// CheckProperties re-checks only a repository's custom properties, skipping
+// the file rules. Unlike CheckRepo, it returns the properties check error so
+// the job can be retried.
+func (e *Engine) CheckProperties(ctx context.Context, client ghclient.Client, owner, repo string) error {
+	log := e.logger.With("owner", owner, "repo", repo)
+
+	if !e.CustomPropertiesEnabled() {
+		log.Debug("custom properties disabled, skipping properties check")
+		return nil
+	}
+
+	repoInfo, repoCfg, ok, err := e.loadRepo(ctx, log, client, owner, repo)
+	if err != nil || !ok {
+		return err
+	}
+
+	if repoCfg.DisableCustomProperties {
+		log.Info("custom properties disabled by repo config")
+		return nil
+	}
+
+	openPRs, err := client.ListOpenPullRequests(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("listing open PRs: %w", err)
+	}
+
+	return e.CheckCustomProperties(ctx, client, owner, repo, repoInfo.DefaultRef, openPRs)
+}
+
+// CustomPropertiesEnabled reports whether the engine manages custom
+// properties at all.
+func (e *Engine) CustomPropertiesEnabled() bool {
+	return e.customPropertiesMode != ""
+}
+
+// loadRepo fetches the repository and its per-repo config. It returns false
+// if the repository should not be checked.
+func (e *Engine) loadRepo(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+) (*ghclient.Repository, *repoconfig.Config, bool, error) {
+	// Get repository metadata.
+	repoInfo, err := client.GetRepository(ctx, owner, repo)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("getting repository info: %w", err)
+	}
+
+	// Authoritative skip checks — the scheduler pre-filters as an
+	// optimization, but the engine is the single source of truth.
+	if skip, reason := e.shouldSkip(repoInfo); skip {
+		log.Info(reason)
+		return nil, nil, false, nil
+	}
+
+	// Per-repo opt-outs and overrides.
+	repoCfg, ok, err := e.loadRepoConfig(ctx, log, client, owner, repo)
+	if err != nil || !ok {
+		return nil, nil, false, err
+	}
+
+	return repoInfo, repoCfg, true, nil
 }
 
 // shouldSkip returns true and a reason if the repository should be skipped.
@@ -113,41 +211,63 @@ func (e *Engine) shouldSkip(repo *ghclient.Repository) (bool, string) {
 	return false, ""
 }
 
-// findMissingFiles checks each enabled rule and returns rules whose files are missing.
-func (e *Engine) findMissingFiles(
+// evaluateRules checks each given rule and returns the rules whose files are
+// missing, plus patches for existing files that have drifted from their
+// managed content or fail content assertions.
+func (e *Engine) evaluateRules(
 	ctx context.Context,
 	log *slog.Logger,
 	client ghclient.Client,
 	owner, repo string,
+	render *ruleRenderer,
+	candidates []rules.FileRule,
 	openPRs []*ghclient.PullRequest,
-) ([]rules.FileRule, error) {
-	enabledRules := e.registry.EnabledRules()
-	missing := make([]rules.FileRule, 0, len(enabledRules))
+) ([]rules.FileRule, []FilePatch, error) {
+	missing := make([]rules.FileRule, 0, len(candidates))
+
+	var patches []FilePatch
 
-	for _, rule := range enabledRules {
+	for i := range candidates {
+		rule := &candidates[i]
 		ruleLog := log.With("rule", rule.Name)
 
-		exists, err := checkFileExists(ctx, client, owner, repo, &rule)
+		existingPath, err := findExistingPath(ctx, client, owner, repo, rule)
 		if err != nil {
-			return nil, fmt.Errorf("checking file existence for rule %s: %w", rule.Name, err)
+			return nil, nil, fmt.Errorf("checking file existence for rule %s: %w", rule.Name, err)
 		}
 
-		if exists {
-			ruleLog.Debug("file exists, skipping rule")
+		if existingPath != "" {
+			patch, ok, err := e.checkExisting(ctx, ruleLog, client, owner, repo, render, rule, existingPath)
+			if err != nil {
+				return nil, nil, fmt.Errorf("checking content for rule %s: %w", rule.Name, err)
+			}
+
+			switch {
+			case !ok:
+				ruleLog.Debug("file exists, skipping rule")
+			case hasExistingPR(openPRs, rule):
+				ruleLog.Info("existing PR found, skipping content patch")
+			case patch.ReportOnly:
+				patches = append(patches, patch)
+			default:
+				ruleLog.Info("existing file needs changes, will patch in PR", "path", existingPath)
+				patches = append(patches, patch)
+			}
+
 			continue
 		}
 
-		if hasExistingPR(openPRs, &rule) {
+		if hasExistingPR(openPRs, rule) {
 			ruleLog.Info("existing PR found, skipping rule")
 			continue
 		}
 
 		ruleLog.Info("file missing, will add to PR")
 		metrics.FilesMissingTotal.WithLabelValues(rule.Name).Inc()
-		missing = append(missing, rule)
+		missing = append(missing, *rule)
 	}
 
-	return missing, nil
+	return missing, patches, nil
 }
 
 func (e *Engine) checkCustomPropertiesIfEnabled(
@@ -161,31 +281,38 @@ func (e *Engine) checkCustomPropertiesIfEnabled(
 		return nil
 	}
 
-	if err := e.CheckCustomProperties(ctx, client, owner, repo, defaultBranch, openPRs); err != nil {
+	err := e.CheckCustomProperties(ctx, client, owner, repo, defaultBranch, openPRs)
+	if isDeferred(err) {
+		return err
+	}
+
+	if err != nil {
 		log.Error("custom properties check failed", "error", err)
 	}
 
 	return nil
 }
 
-func checkFileExists(
+// findExistingPath returns the first of the rule's paths that exists in the
+// repository, or an empty string if none do.
+func findExistingPath(
 	ctx context.Context,
 	client ghclient.Client,
 	owner, repo string,
 	rule *rules.FileRule,
-) (bool, error) {
+) (string, error) {
 	for _, path := range rule.Paths {
 		exists, err := client.GetContents(ctx, owner, repo, path)
 		if err != nil {
-			return false, fmt.Errorf("checking %s: %w", path, err)
+			return "", fmt.Errorf("checking %s: %w", path, err)
 		}
 
 		if exists {
-			return true, nil
+			return path, nil
 		}
 	}
 
-	return false, nil
+	return "", nil
 }
 
 func hasExistingPR(openPRs []*ghclient.PullRequest, rule *rules.FileRule) bool {
@@ -208,7 +335,9 @@ func (e *Engine) createOrUpdatePR(
 	ctx context.Context,
 	client ghclient.Client,
 	owner, repo, defaultBranch string,
+	render *ruleRenderer,
 	missing []rules.FileRule,
+	patches []FilePatch,
 	openPRs []*ghclient.PullRequest,
 ) error {
 	log := e.logger.With("owner", owner, "repo", repo)
@@ -221,6 +350,11 @@ func (e *Engine) createOrUpdatePR(
 
 	// Check if we already have an open PR.
 	existingPR := findOurPR(openPRs)
+	if existingPR == nil {
+		if err := e.deferPR(); err != nil {
+			return err
+		}
+	}
 
 	// If branch exists but no open PR, delete the stale branch.
 	if branchSHA != "" && existingPR == nil {
@@ -252,26 +386,14 @@ func (e *Engine) createOrUpdatePR(
 		log.Info("created branch", "branch", BranchName)
 	}
 
-	// Commit each missing file.
-	for _, rule := range missing {
-		content, err := e.templates.Get(rule.DefaultTemplateName)
-		if err != nil {
-			return fmt.Errorf("getting template for %s: %w", rule.Name, err)
-		}
-
-		msg := fmt.Sprintf("chore: add %s", rule.TargetPath)
-
-		if err := client.CreateOrUpdateFile(ctx, owner, repo, BranchName, rule.TargetPath, content, msg); err != nil {
-			return fmt.Errorf("creating file %s: %w", rule.TargetPath, err)
-		}
-
-		log.Info("added file", "path", rule.TargetPath)
+	if err := commitChanges(ctx, log, client, owner, repo, render, missing, patches); err != nil {
+		return err
 	}
 
+	body := BuildPRBody(missing, patches, render.codeOwner)
+
 	// Create PR if we don't already have one.
 	if existingPR == nil {
-		body := BuildPRBody(missing)
-
 		pr, err := client.CreatePullRequest(ctx, owner, repo, PRTitle, body, BranchName, defaultBranch)
 		if err != nil {
 			return fmt.Errorf("creating PR: %w", err)
@@ -279,14 +401,193 @@ func (e *Engine) createOrUpdatePR(
 
 		metrics.PRsCreatedTotal.Inc()
 		log.Info("created PR", "pr_number", pr.Number)
-	} else {
-		metrics.PRsUpdatedTotal.Inc()
-		log.Info("updated existing PR", "pr_number", existingPR.Number)
+
+		labelPR(ctx, log, client, owner, repo, pr.Number)
+
+		return nil
+	}
+
+	if err := updatePRBody(ctx, client, owner, repo, existingPR, body); err != nil {
+		return err
+	}
+
+	metrics.PRsUpdatedTotal.Inc()
+	log.Info("updated existing PR", "pr_number", existingPR.Number)
+
+	return nil
+}
+
+// updatePRBody replaces the body of an open PR if it changed, keeping the
+// rules marker a later decline is read from in step with what the branch
+// now proposes.
+func updatePRBody(ctx context.Context, client ghclient.Client, owner, repo string, pr *ghclient.PullRequest, body string) error {
+	if body == pr.Body {
+		return nil
+	}
+
+	if err := client.UpdatePullRequest(ctx, owner, repo, pr.Number, body); err != nil {
+		return fmt.Errorf("updating PR body: %w", err)
+	}
+
+	return nil
+}
+
+// commitChanges commits the rendered content of each missing rule and each
+// patch to BranchName in a single commit, so a failure never leaves the
+// branch half-populated and a retry reproduces the same change.
+func commitChanges(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+	render *ruleRenderer,
+	missing []rules.FileRule,
+	patches []FilePatch,
+) error {
+	files := make([]ghclient.FileChange, 0, len(missing)+len(patches))
+
+	var msg strings.Builder
+
+	msg.WriteString(PRTitle + "\n\n")
+
+	for i := range missing {
+		rule := &missing[i]
+
+		content, err := render.render(ctx, rule, rule.TargetPath)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, ghclient.FileChange{Path: rule.TargetPath, Content: content})
+		fmt.Fprintf(&msg, "- add %s\n", rule.TargetPath)
 	}
 
+	for i := range patches {
+		if patches[i].ReportOnly {
+			continue
+		}
+
+		files = append(files, ghclient.FileChange{Path: patches[i].Path, Content: patches[i].Content})
+		fmt.Fprintf(&msg, "- update %s\n", patches[i].Path)
+	}
+
+	sha, err := client.CommitFiles(ctx, owner, repo, BranchName, msg.String(), files)
+	if err != nil {
+		return fmt.Errorf("committing files: %w", err)
+	}
+
+	log.Info("committed files", "commit", sha, "added", ruleNames(missing), "patched", patchPaths(patches))
+
 	return nil
 }
 
+// ruleRenderer renders rule content for a single repository. Template data
+// is gathered, and the CODEOWNERS team resolved, at most once per check.
+type ruleRenderer struct {
+	engine        *Engine
+	log           *slog.Logger
+	client        ghclient.Client
+	owner, repo   string
+	defaultBranch string
+
+	data *rules.TemplateData
+
+	// codeOwner is set once a CODEOWNERS rule has been rendered.
+	codeOwner *CodeOwner
+}
+
+func (e *Engine) newRuleRenderer(
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo, defaultBranch string,
+) *ruleRenderer {
+	return &ruleRenderer{
+		engine:        e,
+		log:           log,
+		client:        client,
+		owner:         owner,
+		repo:          repo,
+		defaultBranch: defaultBranch,
+	}
+}
+
+// render produces the content for rule as it would be committed at path,
+// including the marker for managed rules.
+func (r *ruleRenderer) render(ctx context.Context, rule *rules.FileRule, path string) (string, error) {
+	if r.data == nil {
+		data, err := buildTemplateData(ctx, r.client, r.owner, r.repo, r.defaultBranch, nil)
+		if err != nil {
+			return "", err
+		}
+
+		r.data = data
+	}
+
+	data := *r.data
+
+	if isCodeOwnersRule(rule) {
+		if r.codeOwner == nil {
+			codeOwner, err := r.engine.resolveCodeOwner(ctx, r.log, r.client, r.owner, r.data.Properties)
+			if err != nil {
+				return "", err
+			}
+
+			r.codeOwner = codeOwner
+		}
+
+		data.CodeOwner = r.codeOwner.Handle
+	}
+
+	content, err := r.engine.templates.RenderRule(rule, &data)
+	if err != nil {
+		return "", fmt.Errorf("rendering content for %s: %w", rule.Name, err)
+	}
+
+	if rule.Managed {
+		content = rules.StampManaged(path, content)
+	}
+
+	return content, nil
+}
+
+// buildTemplateData gathers the per-repo context used to render templates.
+// When props is nil, catalog-info.yaml is read to populate Properties.
+func buildTemplateData(
+	ctx context.Context,
+	client ghclient.Client,
+	owner, repo, defaultBranch string,
+	props *catalog.Properties,
+) (*rules.TemplateData, error) {
+	if props == nil {
+		content, err := readCatalogInfo(ctx, client, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+
+		props = catalog.Parse(content)
+	}
+
+	languages, err := client.ListLanguages(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("listing languages: %w", err)
+	}
+
+	files, err := client.ListTree(ctx, owner, repo, defaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("listing repository tree: %w", err)
+	}
+
+	return &rules.TemplateData{
+		Owner:               owner,
+		Repo:                repo,
+		DefaultBranch:       defaultBranch,
+		Properties:          props,
+		Languages:           languages,
+		Files:               files,
+		InstallationAccount: owner,
+	}, nil
+}
+
 func findOurPR(openPRs []*ghclient.PullRequest) *ghclient.PullRequest {
 	for _, pr := range openPRs {
 		if pr.Head == BranchName {
@@ -297,36 +598,123 @@ func findOurPR(openPRs []*ghclient.PullRequest) *ghclient.PullRequest {
 	return nil
 }
 
-// BuildPRBody generates the PR body markdown for the given missing rules.
-func BuildPRBody(missing []rules.FileRule) string {
+// BuildPRBody generates the PR body markdown for the given missing rules and
+// content patches. codeOwner describes how the CODEOWNERS team was resolved
+// and may be nil.
+func BuildPRBody(missing []rules.FileRule, patches []FilePatch, codeOwner *CodeOwner) string {
 	var sb strings.Builder
 
 	sb.WriteString("## Repo Guardian — Missing Configuration Files\n\n")
 	sb.WriteString("This PR was automatically created by **repo-guardian** because the following\n")
-	sb.WriteString("required configuration files were not found in this repository:\n\n")
-	sb.WriteString("### Added Files\n\n")
+	sb.WriteString("required configuration files were missing or incomplete in this repository:\n\n")
 
-	for _, rule := range missing {
-		fmt.Fprintf(&sb, "- `%s` — %s\n", rule.TargetPath, rule.Name)
+	if len(missing) > 0 {
+		sb.WriteString("### Added Files\n\n")
+
+		for i := range missing {
+			fmt.Fprintf(&sb, "- `%s` — %s\n", missing[i].TargetPath, missing[i].Name)
+		}
+
+		sb.WriteString("\n")
 	}
 
-	sb.WriteString("\n> **Note:** The CODEOWNERS file contains a placeholder (`@org/CHANGEME`).\n")
-	sb.WriteString("> Please replace it with your actual team before merging.\n\n")
+	if hasChanges(patches) {
+		writeUpdatedFiles(&sb, patches)
+	}
+
+	if len(patches) > 0 {
+		writeInvalidFiles(&sb, patches)
+	}
+
+	if needsCodeOwner(missing) {
+		writeCodeOwnerNote(&sb, codeOwner)
+	}
 
 	sb.WriteString("### What to do\n\n")
 	sb.WriteString("1. Review the default file contents and adjust for your team's needs.\n")
-	sb.WriteString("2. Merge when ready — these are sensible defaults, not one-size-fits-all.\n\n")
+	sb.WriteString("2. Merge when ready — these are sensible defaults, not one-size-fits-all.\n")
+	sb.WriteString("3. Don't want these changes? Close this PR without merging and repo-guardian will stop\n")
+	fmt.Fprintf(&sb, "   proposing them. Remove the `%s` label from the closed PR to allow them again.\n\n", PRLabel)
 	sb.WriteString("---\n")
 	sb.WriteString("*Automated by [repo-guardian](https://github.com/apps/repo-guardian). ")
 	sb.WriteString("Questions? Reach out in #platform-engineering.*\n")
+	sb.WriteString(rulesMarker(missing, patches))
 
 	return sb.String()
 }
 
+func writeUpdatedFiles(sb *strings.Builder, patches []FilePatch) {
+	sb.WriteString("### Updated Files\n\n")
+
+	for i := range patches {
+		patch := &patches[i]
+		if patch.ReportOnly {
+			continue
+		}
+
+		fmt.Fprintf(sb, "- `%s` — %s\n", patch.Path, patch.Rule.Name)
+
+		if patch.Managed {
+			sb.WriteString("  - updated to the current repo-guardian template\n")
+		}
+
+		for j := range patch.Failed {
+			a := &patch.Failed[j]
+			if a.Fix == "" {
+				fmt.Fprintf(sb, "  - %s (not fixed automatically; please update manually)\n", a.Description)
+				continue
+			}
+
+			fmt.Fprintf(sb, "  - %s\n", a.Description)
+		}
+	}
+
+	sb.WriteString("\n")
+}
+
+// writeInvalidFiles lists the files that could not be fully checked, such as
+// YAML files with syntax errors, so that they are fixed by hand.
+func writeInvalidFiles(sb *strings.Builder, patches []FilePatch) {
+	var header bool
+
+	for i := range patches {
+		patch := &patches[i]
+		if patch.Invalid == nil {
+			continue
+		}
+
+		if !header {
+			sb.WriteString("### Files Needing Attention\n\n")
+
+			header = true
+		}
+
+		fmt.Fprintf(sb, "- `%s` — %s: %v; please fix it manually\n", patch.Path, patch.Rule.Name, patch.Invalid)
+	}
+
+	if header {
+		sb.WriteString("\n")
+	}
+}
+
+func writeCodeOwnerNote(sb *strings.Builder, codeOwner *CodeOwner) {
+	switch {
+	case codeOwner != nil && codeOwner.Handle != "":
+		fmt.Fprintf(sb, "> **Note:** CODEOWNERS assigns all files to `%s`, ", codeOwner.Handle)
+		fmt.Fprintf(sb, "the team mapped from the catalog owner `%s`.\n\n", codeOwner.CatalogOwner)
+	case codeOwner != nil && codeOwner.Reason != "":
+		fmt.Fprintf(sb, "> **Note:** The CODEOWNERS file contains a placeholder (`%s`) because %s.\n", CodeOwnerPlaceholder, codeOwner.Reason)
+		sb.WriteString("> Please replace it with your actual team before merging.\n\n")
+	default:
+		fmt.Fprintf(sb, "> **Note:** The CODEOWNERS file contains a placeholder (`%s`).\n", CodeOwnerPlaceholder)
+		sb.WriteString("> Please replace it with your actual team before merging.\n\n")
+	}
+}
+
 func ruleNames(rr []rules.FileRule) []string {
 	names := make([]string, len(rr))
-	for i, r := range rr {
-		names[i] = r.Name
+	for i := range rr {
+		names[i] = rr[i].Name
 	}
 
 	return names
@@ -19,15 +19,29 @@ type mockClient struct {
 	customProperties map[string][]*ghclient.CustomPropertyValue // "owner/repo" -> values
 	setProperties    []*ghclient.CustomPropertyValue            // records what was set
 	openPRs          []*ghclient.PullRequest
+	closedPRs        []*ghclient.PullRequest
 	repo             *ghclient.Repository
 	branchSHAs       map[string]string // "owner/repo/branch" -> sha
 	createdBranches  []string
 	deletedBranches  []string
+	prsClosed        []int
 	createdFiles     []string
+	commits          []string          // commit messages, one per CommitFiles call
+	fileWrites       map[string]string // path -> committed content
+	languages        []string
+	tree             []string
+	teams            map[string]bool // "org/slug" -> exists
+	teamErr          error
 	createdPR        *ghclient.PullRequest
+	createdPRBody    string
+	updatedPRBodies  map[int]string
+	addedLabels      map[int][]string
+	openIssues       []*ghclient.Issue
+	createdIssues    []*ghclient.Issue
 	installations    []*ghclient.Installation
 	installRepos     map[int64][]*ghclient.Repository
 	processedJobs    atomic.Int32
+	hangGetRepo      bool // GetRepository blocks until its context is done
 
 	getRepoErr        error
 	getContentsErr    error
@@ -38,8 +52,9 @@ type mockClient struct {
 	getBranchErr      error
 	createBranchErr   error
 	deleteBranchErr   error
-	createFileErr     error
+	commitErr         error
 	createPRErr       error
+	addLabelsErr      error
 }
 
 func newMockClient() *mockClient {
@@ -48,7 +63,11 @@ func newMockClient() *mockClient {
 		fileContents:     make(map[string]string),
 		customProperties: make(map[string][]*ghclient.CustomPropertyValue),
 		branchSHAs:       make(map[string]string),
+		fileWrites:       make(map[string]string),
 		installRepos:     make(map[int64][]*ghclient.Repository),
+		teams:            make(map[string]bool),
+		addedLabels:      make(map[int][]string),
+		updatedPRBodies:  make(map[int]string),
 	}
 }
 
@@ -70,11 +89,32 @@ func (m *mockClient) ListOpenPullRequests(_ context.Context, _, _ string) ([]*gh
 	return m.openPRs, nil
 }
 
-func (m *mockClient) GetRepository(_ context.Context, _, _ string) (*ghclient.Repository, error) {
+func (m *mockClient) ListClosedPullRequests(_ context.Context, _, _, branch string) ([]*ghclient.PullRequest, error) {
+	if m.listPRsErr != nil {
+		return nil, m.listPRsErr
+	}
+
+	var prs []*ghclient.PullRequest
+
+	for _, pr := range m.closedPRs {
+		if pr.Head == branch {
+			prs = append(prs, pr)
+		}
+	}
+
+	return prs, nil
+}
+
+func (m *mockClient) GetRepository(ctx context.Context, _, _ string) (*ghclient.Repository, error) {
 	if m.getRepoErr != nil {
 		return nil, m.getRepoErr
 	}
 
+	if m.hangGetRepo {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
 	m.processedJobs.Add(1)
 
 	return m.repo, nil
@@ -110,21 +150,38 @@ func (m *mockClient) DeleteBranch(_ context.Context, _, _, branch string) error
 	return nil
 }
 
-func (m *mockClient) CreateOrUpdateFile(_ context.Context, _, _, _, path, _, _ string) error {
-	if m.createFileErr != nil {
-		return m.createFileErr
+func (m *mockClient) CommitFiles(_ context.Context, _, _, branch, message string, files []ghclient.FileChange) (string, error) {
+	if m.commitErr != nil {
+		return "", m.commitErr
 	}
 
-	m.createdFiles = append(m.createdFiles, path)
+	m.commits = append(m.commits, message)
+
+	for _, f := range files {
+		m.createdFiles = append(m.createdFiles, f.Path)
+		m.fileWrites[f.Path] = f.Content
+	}
+
+	return "commit-" + branch, nil
+}
 
+func (m *mockClient) UpdatePullRequest(_ context.Context, _, _ string, number int, body string) error {
+	m.updatedPRBodies[number] = body
 	return nil
 }
 
-func (m *mockClient) CreatePullRequest(_ context.Context, _, _, title, _, head, _ string) (*ghclient.PullRequest, error) {
+func (m *mockClient) ClosePullRequest(_ context.Context, _, _ string, number int) error {
+	m.prsClosed = append(m.prsClosed, number)
+	return nil
+}
+
+func (m *mockClient) CreatePullRequest(_ context.Context, _, _, title, body, head, _ string) (*ghclient.PullRequest, error) {
 	if m.createPRErr != nil {
 		return nil, m.createPRErr
 	}
 
+	m.createdPRBody = body
+
 	m.createdPR = &ghclient.PullRequest{
 		Number: 1,
 		Title:  title,
@@ -135,6 +192,16 @@ func (m *mockClient) CreatePullRequest(_ context.Context, _, _, title, _, head,
 	return m.createdPR, nil
 }
 
+func (m *mockClient) AddLabels(_ context.Context, _, _ string, number int, labels []string) error {
+	if m.addLabelsErr != nil {
+		return m.addLabelsErr
+	}
+
+	m.addedLabels[number] = append(m.addedLabels[number], labels...)
+
+	return nil
+}
+
 func (m *mockClient) ListInstallations(_ context.Context) ([]*ghclient.Installation, error) {
 	return m.installations, nil
 }
@@ -147,6 +214,8 @@ func (m *mockClient) CreateInstallationClient(_ context.Context, _ int64) (ghcli
 	return m, nil
 }
 
+func (*mockClient) ForgetInstallation(_ int64) {}
+
 func (m *mockClient) GetFileContent(_ context.Context, owner, repo, path string) (string, error) {
 	if m.getFileContentErr != nil {
 		return "", m.getFileContentErr
@@ -177,6 +246,33 @@ func (m *mockClient) SetCustomPropertyValues(_ context.Context, _, _ string, pro
 	return nil
 }
 
+func (m *mockClient) ListTree(_ context.Context, _, _, _ string) ([]string, error) {
+	return m.tree, nil
+}
+
+func (m *mockClient) TeamExists(_ context.Context, org, slug string) (bool, error) {
+	if m.teamErr != nil {
+		return false, m.teamErr
+	}
+
+	return m.teams[org+"/"+slug], nil
+}
+
+func (m *mockClient) ListLanguages(_ context.Context, _, _ string) ([]string, error) {
+	return m.languages, nil
+}
+
+func (m *mockClient) ListOpenIssues(_ context.Context, _, _ string) ([]*ghclient.Issue, error) {
+	return m.openIssues, nil
+}
+
+func (m *mockClient) CreateIssue(_ context.Context, _, _, title, _ string) (*ghclient.Issue, error) {
+	issue := &ghclient.Issue{Number: len(m.createdIssues) + 1, Title: title, State: "open"}
+	m.createdIssues = append(m.createdIssues, issue)
+
+	return issue, nil
+}
+
 func testEngine(dryRun bool) *Engine {
 	return testEngineWithMode(dryRun, "")
 }
@@ -189,7 +285,7 @@ func testEngineWithMode(dryRun bool, customPropertiesMode string) *Engine {
 		panic(err)
 	}
 
-	return NewEngine(reg, ts, slog.Default(), true, true, dryRun, customPropertiesMode)
+	return NewEngine(reg, ts, slog.Default(), true, true, dryRun, customPropertiesMode, nil, 0)
 }
 
 func TestCheckRepo_AllFilesExist(t *testing.T) {
@@ -248,6 +344,37 @@ func TestCheckRepo_MissingFiles_NoPR(t *testing.T) {
 	if len(client.createdFiles) != 2 {
 		t.Errorf("expected 2 files created, got %d: %v", len(client.createdFiles), client.createdFiles)
 	}
+
+	// Both files land in a single commit.
+	if len(client.commits) != 1 {
+		t.Fatalf("expected 1 commit, got %d", len(client.commits))
+	}
+
+	for _, want := range []string{"- add .github/CODEOWNERS", "- add .github/dependabot.yml"} {
+		if !strings.Contains(client.commits[0], want) {
+			t.Errorf("commit message should contain %q, got:\n%s", want, client.commits[0])
+		}
+	}
+}
+
+func TestCheckRepo_CommitFailure(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := newMockClient()
+	client.repo = &ghclient.Repository{
+		Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main",
+	}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.commitErr = fmt.Errorf("ref update rejected")
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err == nil {
+		t.Fatal("expected error when the commit fails")
+	}
+
+	if client.createdPR != nil {
+		t.Error("should not open a PR when the commit fails")
+	}
 }
 
 func TestCheckRepo_MissingFiles_ExistingPR(t *testing.T) {
@@ -445,7 +572,7 @@ func TestBuildPRBody(t *testing.T) {
 		{Name: "Dependabot", TargetPath: ".github/dependabot.yml"},
 	}
 
-	body := BuildPRBody(missing)
+	body := BuildPRBody(missing, nil, nil)
 
 	if !strings.Contains(body, "Repo Guardian") {
 		t.Error("PR body should contain 'Repo Guardian'")
@@ -467,3 +594,33 @@ func TestBuildPRBody(t *testing.T) {
 		t.Error("PR body should reference platform-engineering channel")
 	}
 }
+
+func TestCreateOrUpdatePR_GeneratesDependabotFromTree(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := newMockClient()
+	client.repo = &ghclient.Repository{
+		Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main",
+	}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.contents["org/repo/CODEOWNERS"] = true
+	client.tree = []string{"go.mod", "web/package.json", ".github/workflows/ci.yml"}
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	content := client.fileWrites[".github/dependabot.yml"]
+
+	for _, want := range []string{
+		`package-ecosystem: "gomod"`,
+		`package-ecosystem: "npm"`,
+		`directory: "/web"`,
+		`package-ecosystem: "github-actions"`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("dependabot.yml should contain %q, got:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package checker
+
+// SetInstallationLimit caps how many jobs of one installation are processed
+// at once, so that a large installation does not hold every worker. 0, the
+// default, means no limit. It must be called before Start.
+func (q *Queue) SetInstallationLimit(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.installLimit = n
+}
+
+// fairest returns the job, among each installation's first ready job, of the
+// installation that was served least recently, so installations take turns.
+// Ties, such as installations never served, go to the job enqueued first.
+// The caller must hold q.mu.
+func (q *Queue) fairest(ready map[int64]int) int {
+	best := -1
+
+	for inst, i := range ready {
+		if best < 0 {
+			best = i
+			continue
+		}
+
+		last, bestLast := q.served[inst], q.served[q.pending[best].job.InstallationID]
+		if last < bestLast || (last == bestLast && i < best) {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// atLimit reports whether inst already has as many jobs in flight as the
+// installation limit allows. The caller must hold q.mu.
+func (q *Queue) atLimit(inst int64) bool {
+	return q.installLimit > 0 && q.running[inst] >= q.installLimit
+}
+
+// claim records that a job of inst was dispatched. The caller must hold q.mu.
+func (q *Queue) claim(inst int64) {
+	q.dispatches++
+	q.served[inst] = q.dispatches
+	q.running[inst]++
+}
+
+// release records that a job of inst finished. The caller must hold q.mu.
+func (q *Queue) release(inst int64) {
+	q.running[inst]--
+	if q.running[inst] <= 0 {
+		delete(q.running, inst)
+	}
+}
@@ -0,0 +1,91 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestNext_RoundRobinsInstallations(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(100, slog.Default())
+
+	// The large installation is enqueued first, as in reconcileAll.
+	for i := range 5 {
+		_ = q.Enqueue(RepoJob{Owner: "big", Repo: fmt.Sprintf("big-%d", i), InstallationID: 1, Trigger: TriggerScheduler})
+	}
+
+	for i := range 2 {
+		_ = q.Enqueue(RepoJob{Owner: "small", Repo: fmt.Sprintf("small-%d", i), InstallationID: 2, Trigger: TriggerScheduler})
+		_ = q.Enqueue(RepoJob{Owner: "tiny", Repo: fmt.Sprintf("tiny-%d", i), InstallationID: 3, Trigger: TriggerScheduler})
+	}
+
+	var got []int64
+
+	for range 9 {
+		qj, ok := q.next(context.Background())
+		if !ok {
+			t.Fatal("expected a job")
+		}
+
+		got = append(got, qj.job.InstallationID)
+		q.done(slog.Default(), qj, nil)
+	}
+
+	want := []int64{1, 2, 3, 1, 2, 3, 1, 1, 1}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("dispatch order = %v, want %v", got, want)
+	}
+}
+
+func TestNext_InstallationLimit(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+	q.SetInstallationLimit(1)
+
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerWebhook})
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "b", InstallationID: 1, Trigger: TriggerWebhook})
+	_ = q.Enqueue(RepoJob{Owner: "other", Repo: "c", InstallationID: 2, Trigger: TriggerScheduler})
+
+	first, _ := q.next(context.Background())
+	if first.job.Repo != "a" {
+		t.Fatalf("first job = %s, want a", first.job.Repo)
+	}
+
+	// Installation 1 is at its limit, so the lower-priority job of
+	// installation 2 is dispatched instead of b.
+	second, _ := q.next(context.Background())
+	if second.job.Repo != "c" {
+		t.Fatalf("second job = %s, want c", second.job.Repo)
+	}
+
+	got := make(chan *queuedJob, 1)
+
+	go func() {
+		qj, _ := q.next(context.Background())
+		got <- qj
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("job dispatched while its installation was at the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.done(slog.Default(), first, nil)
+
+	select {
+	case qj := <-got:
+		if qj.job.Repo != "b" {
+			t.Errorf("third job = %s, want b", qj.job.Repo)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not released after its installation freed a slot")
+	}
+
+	q.done(slog.Default(), second, nil)
+}
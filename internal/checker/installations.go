@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInstallationSuspended is returned when enqueuing a job for an
+// installation that was suspended or deleted.
+var ErrInstallationSuspended = errors.New("installation is suspended")
+
+// SuspendInstallation drops the pending jobs of a suspended installation
+// and refuses new ones until ResumeInstallation. Jobs already running are
+// not interrupted, but they are not retried. It returns how many pending
+// jobs were dropped.
+//
+// The suspension is kept in memory only. After a restart the scheduler
+// derives it again from the installation's Suspended field.
+func (q *Queue) SuspendInstallation(installationID int64) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.suspended[installationID] = true
+
+	return q.dropInstallation(installationID)
+}
+
+// RemoveInstallation drops the pending jobs of an installation that was
+// uninstalled and forgets whether it was suspended. It returns how many
+// pending jobs were dropped.
+func (q *Queue) RemoveInstallation(installationID int64) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.suspended, installationID)
+
+	return q.dropInstallation(installationID)
+}
+
+// dropInstallation removes the pending and deferred jobs of an installation
+// and returns how many it removed. The caller must hold q.mu.
+func (q *Queue) dropInstallation(installationID int64) (int, error) {
+	dropped, err := q.dropJobs(func(job *RepoJob) bool { return job.InstallationID == installationID })
+	if err != nil {
+		return dropped, fmt.Errorf("dropping jobs of installation %d: %w", installationID, err)
+	}
+
+	return dropped, nil
+}
+
+// ResumeInstallation accepts jobs for a suspended installation again.
+func (q *Queue) ResumeInstallation(installationID int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.suspended, installationID)
+}
+
+// InstallationSuspended reports whether jobs for the installation are
+// refused.
+func (q *Queue) InstallationSuspended(installationID int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.suspended[installationID]
+}
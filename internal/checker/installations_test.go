@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestSuspendInstallation(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+
+	for _, job := range []RepoJob{
+		{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerScheduler},
+		{Owner: "org", Repo: "b", InstallationID: 1, Trigger: TriggerWebhook},
+		{Owner: "other", Repo: "c", InstallationID: 2, Trigger: TriggerScheduler},
+	} {
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	dropped, err := q.SuspendInstallation(1)
+	if err != nil {
+		t.Fatalf("SuspendInstallation: %v", err)
+	}
+
+	if dropped != 2 || q.Len() != 1 {
+		t.Errorf("dropped %d jobs leaving %d, want 2 leaving 1", dropped, q.Len())
+	}
+
+	if !q.InstallationSuspended(1) || q.InstallationSuspended(2) {
+		t.Error("only installation 1 should be suspended")
+	}
+
+	err = q.Enqueue(RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerWebhook})
+	if !errors.Is(err, ErrInstallationSuspended) {
+		t.Errorf("expected ErrInstallationSuspended, got %v", err)
+	}
+
+	q.ResumeInstallation(1)
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerWebhook}); err != nil {
+		t.Errorf("Enqueue after resume: %v", err)
+	}
+
+	if q.Len() != 2 {
+		t.Errorf("expected 2 pending jobs, got %d", q.Len())
+	}
+}
+
+func TestRemoveInstallation(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerScheduler}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if _, err := q.SuspendInstallation(1); err != nil {
+		t.Fatalf("SuspendInstallation: %v", err)
+	}
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "b", InstallationID: 2, Trigger: TriggerScheduler}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	for _, id := range []int64{1, 2} {
+		if _, err := q.RemoveInstallation(id); err != nil {
+			t.Fatalf("RemoveInstallation(%d): %v", id, err)
+		}
+	}
+
+	if q.Len() != 0 {
+		t.Errorf("expected no pending jobs, got %d", q.Len())
+	}
+
+	if q.InstallationSuspended(1) {
+		t.Error("a removed installation should not stay suspended")
+	}
+}
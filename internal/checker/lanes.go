@@ -0,0 +1,129 @@
+package checker
+
+import (
+	"slices"
+	"time"
+
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+)
+
+// numLanes is the number of priority lanes. A job's lane is its trigger's
+// priority, so manual jobs are dispatched before webhook jobs, and webhook
+// jobs before scheduler backfill.
+const numLanes = 3
+
+// starvationLimit is how many dispatches in a row may pass over a lane that
+// has a ready job before that lane is served anyway. It keeps scheduler
+// backfill moving during a sustained burst of webhook deliveries.
+const starvationLimit = 8
+
+// laneNames labels the lane metrics, indexed by lane.
+var laneNames = [numLanes]string{"scheduler", "webhook", "manual"}
+
+func (j *RepoJob) lane() int {
+	return j.Trigger.priority()
+}
+
+// pick returns the index in q.pending of the job to dispatch next, or -1 if
+// none is ready, together with the earliest time a job waiting on retry
+// backoff becomes ready. Within the chosen lane, installations take turns;
+// see fairest. The caller must hold q.mu.
+func (q *Queue) pick(now time.Time) (int, time.Time) {
+	var (
+		ready [numLanes]map[int64]int // first ready job of each installation
+		wake  time.Time
+	)
+
+	for i, qj := range q.pending {
+		lane, inst := qj.job.lane(), qj.job.InstallationID
+		if _, ok := ready[lane][inst]; ok || q.inFlight[qj.job.key()] || q.atLimit(inst) {
+			continue
+		}
+
+		if readyAt := qj.job.readyAt(); readyAt.After(now) {
+			if wake.IsZero() || readyAt.Before(wake) {
+				wake = readyAt
+			}
+
+			continue
+		}
+
+		if ready[lane] == nil {
+			ready[lane] = make(map[int64]int)
+		}
+
+		ready[lane][inst] = i
+	}
+
+	chosen := -1
+
+	for lane := numLanes - 1; lane >= 0; lane-- {
+		if len(ready[lane]) > 0 {
+			chosen = lane
+			break
+		}
+	}
+
+	// A starved lower lane takes precedence over the highest ready lane.
+	for lane := range chosen {
+		if len(ready[lane]) > 0 && q.passed[lane] >= starvationLimit {
+			chosen = lane
+			break
+		}
+	}
+
+	if chosen < 0 {
+		return -1, wake
+	}
+
+	for lane := range numLanes {
+		switch {
+		case lane == chosen:
+			q.passed[lane] = 0
+		case len(ready[lane]) > 0:
+			q.passed[lane]++
+		}
+	}
+
+	return q.fairest(ready[chosen]), wake
+}
+
+// take removes the pending job at index i and marks its repository in
+// flight. The caller must hold q.mu.
+func (q *Queue) take(i int, now time.Time) *queuedJob {
+	qj := q.pending[i]
+	q.pending = slices.Delete(q.pending, i, i+1)
+
+	key := qj.job.key()
+	delete(q.byKey, key)
+	q.inFlight[key] = true
+	q.claim(qj.job.InstallationID)
+
+	// Wake producers waiting for capacity in EnqueueWait.
+	q.cond.Broadcast()
+
+	lane := laneNames[qj.job.lane()]
+	metrics.QueueLaneDepth.WithLabelValues(lane).Dec()
+
+	// Time spent in retry backoff or waiting for a PR window is not waiting
+	// for a worker.
+	ready := qj.enqueuedAt
+	if readyAt := qj.job.readyAt(); readyAt.After(ready) {
+		ready = readyAt
+	}
+
+	metrics.QueueWaitSeconds.WithLabelValues(lane).Observe(now.Sub(ready).Seconds())
+
+	return qj
+}
+
+// moveLane updates the lane depth metric when a merge changes a pending
+// job's trigger.
+func moveLane(from, to int) {
+	if from == to {
+		return
+	}
+
+	metrics.QueueLaneDepth.WithLabelValues(laneNames[from]).Dec()
+	metrics.QueueLaneDepth.WithLabelValues(laneNames[to]).Inc()
+}
@@ -0,0 +1,96 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func TestNext_DispatchesByLane(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+
+	jobs := []RepoJob{
+		{Owner: "org", Repo: "backfill", InstallationID: 1, Trigger: TriggerScheduler},
+		{Owner: "org", Repo: "push", InstallationID: 1, Trigger: TriggerWebhook},
+		{Owner: "org", Repo: "operator", InstallationID: 1, Trigger: TriggerManual},
+		{Owner: "org", Repo: "push-2", InstallationID: 1, Trigger: TriggerWebhook},
+	}
+
+	for _, job := range jobs {
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue %s: %v", job.Repo, err)
+		}
+	}
+
+	want := []string{"operator", "push", "push-2", "backfill"}
+
+	for _, repo := range want {
+		qj, ok := q.next(context.Background())
+		if !ok {
+			t.Fatal("expected a job")
+		}
+
+		if qj.job.Repo != repo {
+			t.Errorf("dispatched %s, want %s", qj.job.Repo, repo)
+		}
+
+		q.done(slog.Default(), qj, nil)
+	}
+}
+
+func TestNext_CoalescedJobMovesLane(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "a", InstallationID: 1, Trigger: TriggerWebhook})
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "b", InstallationID: 1, Trigger: TriggerScheduler})
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "b", InstallationID: 1, Trigger: TriggerManual})
+
+	qj, ok := q.next(context.Background())
+	if !ok || qj.job.Repo != "b" || qj.job.Trigger != TriggerManual {
+		t.Fatalf("expected upgraded manual job for b first, got %+v", qj)
+	}
+}
+
+func TestNext_SchedulerLaneIsNotStarved(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(100, slog.Default())
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "backfill", InstallationID: 1, Trigger: TriggerScheduler}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Webhook jobs keep arriving faster than they are processed.
+	for i := range starvationLimit * 2 {
+		job := RepoJob{Owner: "org", Repo: fmt.Sprintf("hook-%d", i), InstallationID: 1, Trigger: TriggerWebhook}
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	for n := 1; n <= starvationLimit+1; n++ {
+		qj, ok := q.next(context.Background())
+		if !ok {
+			t.Fatal("expected a job")
+		}
+
+		q.done(slog.Default(), qj, nil)
+
+		if qj.job.Trigger != TriggerScheduler {
+			continue
+		}
+
+		if n != starvationLimit+1 {
+			t.Errorf("scheduler job dispatched at position %d, want %d", n, starvationLimit+1)
+		}
+
+		return
+	}
+
+	t.Fatalf("scheduler job was not dispatched within %d jobs", starvationLimit+1)
+}
@@ -0,0 +1,90 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+// checkExisting decides whether an existing file needs a patch. Managed
+// drift is checked first since the current template supersedes any
+// assertion fixes; otherwise content assertions are evaluated.
+func (e *Engine) checkExisting(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+	render *ruleRenderer,
+	rule *rules.FileRule,
+	path string,
+) (FilePatch, bool, error) {
+	if rule.Managed {
+		patch, ok, err := checkDrift(ctx, log, client, owner, repo, render, rule, path)
+		if err != nil || ok {
+			return patch, ok, err
+		}
+	}
+
+	return e.checkContent(ctx, log, client, owner, repo, rule, path)
+}
+
+// checkDrift compares a managed file with the current rendered content. A
+// file that was edited after repo-guardian wrote it, or that it never wrote,
+// is left alone unless the rule is strict.
+func checkDrift(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+	render *ruleRenderer,
+	rule *rules.FileRule,
+	path string,
+) (FilePatch, bool, error) {
+	content, err := client.GetFileContent(ctx, owner, repo, path)
+	if err != nil {
+		return FilePatch{}, false, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	existing := rules.ParseManaged(content)
+
+	if existing.Edited() && !rule.Strict {
+		if existing.Hash == "" {
+			log.Debug("file was not created by repo-guardian, not managing it", "path", path)
+		} else {
+			log.Info("managed file was edited, leaving it alone", "path", path)
+			metrics.ManagedFileDriftTotal.WithLabelValues(rule.Name, "edited").Inc()
+		}
+
+		return FilePatch{}, false, nil
+	}
+
+	desired, err := render.render(ctx, rule, path)
+	if err != nil {
+		return FilePatch{}, false, err
+	}
+
+	if sameContent(rules.ParseManaged(desired).Body, existing.Body) {
+		log.Debug("managed file is up to date", "path", path)
+		return FilePatch{}, false, nil
+	}
+
+	log.Info("managed file drifted from template, will update", "path", path, "strict", rule.Strict)
+	metrics.ManagedFileDriftTotal.WithLabelValues(rule.Name, "updated").Inc()
+
+	return FilePatch{
+		Rule:    *rule,
+		Path:    path,
+		Content: desired,
+		Managed: true,
+	}, true, nil
+}
+
+// sameContent compares file bodies, ignoring trailing newlines.
+func sameContent(a, b string) bool {
+	return strings.TrimRight(a, "\n") == strings.TrimRight(b, "\n")
+}
@@ -0,0 +1,160 @@
+package checker
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+const oldDependabot = "version: 2\nupdates:\n  - package-ecosystem: \"github-actions\"\n    directory: \"/\"\n"
+
+func managedEngine(t *testing.T, strict bool) *Engine {
+	t.Helper()
+
+	ts := rules.NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	rr := []rules.FileRule{{
+		Name:                "Dependabot",
+		Paths:               []string{".github/dependabot.yml"},
+		PRSearchTerms:       []string{"dependabot"},
+		DefaultTemplateName: "dependabot",
+		TargetPath:          ".github/dependabot.yml",
+		Generator:           "dependabot",
+		Managed:             true,
+		Strict:              strict,
+		Enabled:             true,
+	}}
+
+	return NewEngine(rules.NewRegistry(rr), ts, slog.Default(), true, true, false, "", nil, 0)
+}
+
+func managedClient(content string) *mockClient {
+	client := newMockClient()
+	client.repo = &ghclient.Repository{
+		Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main",
+	}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.contents["org/repo/.github/dependabot.yml"] = true
+	client.fileContents["org/repo/.github/dependabot.yml"] = content
+
+	return client
+}
+
+func TestCheckRepo_ManagedFileCreatedWithMarker(t *testing.T) {
+	t.Parallel()
+
+	engine := managedEngine(t, false)
+	client := managedClient("")
+	client.contents["org/repo/.github/dependabot.yml"] = false
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	content := client.fileWrites[".github/dependabot.yml"]
+	if !strings.HasPrefix(content, "# repo-guardian:managed sha256=") {
+		t.Errorf("managed file should start with a marker, got:\n%s", content)
+	}
+
+	if rules.ParseManaged(content).Edited() {
+		t.Error("marker hash should match the committed body")
+	}
+}
+
+func TestCheckRepo_ManagedFileDrift(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		strict     bool
+		content    string
+		wantUpdate bool
+	}{
+		{
+			name:       "unedited stale file is updated",
+			content:    rules.StampManaged(".github/dependabot.yml", oldDependabot),
+			wantUpdate: true,
+		},
+		{
+			name:    "human edited file is left alone",
+			content: rules.StampManaged(".github/dependabot.yml", oldDependabot) + "# tweak\n",
+		},
+		{
+			name:    "unmanaged file is left alone",
+			content: oldDependabot,
+		},
+		{
+			name:       "strict overrides human edits",
+			strict:     true,
+			content:    oldDependabot,
+			wantUpdate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := managedEngine(t, tt.strict)
+			client := managedClient(tt.content)
+			client.tree = []string{"go.mod"}
+
+			if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+				t.Fatalf("CheckRepo: %v", err)
+			}
+
+			updated, ok := client.fileWrites[".github/dependabot.yml"]
+			if ok != tt.wantUpdate {
+				t.Fatalf("updated = %v, want %v", ok, tt.wantUpdate)
+			}
+
+			if !tt.wantUpdate {
+				return
+			}
+
+			if !strings.Contains(updated, `package-ecosystem: "gomod"`) {
+				t.Errorf("update should contain the current rendered content, got:\n%s", updated)
+			}
+
+			if !strings.Contains(client.createdPRBody, "updated to the current repo-guardian template") {
+				t.Errorf("PR body should describe the update, got:\n%s", client.createdPRBody)
+			}
+		})
+	}
+}
+
+func TestCheckRepo_ManagedFileUpToDate(t *testing.T) {
+	t.Parallel()
+
+	engine := managedEngine(t, true)
+
+	ts := rules.NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	rule := engine.registry.EnabledRules()[0]
+
+	current, err := ts.RenderRule(&rule, &rules.TemplateData{Files: []string{"go.mod"}})
+	if err != nil {
+		t.Fatalf("RenderRule: %v", err)
+	}
+
+	client := managedClient(rules.StampManaged(rule.TargetPath, current))
+	client.tree = []string{"go.mod"}
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if client.createdPR != nil {
+		t.Error("should not open a PR when the managed file is up to date")
+	}
+}
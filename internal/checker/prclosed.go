@@ -0,0 +1,171 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+)
+
+// managedBranches maps each branch repo-guardian opens PRs from to the kind
+// of change it proposes, used as a metric label.
+var managedBranches = map[string]string{
+	BranchName:            "files",
+	PropertiesBranchName:  "properties",
+	CatalogInfoBranchName: "catalog-info",
+}
+
+// ManagedBranchKind reports whether branch is one repo-guardian opens PRs
+// from, and if so which kind of change it proposes.
+func ManagedBranchKind(branch string) (string, bool) {
+	kind, ok := managedBranches[branch]
+	return kind, ok
+}
+
+// HandleClosedPR reacts to one of repo-guardian's own PRs being closed. The
+// branch of a merged PR is deleted. A PR closed without merging is recorded
+// as a decline by making sure it carries PRLabel, which is what CheckRepo
+// and the properties checks consult before proposing the change again,
+// unless repo-guardian withdrew it.
+func (e *Engine) HandleClosedPR(ctx context.Context, client ghclient.Client, owner, repo string, pr *ghclient.PullRequest) error {
+	kind, ok := ManagedBranchKind(pr.Head)
+	if !ok {
+		return nil
+	}
+
+	log := e.logger.With("owner", owner, "repo", repo, "pr_number", pr.Number, "branch", pr.Head)
+
+	if pr.Merged {
+		metrics.PRsMergedTotal.WithLabelValues(kind).Inc()
+		log.Info("repo-guardian PR merged")
+
+		if e.dryRun {
+			log.Info("dry run: would delete merged branch")
+			return nil
+		}
+
+		if err := client.DeleteBranch(ctx, owner, repo, pr.Head); err != nil {
+			return fmt.Errorf("deleting merged branch: %w", err)
+		}
+
+		return nil
+	}
+
+	if slices.Contains(pr.Labels, WithdrawnLabel) {
+		log.Info("withdrawn repo-guardian PR closed")
+		return nil
+	}
+
+	metrics.PRsDeclinedTotal.WithLabelValues(kind).Inc()
+	log.Info("repo-guardian PR closed without merging, recording decline", "backoff", e.declineBackoff)
+
+	if slices.Contains(pr.Labels, PRLabel) || e.dryRun {
+		return nil
+	}
+
+	if err := client.AddLabels(ctx, owner, repo, pr.Number, []string{PRLabel}); err != nil {
+		return fmt.Errorf("labeling declined PR: %w", err)
+	}
+
+	return nil
+}
+
+// WithdrawPRs closes repo-guardian's open PRs in a repository it no longer
+// looks after, e.g. one that was transferred to another account, and
+// deletes their branches. The PRs are labeled WithdrawnLabel first, so that
+// closing them is not taken for a decline.
+func (e *Engine) WithdrawPRs(ctx context.Context, client ghclient.Client, owner, repo string) error {
+	openPRs, err := client.ListOpenPullRequests(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("listing open PRs: %w", err)
+	}
+
+	var errs []error
+
+	for _, pr := range openPRs {
+		kind, ok := ManagedBranchKind(pr.Head)
+		if !ok {
+			continue
+		}
+
+		log := e.logger.With("owner", owner, "repo", repo, "pr_number", pr.Number, "branch", pr.Head)
+
+		if e.dryRun {
+			log.Info("dry run: would close PR and delete its branch")
+			continue
+		}
+
+		if err := client.AddLabels(ctx, owner, repo, pr.Number, []string{WithdrawnLabel}); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := client.ClosePullRequest(ctx, owner, repo, pr.Number); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := client.DeleteBranch(ctx, owner, repo, pr.Head); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		metrics.PRsWithdrawnTotal.WithLabelValues(kind).Inc()
+		log.Info("closed repo-guardian PR and deleted its branch")
+	}
+
+	return errors.Join(errs...)
+}
+
+// branchDeclined reports whether the most recent PR from branch was closed
+// without merging less than the decline backoff ago, so the properties
+// checks do not recreate it right away.
+func (e *Engine) branchDeclined(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo, branch string,
+) (bool, error) {
+	closed, err := client.ListClosedPullRequests(ctx, owner, repo, branch)
+	if err != nil {
+		return false, fmt.Errorf("listing closed PRs: %w", err)
+	}
+
+	var latest *ghclient.PullRequest
+
+	for _, pr := range closed {
+		if latest == nil || pr.ClosedAt.After(latest.ClosedAt) {
+			latest = pr
+		}
+	}
+
+	if latest == nil || !isDecline(latest) {
+		return false, nil
+	}
+
+	d := &Decline{PRNumber: latest.Number, ClosedAt: latest.ClosedAt}
+	if e.declineBackoff > 0 {
+		d.Until = latest.ClosedAt.Add(e.declineBackoff)
+	}
+
+	if !d.Active(e.now()) {
+		return false, nil
+	}
+
+	log.Info("PR declined, not recreating", "branch", branch, "pr_number", d.PRNumber, "expires", d.expiry())
+
+	return true, nil
+}
+
+// labelPR applies PRLabel to a newly created PR. Without the label a later
+// decline of the PR goes unnoticed, but the PR itself is still useful, so a
+// failure is only logged.
+func labelPR(ctx context.Context, log *slog.Logger, client ghclient.Client, owner, repo string, number int) {
+	if err := client.AddLabels(ctx, owner, repo, number, []string{PRLabel}); err != nil {
+		log.Warn("failed to label PR", "pr_number", number, "error", err)
+	}
+}
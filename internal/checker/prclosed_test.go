@@ -0,0 +1,170 @@
+package checker
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+)
+
+func TestHandleClosedPR_MergedDeletesBranch(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := newMockClient()
+
+	pr := &ghclient.PullRequest{Number: 3, Head: CatalogInfoBranchName, Merged: true}
+
+	if err := engine.HandleClosedPR(context.Background(), client, "org", "repo", pr); err != nil {
+		t.Fatalf("HandleClosedPR: %v", err)
+	}
+
+	if !slices.Equal(client.deletedBranches, []string{CatalogInfoBranchName}) {
+		t.Errorf("deleted branches = %v, want %s", client.deletedBranches, CatalogInfoBranchName)
+	}
+
+	if len(client.addedLabels) != 0 {
+		t.Errorf("merged PR should not be labeled, got %v", client.addedLabels)
+	}
+}
+
+func TestHandleClosedPR_DeclineLabelsPR(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		labels    []string
+		wantLabel bool
+	}{
+		{name: "unlabeled", wantLabel: true},
+		{name: "already labeled", labels: []string{PRLabel}},
+		{name: "withdrawn", labels: []string{WithdrawnLabel}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := testEngine(false)
+			client := newMockClient()
+
+			pr := &ghclient.PullRequest{Number: 4, Head: BranchName, Labels: tt.labels}
+
+			if err := engine.HandleClosedPR(context.Background(), client, "org", "repo", pr); err != nil {
+				t.Fatalf("HandleClosedPR: %v", err)
+			}
+
+			if got := slices.Contains(client.addedLabels[4], PRLabel); got != tt.wantLabel {
+				t.Errorf("labels added = %v, want label %v", client.addedLabels[4], tt.wantLabel)
+			}
+
+			if len(client.deletedBranches) != 0 {
+				t.Errorf("declined PR branch should not be deleted, got %v", client.deletedBranches)
+			}
+		})
+	}
+}
+
+func TestHandleClosedPR_IgnoresOtherBranches(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := newMockClient()
+
+	pr := &ghclient.PullRequest{Number: 5, Head: "feature/x", Merged: true}
+
+	if err := engine.HandleClosedPR(context.Background(), client, "org", "repo", pr); err != nil {
+		t.Fatalf("HandleClosedPR: %v", err)
+	}
+
+	if len(client.deletedBranches) != 0 {
+		t.Errorf("unmanaged branch should not be deleted, got %v", client.deletedBranches)
+	}
+}
+
+func TestGHAMode_DeclinedPRNotRecreated(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngineWithMode(false, "github-action")
+	engine.declineBackoff = 24 * time.Hour
+	client := basePropertiesClient()
+	client.fileContents["org/my-service/catalog-info.yaml"] = validCatalogInfo
+	client.closedPRs = []*ghclient.PullRequest{{
+		Number:   9,
+		Head:     PropertiesBranchName,
+		ClosedAt: time.Now().Add(-time.Hour),
+		Labels:   []string{PRLabel},
+	}}
+
+	err := engine.CheckCustomProperties(context.Background(), client, "org", "my-service", "main", nil)
+	if err != nil {
+		t.Fatalf("CheckCustomProperties: %v", err)
+	}
+
+	if client.createdPR != nil {
+		t.Errorf("declined properties PR should not be recreated, got %+v", client.createdPR)
+	}
+
+	// Once the backoff has passed, the PR is proposed again and labeled.
+	client.closedPRs[0].ClosedAt = time.Now().Add(-48 * time.Hour)
+
+	err = engine.CheckCustomProperties(context.Background(), client, "org", "my-service", "main", nil)
+	if err != nil {
+		t.Fatalf("CheckCustomProperties: %v", err)
+	}
+
+	if client.createdPR == nil {
+		t.Fatal("expected properties PR after the decline expired")
+	}
+
+	if !slices.Contains(client.addedLabels[client.createdPR.Number], PRLabel) {
+		t.Errorf("properties PR should be labeled, got %v", client.addedLabels)
+	}
+}
+
+func TestWithdrawPRs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		dryRun     bool
+		wantClosed []int
+	}{
+		{name: "closes managed PRs", wantClosed: []int{3, 5}},
+		{name: "dry run", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := testEngine(tt.dryRun)
+			client := newMockClient()
+			client.openPRs = []*ghclient.PullRequest{
+				{Number: 3, Head: BranchName, State: "open"},
+				{Number: 4, Head: "feature/x", State: "open"},
+				{Number: 5, Head: PropertiesBranchName, State: "open"},
+			}
+
+			if err := engine.WithdrawPRs(context.Background(), client, "org", "repo"); err != nil {
+				t.Fatalf("WithdrawPRs: %v", err)
+			}
+
+			if !slices.Equal(client.prsClosed, tt.wantClosed) {
+				t.Errorf("closed PRs = %v, want %v", client.prsClosed, tt.wantClosed)
+			}
+
+			if len(client.deletedBranches) != len(tt.wantClosed) {
+				t.Errorf("deleted branches = %v, want one per closed PR", client.deletedBranches)
+			}
+
+			for _, number := range tt.wantClosed {
+				if !slices.Contains(client.addedLabels[number], WithdrawnLabel) {
+					t.Errorf("PR %d labels = %v, want %s", number, client.addedLabels[number], WithdrawnLabel)
+				}
+			}
+		})
+	}
+}
@@ -36,21 +36,12 @@ func (e *Engine) CheckCustomProperties(
 	log := e.logger.With("owner", owner, "repo", repo, "mode", e.customPropertiesMode)
 	metrics.PropertiesCheckedTotal.Inc()
 
-	// Try to read catalog-info.yaml (then .yml).
-	content, err := client.GetFileContent(ctx, owner, repo, "catalog-info.yaml")
+	content, err := readCatalogInfo(ctx, client, owner, repo)
 	if err != nil {
-		return fmt.Errorf("reading catalog-info.yaml: %w", err)
+		return err
 	}
 
 	catalogFound := content != ""
-	if !catalogFound {
-		content, err = client.GetFileContent(ctx, owner, repo, "catalog-info.yml")
-		if err != nil {
-			return fmt.Errorf("reading catalog-info.yml: %w", err)
-		}
-
-		catalogFound = content != ""
-	}
 
 	// Parse content (returns Unclassified defaults if empty/invalid).
 	desired := catalog.Parse(content)
@@ -101,6 +92,10 @@ func (e *Engine) handleGHAMode(
 		return nil
 	}
 
+	if declined, err := e.branchDeclined(ctx, log, client, owner, repo, PropertiesBranchName); err != nil || declined {
+		return err
+	}
+
 	if e.dryRun {
 		log.Info("dry run: would create properties PR",
 			"owner_value", desired.Owner,
@@ -110,23 +105,25 @@ func (e *Engine) handleGHAMode(
 		return nil
 	}
 
+	if err := e.deferPR(); err != nil {
+		return err
+	}
+
 	// Handle stale branch cleanup.
 	if err := e.cleanupStaleBranch(ctx, client, owner, repo, PropertiesBranchName); err != nil {
 		return err
 	}
 
 	// Render template with actual values.
-	tmplContent, err := e.templates.Get("set-custom-properties")
+	data, err := buildTemplateData(ctx, client, owner, repo, defaultBranch, desired)
 	if err != nil {
-		return fmt.Errorf("getting set-custom-properties template: %w", err)
+		return err
 	}
 
-	rendered := renderTemplate(tmplContent, map[string]string{
-		"OWNER_VALUE":        desired.Owner,
-		"COMPONENT_VALUE":    desired.Component,
-		"JIRA_PROJECT_VALUE": desired.JiraProject,
-		"JIRA_LABEL_VALUE":   desired.JiraLabel,
-	})
+	rendered, err := e.templates.Render("set-custom-properties", data)
+	if err != nil {
+		return fmt.Errorf("rendering set-custom-properties template: %w", err)
+	}
 
 	// Create branch from default branch HEAD.
 	baseSHA, err := client.GetBranchSHA(ctx, owner, repo, defaultBranch)
@@ -146,7 +143,9 @@ func (e *Engine) handleGHAMode(
 	commitMsg := "chore: add workflow to set custom properties"
 	targetPath := ".github/workflows/set-custom-properties.yml"
 
-	if err := client.CreateOrUpdateFile(ctx, owner, repo, PropertiesBranchName, targetPath, rendered, commitMsg); err != nil {
+	files := []ghclient.FileChange{{Path: targetPath, Content: rendered}}
+
+	if _, err := client.CommitFiles(ctx, owner, repo, PropertiesBranchName, commitMsg, files); err != nil {
 		return fmt.Errorf("creating workflow file: %w", err)
 	}
 
@@ -160,6 +159,7 @@ func (e *Engine) handleGHAMode(
 
 	metrics.PropertiesPRsCreatedTotal.Inc()
 	log.Info("created properties PR", "pr_number", pr.Number)
+	labelPR(ctx, log, client, owner, repo, pr.Number)
 
 	return nil
 }
@@ -217,26 +217,35 @@ func (e *Engine) createCatalogInfoPR(
 		return nil
 	}
 
+	if declined, err := e.branchDeclined(ctx, log, client, owner, repo, CatalogInfoBranchName); err != nil || declined {
+		return err
+	}
+
 	if e.dryRun {
 		log.Info("dry run: would create catalog-info PR")
 		return nil
 	}
 
+	if err := e.deferPR(); err != nil {
+		return err
+	}
+
 	// Handle stale branch cleanup.
 	if err := e.cleanupStaleBranch(ctx, client, owner, repo, CatalogInfoBranchName); err != nil {
 		return err
 	}
 
-	// Render catalog-info template.
-	tmplContent, err := e.templates.Get("catalog-info")
+	// Render catalog-info template. The file is missing, so Properties
+	// holds the Unclassified defaults.
+	data, err := buildTemplateData(ctx, client, owner, repo, defaultBranch, catalog.Parse(""))
 	if err != nil {
-		return fmt.Errorf("getting catalog-info template: %w", err)
+		return err
 	}
 
-	rendered := renderTemplate(tmplContent, map[string]string{
-		"REPO_NAME": repo,
-		"ORG_NAME":  owner,
-	})
+	rendered, err := e.templates.Render("catalog-info", data)
+	if err != nil {
+		return fmt.Errorf("rendering catalog-info template: %w", err)
+	}
 
 	// Create branch from default branch HEAD.
 	baseSHA, err := client.GetBranchSHA(ctx, owner, repo, defaultBranch)
@@ -255,7 +264,9 @@ func (e *Engine) createCatalogInfoPR(
 	// Commit the catalog-info.yaml file.
 	commitMsg := "chore: add catalog-info.yaml"
 
-	if err := client.CreateOrUpdateFile(ctx, owner, repo, CatalogInfoBranchName, "catalog-info.yaml", rendered, commitMsg); err != nil {
+	files := []ghclient.FileChange{{Path: "catalog-info.yaml", Content: rendered}}
+
+	if _, err := client.CommitFiles(ctx, owner, repo, CatalogInfoBranchName, commitMsg, files); err != nil {
 		return fmt.Errorf("creating catalog-info.yaml: %w", err)
 	}
 
@@ -269,6 +280,7 @@ func (e *Engine) createCatalogInfoPR(
 
 	metrics.PropertiesPRsCreatedTotal.Inc()
 	log.Info("created catalog-info PR", "pr_number", pr.Number)
+	labelPR(ctx, log, client, owner, repo, pr.Number)
 
 	return nil
 }
@@ -339,14 +351,21 @@ func diffProperties(desired *catalog.Properties, current []*ghclient.CustomPrope
 	return false
 }
 
-// renderTemplate performs simple string replacement of placeholders in template content.
-func renderTemplate(content string, replacements map[string]string) string {
-	result := content
-	for placeholder, value := range replacements {
-		result = strings.ReplaceAll(result, placeholder, value)
+// readCatalogInfo returns the content of catalog-info.yaml (then .yml), or
+// an empty string if neither exists.
+func readCatalogInfo(ctx context.Context, client ghclient.Client, owner, repo string) (string, error) {
+	for _, path := range []string{"catalog-info.yaml", "catalog-info.yml"} {
+		content, err := client.GetFileContent(ctx, owner, repo, path)
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		if content != "" {
+			return content, nil
+		}
 	}
 
-	return result
+	return "", nil
 }
 
 // desiredToPropertyValues converts catalog Properties to GitHub CustomPropertyValue slice.
@@ -398,7 +417,7 @@ func buildGHABody(sb *strings.Builder, props *catalog.Properties) {
 	sb.WriteString("### Properties to be set\n\n")
 	writePropertyList(sb, props)
 	sb.WriteString("\n### What happens when merged\n\n")
-	sb.WriteString("The included GitHub Actions workflow runs once on push to `main` and sets\n")
+	sb.WriteString("The included GitHub Actions workflow runs once on push to the default branch and sets\n")
 	sb.WriteString("the above custom properties on this repository. The workflow can be safely\n")
 	sb.WriteString("deleted after it runs.\n\n")
 }
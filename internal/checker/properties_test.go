@@ -2,6 +2,7 @@ package checker
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -506,6 +507,39 @@ func TestCustomProperties_Disabled(t *testing.T) {
 	}
 }
 
+func TestCheckProperties_SkipsFileRules(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngineWithMode(false, "api")
+	client := basePropertiesClient()
+	client.fileContents["org/my-service/catalog-info.yaml"] = validCatalogInfo
+	// Every rule's file is missing, but a properties-only check ignores them.
+
+	if err := engine.CheckProperties(context.Background(), client, "org", "my-service"); err != nil {
+		t.Fatalf("CheckProperties: %v", err)
+	}
+
+	if len(client.setProperties) == 0 {
+		t.Error("expected properties to be set")
+	}
+
+	if client.createdPR != nil {
+		t.Errorf("properties-only check should not open a file-rule PR, got %+v", client.createdPR)
+	}
+}
+
+func TestCheckProperties_ReturnsErrors(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngineWithMode(false, "api")
+	client := basePropertiesClient()
+	client.getCustomPropsErr = errors.New("502 bad gateway")
+
+	if err := engine.CheckProperties(context.Background(), client, "org", "my-service"); err == nil {
+		t.Error("expected the properties error to be returned so the job is retried")
+	}
+}
+
 // --- Helper unit tests ---
 
 func TestDiffProperties(t *testing.T) {
@@ -617,3 +651,46 @@ func TestBuildPropertiesPRBody_APIMode(t *testing.T) {
 func parseForTest(content string) *catalog.Properties {
 	return catalog.Parse(content)
 }
+
+func TestGHAMode_RendersWorkflowTemplate(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngineWithMode(false, "github-action")
+	client := basePropertiesClient()
+	client.fileContents["org/my-service/catalog-info.yaml"] = validCatalogInfo
+
+	err := engine.CheckCustomProperties(context.Background(), client, "org", "my-service", "main", nil)
+	if err != nil {
+		t.Fatalf("CheckCustomProperties: %v", err)
+	}
+
+	workflow := client.fileWrites[".github/workflows/set-custom-properties.yml"]
+
+	for _, want := range []string{
+		"properties[][value]=platform-team",
+		"properties[][value]=PROJ",
+		"${{ secrets.GITHUB_TOKEN }}",
+	} {
+		if !strings.Contains(workflow, want) {
+			t.Errorf("workflow should contain %q, got:\n%s", want, workflow)
+		}
+	}
+}
+
+func TestAPIMode_RendersCatalogInfoTemplate(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngineWithMode(false, "api")
+	client := basePropertiesClient()
+
+	err := engine.CheckCustomProperties(context.Background(), client, "org", "my-service", "main", nil)
+	if err != nil {
+		t.Fatalf("CheckCustomProperties: %v", err)
+	}
+
+	content := client.fileWrites["catalog-info.yaml"]
+	if !strings.Contains(content, "name: my-service") ||
+		!strings.Contains(content, "url:https://github.com/org/my-service") {
+		t.Errorf("catalog-info.yaml not rendered with repo data:\n%s", content)
+	}
+}
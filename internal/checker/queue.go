@@ -2,8 +2,11 @@ package checker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,75 +28,395 @@ const (
 	TriggerManual Trigger = "manual"
 )
 
-// RepoJob represents a unit of work for the checker engine.
+// JobKind selects which checks a job runs.
+type JobKind string
+
+const (
+	// KindFull runs the file rules and the custom properties check. It is
+	// the zero value, so jobs stored without a kind are full checks.
+	KindFull JobKind = ""
+
+	// KindProperties only re-checks custom properties.
+	KindProperties JobKind = "properties"
+)
+
+func (k JobKind) String() string {
+	if k == KindFull {
+		return "full"
+	}
+
+	return string(k)
+}
+
+// RepoJob represents a unit of work for the checker engine. The JSON form is
+// what persistent JobStores write to disk.
 type RepoJob struct {
-	Owner          string
-	Repo           string
-	InstallationID int64
-	Trigger        Trigger
+	Owner          string  `json:"owner"`
+	Repo           string  `json:"repo"`
+	InstallationID int64   `json:"installationId"`
+	Trigger        Trigger `json:"trigger"`
+	Kind           JobKind `json:"kind,omitempty"`
+
+	// Attempt is the number of times the job has already failed with a
+	// retryable error.
+	Attempt int `json:"attempt,omitempty"`
+
+	// RetryAt delays a retried job until its backoff has elapsed.
+	RetryAt time.Time `json:"retryAt,omitzero"`
+
+	// DeferredUntil delays a job that needs to open a pull request until
+	// the next PR window. Until then the job does not count toward the
+	// queue size.
+	DeferredUntil time.Time `json:"deferredUntil,omitzero"`
 }
 
-// Queue is a buffered work queue that dispatches RepoJobs to worker goroutines.
+// readyAt returns when the job may be dispatched.
+func (j *RepoJob) readyAt() time.Time {
+	if j.DeferredUntil.After(j.RetryAt) {
+		return j.DeferredUntil
+	}
+
+	return j.RetryAt
+}
+
+// priority orders triggers: it selects the lane a job is dispatched from,
+// and the higher trigger wins when duplicate jobs are coalesced.
+func (t Trigger) priority() int {
+	switch t {
+	case TriggerManual:
+		return 2
+	case TriggerWebhook:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// repoKey identifies the repository a job operates on. Jobs with the same
+// key are coalesced while pending and never processed concurrently.
+type repoKey struct {
+	owner, repo    string
+	installationID int64
+}
+
+func (j *RepoJob) key() repoKey {
+	return repoKey{
+		owner:          strings.ToLower(j.Owner),
+		repo:           strings.ToLower(j.Repo),
+		installationID: j.InstallationID,
+	}
+}
+
+// queuedJob is a job together with its JobStore ID.
+type queuedJob struct {
+	id         uint64
+	job        RepoJob
+	enqueuedAt time.Time
+}
+
+// Queue is a bounded work queue that dispatches RepoJobs to worker goroutines.
+// Jobs are recorded in a JobStore until a worker has processed them. A job
+// for a repository that is already pending is merged into the pending job,
+// and a repository is only ever processed by one worker at a time. Pending
+// jobs are dispatched by priority lane and, within a lane, round-robin across
+// installations; see pick.
 type Queue struct {
-	ch     chan RepoJob
+	size   int
+	store  JobStore
+	retry  RetryPolicy
 	logger *slog.Logger
 	wg     sync.WaitGroup
 
 	mu       sync.Mutex
-	stopped  bool
-	cancelFn context.CancelFunc
+	cond     *sync.Cond // broadcast when a job or queue capacity may have become available
+	pending  []*queuedJob
+	byKey    map[repoKey]*queuedJob // pending jobs
+	inFlight map[repoKey]bool
+	passed   [numLanes]int // dispatches that skipped each lane's ready job
+
+	installLimit int              // max in-flight jobs per installation; 0 is unlimited
+	running      map[int64]int    // in-flight jobs per installation
+	served       map[int64]uint64 // dispatch sequence of each installation's last job
+	dispatches   uint64
+
+	suspended map[int64]bool // installations whose jobs are refused; in memory only
+
+	delayed map[repoKey]*queuedJob // jobs waiting for a PR window, not counted toward size
+
+	jobTimeout    time.Duration
+	shutdownGrace time.Duration
+	abortJobs     context.CancelFunc // cancels in-flight jobs once the grace period ends
+	aborted       bool
+	stopped       bool
+	cancelFn      context.CancelFunc
 }
 
-// NewQueue creates a Queue with the given buffer size.
+// NewQueue creates an in-memory Queue that holds up to size pending jobs.
 func NewQueue(size int, logger *slog.Logger) *Queue {
-	return &Queue{
-		ch:     make(chan RepoJob, size),
-		logger: logger,
+	q := &Queue{
+		size:      size,
+		store:     NewMemoryStore(),
+		retry:     DefaultRetryPolicy(),
+		logger:    logger,
+		byKey:     make(map[repoKey]*queuedJob),
+		inFlight:  make(map[repoKey]bool),
+		running:   make(map[int64]int),
+		served:    make(map[int64]uint64),
+		suspended: make(map[int64]bool),
+		delayed:   make(map[repoKey]*queuedJob),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	return q
+}
+
+// NewQueueWithStore creates a Queue backed by store and reloads the jobs left
+// pending in it by a previous process, even if there are more than size.
+// The Queue takes ownership of store and closes it on Stop.
+func NewQueueWithStore(size int, store JobStore, logger *slog.Logger) (*Queue, error) {
+	pending, err := store.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	dead, err := store.DeadLetters()
+	if err != nil {
+		return nil, err
+	}
+
+	q := NewQueue(size, logger)
+	q.store = store
+
+	for _, p := range pending {
+		if err := q.add(p.ID, p.Job); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pending) > 0 {
+		metrics.QueueJobsRecoveredTotal.Add(float64(len(pending)))
+		logger.Info("resumed pending jobs", "count", len(q.pending))
 	}
+
+	metrics.DeadLetterJobs.Set(float64(len(dead)))
+
+	return q, nil
+}
+
+// SetRetryPolicy replaces the DefaultRetryPolicy. It must be called before
+// Start.
+func (q *Queue) SetRetryPolicy(p RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.retry = p
 }
 
-// Enqueue adds a job to the queue. Returns an error if the queue is full.
+// Enqueue adds a job to the queue. If a job for the same repository is
+// already pending, the two are merged instead. Returns an error if the
+// queue is full.
 func (q *Queue) Enqueue(job RepoJob) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	return q.enqueue(job)
+}
+
+// EnqueueWait is like Enqueue, but when the queue is full it waits for
+// capacity until ctx is done or the queue is stopped. The bool reports
+// whether it had to wait. It is meant for bulk producers such as the
+// scheduler; latency-sensitive callers should use Enqueue.
+func (q *Queue) EnqueueWait(ctx context.Context, job RepoJob) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.full(job) {
+		return false, q.enqueue(job)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	})
+	defer stop()
+
+	for q.full(job) && !q.stopped && !q.suspended[job.InstallationID] {
+		if err := ctx.Err(); err != nil {
+			return true, fmt.Errorf("waiting for queue capacity: %w", err)
+		}
+
+		q.cond.Wait()
+	}
+
+	return true, q.enqueue(job)
+}
+
+// full reports whether job would be rejected for lack of capacity. Merging
+// never grows the queue, so it is allowed when full; jobs waiting for a PR
+// window are not counted. The caller must hold q.mu.
+func (q *Queue) full(job RepoJob) bool {
+	key := job.key()
+
+	return q.byKey[key] == nil && q.delayed[key] == nil && len(q.pending) >= q.size
+}
+
+// enqueue implements Enqueue. The caller must hold q.mu.
+func (q *Queue) enqueue(job RepoJob) error {
 	if q.stopped {
 		return fmt.Errorf("queue is stopped")
 	}
 
-	select {
-	case q.ch <- job:
-		q.logger.Debug("job enqueued",
-			"owner", job.Owner,
-			"repo", job.Repo,
-			"trigger", job.Trigger,
-		)
+	if q.suspended[job.InstallationID] {
+		return fmt.Errorf("%w: %d", ErrInstallationSuspended, job.InstallationID)
+	}
+
+	// Check capacity before persisting so a rejected job is never stored.
+	if q.full(job) {
+		return fmt.Errorf("queue is full (capacity %d)", q.size)
+	}
+
+	id, err := q.store.Add(job)
+	if err != nil {
+		return fmt.Errorf("persisting job: %w", err)
+	}
+
+	if err := q.add(id, job); err != nil {
+		return err
+	}
+
+	q.logger.Debug("job enqueued",
+		"owner", job.Owner,
+		"repo", job.Repo,
+		"trigger", job.Trigger,
+	)
+
+	return nil
+}
+
+// add queues a stored job, or merges it into the pending job for the same
+// repository, keeping the higher-priority trigger and the earlier position.
+// The caller must hold q.mu.
+func (q *Queue) add(id uint64, job RepoJob) error {
+	// A job for a repository waiting on its PR window joins it in pending.
+	q.undelay(job.key())
+
+	existing, ok := q.byKey[job.key()]
+	if !ok {
+		qj := &queuedJob{id: id, job: job, enqueuedAt: time.Now()}
+		if job.DeferredUntil.After(qj.enqueuedAt) {
+			q.delay(qj)
+			return nil
+		}
+
+		q.pending = append(q.pending, qj)
+		q.byKey[job.key()] = qj
+		metrics.QueueLaneDepth.WithLabelValues(laneNames[job.lane()]).Inc()
+
+		// Producers in EnqueueWait share the condition, so wake everyone.
+		q.cond.Broadcast()
 
 		return nil
-	default:
-		return fmt.Errorf("queue is full (capacity %d)", cap(q.ch))
 	}
+
+	metrics.QueueJobsCoalescedTotal.WithLabelValues(string(job.Trigger)).Inc()
+	q.logger.Debug("job coalesced with pending job",
+		"owner", job.Owner,
+		"repo", job.Repo,
+		"trigger", job.Trigger,
+		"pending_trigger", existing.job.Trigger,
+	)
+
+	// Keep exactly one stored record per pending job.
+	merged := mergeJobs(existing.job, job)
+	moveLane(existing.job.lane(), merged.lane())
+
+	switch merged {
+	case existing.job:
+		return q.removeStored(id)
+	case job:
+		drop := existing.id
+		existing.id, existing.job = id, job
+
+		return q.removeStored(drop)
+	}
+
+	mergedID, err := q.store.Add(merged)
+	if err != nil {
+		return fmt.Errorf("persisting coalesced job: %w", err)
+	}
+
+	drop := []uint64{existing.id, id}
+	existing.id, existing.job = mergedID, merged
+
+	return q.removeStored(drop...)
+}
+
+// mergeJobs combines two jobs for the same repository. The higher-priority
+// trigger wins, a full check covers a properties-only one, and a fresh job
+// cancels the other's pending retry backoff and PR window deferral.
+func mergeJobs(pending, incoming RepoJob) RepoJob {
+	merged := pending
+
+	if incoming.Trigger.priority() > merged.Trigger.priority() {
+		merged.Trigger = incoming.Trigger
+	}
+
+	if incoming.Kind == KindFull {
+		merged.Kind = KindFull
+	}
+
+	if incoming.Attempt == 0 {
+		merged.Attempt = 0
+		merged.RetryAt = time.Time{}
+		merged.DeferredUntil = time.Time{}
+	}
+
+	return merged
+}
+
+func (q *Queue) removeStored(ids ...uint64) error {
+	for _, id := range ids {
+		if err := q.store.Remove(id); err != nil {
+			return fmt.Errorf("removing coalesced job: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // Start launches worker goroutines that pull jobs from the queue and
-// call the checker engine. It blocks until the context is canceled.
+// call the checker engine. It returns immediately; workers run until the
+// context is canceled or Stop is called. Canceling ctx stops dispatching new
+// jobs but does not interrupt jobs in flight; see Stop.
 func (q *Queue) Start(ctx context.Context, workers int, engine *Engine, ghClient ghclient.Client) {
 	workerCtx, cancel := context.WithCancel(ctx)
+	jobsCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
 
 	q.mu.Lock()
 	q.cancelFn = cancel
+	q.abortJobs = abort
 	q.mu.Unlock()
 
+	// Wake idle workers so they notice cancellation.
+	context.AfterFunc(workerCtx, func() {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	})
+
 	for i := range workers {
 		q.wg.Add(1)
 
-		go q.worker(workerCtx, i, engine, ghClient)
+		go q.worker(workerCtx, jobsCtx, i, engine, ghClient)
 	}
 
-	q.logger.Info("work queue started", "workers", workers, "capacity", cap(q.ch))
+	q.logger.Info("work queue started", "workers", workers, "capacity", q.size)
 }
 
-// Stop signals all workers to finish and waits for in-flight work to complete.
+// Stop signals all workers to finish, gives in-flight jobs the shutdown grace
+// period to complete, aborts any still running, and closes the store. Jobs
+// not yet processed, and aborted jobs, remain in a persistent store.
 func (q *Queue) Stop() {
 	q.mu.Lock()
 	q.stopped = true
@@ -102,16 +425,110 @@ func (q *Queue) Stop() {
 		q.cancelFn()
 	}
 
-	close(q.ch)
+	q.cond.Broadcast()
 	q.mu.Unlock()
-	q.wg.Wait()
+	q.drain()
 
-	q.logger.Info("work queue stopped")
+	if err := q.store.Close(); err != nil {
+		q.logger.Error("failed to close job store", "error", err)
+	}
+
+	q.logger.Info("work queue stopped", "pending", q.Len())
 }
 
 // Len returns the number of pending items in the queue.
 func (q *Queue) Len() int {
-	return len(q.ch)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// Discard removes the pending job for a repository, e.g. after the
+// repository was archived or deleted, and reports whether there was one. A
+// job already being processed is not interrupted.
+func (q *Queue) Discard(owner, repo string, installationID int64) (bool, error) {
+	key := (&RepoJob{Owner: owner, Repo: repo, InstallationID: installationID}).key()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if qj, ok := q.delayed[key]; ok {
+		return true, q.removeDelayed(qj)
+	}
+
+	qj, ok := q.byKey[key]
+	if !ok {
+		return false, nil
+	}
+
+	return true, q.removePending(qj)
+}
+
+// DiscardRepo removes the pending jobs for a repository under any
+// installation, e.g. after it was transferred between installations, and
+// returns how many there were. Jobs already being processed are not
+// interrupted.
+func (q *Queue) DiscardRepo(owner, repo string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.dropJobs(func(job *RepoJob) bool {
+		return strings.EqualFold(job.Owner, owner) && strings.EqualFold(job.Repo, repo)
+	})
+}
+
+// dropJobs removes the pending and deferred jobs that match and returns how
+// many it removed. The caller must hold q.mu.
+func (q *Queue) dropJobs(match func(job *RepoJob) bool) (int, error) {
+	var (
+		dropped []*queuedJob
+		errs    []error
+	)
+
+	for _, qj := range q.pending {
+		if match(&qj.job) {
+			dropped = append(dropped, qj)
+		}
+	}
+
+	for _, qj := range dropped {
+		if err := q.removePending(qj); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, qj := range q.delayed {
+		if !match(&qj.job) {
+			continue
+		}
+
+		dropped = append(dropped, qj)
+
+		if err := q.removeDelayed(qj); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(dropped), errors.Join(errs...)
+}
+
+// removePending removes a pending job from the queue and the store. The
+// caller must hold q.mu.
+func (q *Queue) removePending(qj *queuedJob) error {
+	i := slices.Index(q.pending, qj)
+	q.pending = slices.Delete(q.pending, i, i+1)
+	delete(q.byKey, qj.job.key())
+	metrics.QueueLaneDepth.WithLabelValues(laneNames[qj.job.lane()]).Dec()
+
+	// Wake producers waiting for capacity in EnqueueWait.
+	q.cond.Broadcast()
+
+	if err := q.store.Remove(qj.id); err != nil {
+		return fmt.Errorf("removing discarded job: %w", err)
+	}
+
+	return nil
 }
 
 // Accepting returns true if the queue is accepting new jobs.
@@ -122,24 +539,70 @@ func (q *Queue) Accepting() bool {
 	return !q.stopped
 }
 
-func (q *Queue) worker(ctx context.Context, id int, engine *Engine, ghClient ghclient.Client) {
+func (q *Queue) worker(ctx, jobsCtx context.Context, id int, engine *Engine, ghClient ghclient.Client) {
 	defer q.wg.Done()
 
 	log := q.logger.With("worker_id", id)
 	log.Debug("worker started")
 
-	for job := range q.ch {
-		select {
-		case <-ctx.Done():
+	for {
+		qj, ok := q.next(ctx)
+		if !ok {
 			log.Debug("worker shutting down")
 			return
-		default:
 		}
 
-		processJob(ctx, log, engine, ghClient, job)
+		err := q.runJob(jobsCtx, log, engine, ghClient, qj.job)
+		q.done(log, qj, err)
+	}
+}
+
+// next blocks until a job is ready: its retry backoff, if any, has elapsed
+// and its repository is not already being processed. The highest-priority
+// lane with a ready job is served first. It marks that
+// repository in flight, and returns false once the queue is stopped or ctx
+// is canceled.
+func (q *Queue) next(ctx context.Context) (*queuedJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.stopped || ctx.Err() != nil {
+			return nil, false
+		}
+
+		now := time.Now()
+		undeferred := q.promote(now)
+
+		i, wake := q.pick(now)
+		if i >= 0 {
+			return q.take(i, now), true
+		}
+
+		if !undeferred.IsZero() && (wake.IsZero() || undeferred.Before(wake)) {
+			wake = undeferred
+		}
+
+		q.wait(wake)
+	}
+}
+
+// wait blocks on q.cond until it is signaled or, if wake is set, until wake.
+// The caller must hold q.mu.
+func (q *Queue) wait(wake time.Time) {
+	if wake.IsZero() {
+		q.cond.Wait()
+		return
 	}
 
-	log.Debug("worker finished")
+	timer := time.AfterFunc(time.Until(wake), func() {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	})
+
+	q.cond.Wait()
+	timer.Stop()
 }
 
 func processJob(
@@ -148,13 +611,15 @@ func processJob(
 	engine *Engine,
 	ghClient ghclient.Client,
 	job RepoJob,
-) {
+) error {
 	start := time.Now()
 	jobLog := log.With(
 		"owner", job.Owner,
 		"repo", job.Repo,
 		"trigger", job.Trigger,
 		"installation_id", job.InstallationID,
+		"kind", job.Kind.String(),
+		"attempt", job.Attempt+1,
 	)
 
 	jobLog.Info("processing job")
@@ -165,18 +630,31 @@ func processJob(
 		jobLog.Error("failed to create installation client", "error", err)
 		metrics.ErrorsTotal.WithLabelValues("create_install_client").Inc()
 
-		return
+		return fmt.Errorf("creating installation client: %w", err)
 	}
 
-	if err := engine.CheckRepo(ctx, installClient, job.Owner, job.Repo); err != nil {
+	check := engine.CheckRepo
+	if job.Kind == KindProperties {
+		check = engine.CheckProperties
+	}
+
+	if err := check(ctx, installClient, job.Owner, job.Repo); err != nil {
+		var deferred *DeferredError
+		if errors.As(err, &deferred) {
+			jobLog.Info("job needs a PR outside the PR windows", "until", deferred.Until)
+			return err
+		}
+
 		jobLog.Error("job failed", "error", err, "duration", time.Since(start))
 		metrics.ErrorsTotal.WithLabelValues("check_repo").Inc()
 
-		return
+		return err
 	}
 
 	duration := time.Since(start)
 	metrics.ReposCheckedTotal.WithLabelValues(string(job.Trigger)).Inc()
 	metrics.CheckDurationSeconds.Observe(duration.Seconds())
 	jobLog.Info("job completed", "duration", duration)
+
+	return nil
 }
@@ -2,6 +2,8 @@ package checker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"testing"
 	"time"
@@ -73,7 +75,7 @@ func TestWorkers_ProcessJobs(t *testing.T) {
 	for i := range jobCount {
 		job := RepoJob{
 			Owner:          "org",
-			Repo:           "repo",
+			Repo:           fmt.Sprintf("repo-%d", i),
 			InstallationID: 1,
 			Trigger:        TriggerWebhook,
 		}
@@ -141,3 +143,236 @@ func TestStop_DrainsGracefully(t *testing.T) {
 		t.Error("queue should not be accepting after Stop")
 	}
 }
+
+func TestEnqueue_CoalescesPendingJobs(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(1, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	jobs := []RepoJob{
+		{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerScheduler},
+		{Owner: "Org", Repo: "Repo", InstallationID: 1, Trigger: TriggerWebhook},
+		{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerScheduler},
+	}
+
+	// The queue holds a single job, but merged jobs never count against it.
+	for i, job := range jobs {
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue %d: %v", i, err)
+		}
+	}
+
+	if q.Len() != 1 {
+		t.Fatalf("expected 1 pending job, got %d", q.Len())
+	}
+
+	if got := q.pending[0].job.Trigger; got != TriggerWebhook {
+		t.Errorf("merged trigger = %q, want %q", got, TriggerWebhook)
+	}
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	if len(pending) != 1 || pending[0].Job.Trigger != TriggerWebhook {
+		t.Errorf("store should hold one webhook job, got %+v", pending)
+	}
+
+	// A different installation is a different job.
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "repo", InstallationID: 2, Trigger: TriggerWebhook}); err == nil {
+		t.Error("expected full queue error for a job from another installation")
+	}
+}
+
+func TestNext_SkipsRepoInFlight(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+	job := RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerWebhook}
+
+	if err := q.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	first, ok := q.next(context.Background())
+	if !ok {
+		t.Fatal("expected a job")
+	}
+
+	// The same repo is enqueued again while the first job is running.
+	if err := q.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	got := make(chan *queuedJob, 1)
+
+	go func() {
+		qj, _ := q.next(context.Background())
+		got <- qj
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("second job for the same repo was handed out while the first was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.done(slog.Default(), first, nil)
+
+	select {
+	case qj := <-got:
+		if qj.job.Repo != "repo" {
+			t.Errorf("unexpected job %+v", qj.job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second job was not released after the first finished")
+	}
+}
+
+func TestNewQueueWithStore_CoalescesResumedJobs(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	for _, trigger := range []Trigger{TriggerScheduler, TriggerManual, TriggerWebhook} {
+		if _, err := store.Add(RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: trigger}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	q, err := NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	if q.Len() != 1 || q.pending[0].job.Trigger != TriggerManual {
+		t.Errorf("expected one manual job, got %d pending", q.Len())
+	}
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	if len(pending) != 1 {
+		t.Errorf("duplicate stored jobs should be removed, got %+v", pending)
+	}
+}
+
+func TestEnqueueWait_WaitsForCapacity(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(1, slog.Default())
+
+	waited, err := q.EnqueueWait(context.Background(), RepoJob{Owner: "org", Repo: "a", InstallationID: 1})
+	if err != nil || waited {
+		t.Fatalf("EnqueueWait with capacity = %v, %v", waited, err)
+	}
+
+	// The webhook path still fails fast.
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "b", InstallationID: 1}); err == nil {
+		t.Fatal("expected Enqueue to fail on a full queue")
+	}
+
+	result := make(chan error, 1)
+
+	go func() {
+		waited, err := q.EnqueueWait(context.Background(), RepoJob{Owner: "org", Repo: "b", InstallationID: 1})
+		if err == nil && !waited {
+			err = fmt.Errorf("expected EnqueueWait to report waiting")
+		}
+
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		t.Fatalf("EnqueueWait returned before capacity was available: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A worker taking the pending job frees capacity.
+	qj, _ := q.next(context.Background())
+	q.done(slog.Default(), qj, nil)
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EnqueueWait did not resume when capacity became available")
+	}
+
+	if q.Len() != 1 {
+		t.Errorf("expected the waiting job to be queued, got %d pending", q.Len())
+	}
+}
+
+func TestEnqueueWait_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(1, slog.Default())
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "a", InstallationID: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	waited, err := q.EnqueueWait(ctx, RepoJob{Owner: "org", Repo: "b", InstallationID: 1})
+	if !waited || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("EnqueueWait = %v, %v; want true, DeadlineExceeded", waited, err)
+	}
+}
+
+func TestDiscard_RemovesPendingJob(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "gone", InstallationID: 1, Trigger: TriggerScheduler})
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "kept", InstallationID: 1, Trigger: TriggerScheduler})
+
+	ok, err := q.Discard("Org", "Gone", 1)
+	if err != nil || !ok {
+		t.Fatalf("Discard = %v, %v; want true", ok, err)
+	}
+
+	if ok, _ := q.Discard("org", "gone", 1); ok {
+		t.Error("second Discard should find nothing")
+	}
+
+	pending, _ := store.Pending()
+	if q.Len() != 1 || len(pending) != 1 || pending[0].Job.Repo != "kept" {
+		t.Errorf("expected only the kept job to remain, got %d pending, store %+v", q.Len(), pending)
+	}
+}
+
+func TestDiscardRepo_AllInstallations(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "moved", InstallationID: 1, Trigger: TriggerScheduler})
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "moved", InstallationID: 2, Trigger: TriggerWebhook})
+	_ = q.Enqueue(RepoJob{Owner: "org", Repo: "kept", InstallationID: 1, Trigger: TriggerScheduler})
+
+	dropped, err := q.DiscardRepo("Org", "Moved")
+	if err != nil || dropped != 2 {
+		t.Fatalf("DiscardRepo = %d, %v; want 2", dropped, err)
+	}
+
+	if q.Len() != 1 {
+		t.Errorf("expected only the kept job to remain, got %d pending", q.Len())
+	}
+}
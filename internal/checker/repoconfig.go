@@ -0,0 +1,192 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/repoconfig"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
+)
+
+// InvalidConfigIssueTitle is the title of the issue opened when a repository's
+// .github/repo-guardian.yml cannot be parsed or references unknown rules.
+const InvalidConfigIssueTitle = "repo-guardian: invalid .github/repo-guardian.yml"
+
+// loadRepoConfig reads and validates the per-repository configuration file.
+// It returns false when the file is invalid; in that case the problem has
+// already been reported to the repository and the caller should stop.
+func (e *Engine) loadRepoConfig(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+) (*repoconfig.Config, bool, error) {
+	content, err := client.GetFileContent(ctx, owner, repo, repoconfig.Path)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading %s: %w", repoconfig.Path, err)
+	}
+
+	cfg, cfgErr := e.parseRepoConfig(content)
+	if cfgErr == nil {
+		return cfg, true, nil
+	}
+
+	log.Warn("invalid repo config, skipping repository", "path", repoconfig.Path, "error", cfgErr)
+	metrics.RepoConfigInvalidTotal.Inc()
+
+	if err := e.reportInvalidRepoConfig(ctx, log, client, owner, repo, cfgErr); err != nil {
+		return nil, false, err
+	}
+
+	return nil, false, nil
+}
+
+// parseRepoConfig parses the file and checks that every referenced rule and
+// template is known to this instance.
+func (e *Engine) parseRepoConfig(content string) (*repoconfig.Config, error) {
+	cfg, err := repoconfig.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+
+	for _, name := range cfg.RuleNames() {
+		if _, ok := e.registry.RuleByName(name); !ok {
+			errs = append(errs, fmt.Errorf("unknown rule %q", name))
+		}
+	}
+
+	for name, o := range cfg.Overrides {
+		if o.Template == "" {
+			continue
+		}
+
+		if _, err := e.templates.Get(o.Template); err != nil {
+			errs = append(errs, fmt.Errorf("overrides.%s: %w", name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// applicableRules returns the enabled rules with the repository's excludes
+// removed and overrides applied.
+func (e *Engine) applicableRules(log *slog.Logger, cfg *repoconfig.Config) []rules.FileRule {
+	enabled := e.registry.EnabledRules()
+	result := make([]rules.FileRule, 0, len(enabled))
+
+	for i := range enabled {
+		rule := &enabled[i]
+		if cfg.Excludes(rule.Name) {
+			log.Info("rule excluded by repo config", "rule", rule.Name)
+			continue
+		}
+
+		if o, ok := cfg.OverrideFor(rule.Name); ok {
+			applyOverride(rule, o)
+		}
+
+		result = append(result, *rule)
+	}
+
+	return result
+}
+
+// applyOverride applies o to rule in place. An overridden template replaces
+// any generator, since the team asked for that exact content. An overridden
+// TargetPath is also added to Paths so the existence check recognizes a file
+// at the team's chosen location. Paths is reallocated so the registry's slice
+// is untouched.
+func applyOverride(rule *rules.FileRule, o repoconfig.Override) {
+	if o.Template != "" {
+		rule.DefaultTemplateName = o.Template
+		rule.Generator = ""
+	}
+
+	if o.TargetPath != "" {
+		rule.TargetPath = o.TargetPath
+
+		paths := make([]string, 0, len(rule.Paths)+1)
+		paths = append(paths, o.TargetPath)
+
+		for _, p := range rule.Paths {
+			if p != o.TargetPath {
+				paths = append(paths, p)
+			}
+		}
+
+		rule.Paths = paths
+	}
+}
+
+// reportInvalidRepoConfig opens an issue describing the configuration error,
+// unless one is already open.
+func (e *Engine) reportInvalidRepoConfig(
+	ctx context.Context,
+	log *slog.Logger,
+	client ghclient.Client,
+	owner, repo string,
+	cfgErr error,
+) error {
+	if e.dryRun {
+		log.Info("dry run: would open issue for invalid repo config")
+		return nil
+	}
+
+	issues, err := client.ListOpenIssues(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("listing open issues: %w", err)
+	}
+
+	for _, issue := range issues {
+		if issue.Title == InvalidConfigIssueTitle {
+			log.Info("invalid repo config issue already open", "issue_number", issue.Number)
+			return nil
+		}
+	}
+
+	issue, err := client.CreateIssue(ctx, owner, repo, InvalidConfigIssueTitle, buildInvalidConfigBody(cfgErr))
+	if err != nil {
+		return fmt.Errorf("creating invalid config issue: %w", err)
+	}
+
+	log.Info("opened issue for invalid repo config", "issue_number", issue.Number)
+
+	return nil
+}
+
+func buildInvalidConfigBody(cfgErr error) string {
+	var sb strings.Builder
+
+	sb.WriteString("## Repo Guardian — Invalid Configuration\n\n")
+	fmt.Fprintf(&sb, "**repo-guardian** could not apply `%s` in this repository:\n\n", repoconfig.Path)
+	sb.WriteString("```\n")
+	sb.WriteString(cfgErr.Error())
+	sb.WriteString("\n```\n\n")
+	sb.WriteString("Compliance checks for this repository are paused until the file is fixed.\n")
+	sb.WriteString("Close this issue once the fix is merged; a new one is opened if the problem persists.\n\n")
+	sb.WriteString("### Example\n\n")
+	sb.WriteString("```yaml\n")
+	sb.WriteString("exclude:\n")
+	sb.WriteString("  - renovate\n")
+	sb.WriteString("overrides:\n")
+	sb.WriteString("  codeowners:\n")
+	sb.WriteString("    targetPath: CODEOWNERS\n")
+	sb.WriteString("disableCustomProperties: false\n")
+	sb.WriteString("```\n\n")
+	sb.WriteString("---\n")
+	sb.WriteString("*Automated by [repo-guardian](https://github.com/apps/repo-guardian). ")
+	sb.WriteString("Questions? Reach out in #platform-engineering.*\n")
+
+	return sb.String()
+}
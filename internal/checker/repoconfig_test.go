@@ -0,0 +1,150 @@
+package checker
+
+import (
+	"context"
+	"testing"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/repoconfig"
+)
+
+func repoConfigClient(content string) *mockClient {
+	client := newMockClient()
+	client.repo = &ghclient.Repository{
+		Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main",
+	}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.fileContents["org/repo/"+repoconfig.Path] = content
+
+	return client
+}
+
+func TestCheckRepo_RepoConfigExclude(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := repoConfigClient("exclude:\n  - codeowners\n")
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if len(client.createdFiles) != 1 || client.createdFiles[0] != ".github/dependabot.yml" {
+		t.Errorf("expected only dependabot file, got %v", client.createdFiles)
+	}
+}
+
+func TestCheckRepo_RepoConfigExcludeAll(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := repoConfigClient("exclude: [CODEOWNERS, Dependabot]\n")
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if client.createdPR != nil {
+		t.Error("should not create PR when all rules are excluded")
+	}
+}
+
+func TestCheckRepo_RepoConfigOverrideTargetPath(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := repoConfigClient("overrides:\n  codeowners:\n    targetPath: CODEOWNERS\n")
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	found := false
+
+	for _, f := range client.createdFiles {
+		if f == "CODEOWNERS" {
+			found = true
+		}
+
+		if f == ".github/CODEOWNERS" {
+			t.Error("default target path should not be used when overridden")
+		}
+	}
+
+	if !found {
+		t.Errorf("expected CODEOWNERS at overridden path, got %v", client.createdFiles)
+	}
+}
+
+func TestCheckRepo_RepoConfigInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "malformed", content: "exclude: [unclosed"},
+		{name: "unknown rule", content: "exclude:\n  - not-a-rule\n"},
+		{name: "unknown template", content: "overrides:\n  codeowners:\n    template: missing\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := testEngine(false)
+			client := repoConfigClient(tt.content)
+
+			if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+				t.Fatalf("CheckRepo: %v", err)
+			}
+
+			if client.createdPR != nil {
+				t.Error("should not create PR when repo config is invalid")
+			}
+
+			if len(client.createdIssues) != 1 {
+				t.Fatalf("expected 1 issue created, got %d", len(client.createdIssues))
+			}
+
+			if client.createdIssues[0].Title != InvalidConfigIssueTitle {
+				t.Errorf("unexpected issue title %q", client.createdIssues[0].Title)
+			}
+		})
+	}
+}
+
+func TestCheckRepo_RepoConfigInvalid_ExistingIssue(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngine(false)
+	client := repoConfigClient("exclude: [unclosed")
+	client.openIssues = []*ghclient.Issue{
+		{Number: 3, Title: InvalidConfigIssueTitle, State: "open"},
+	}
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if len(client.createdIssues) != 0 {
+		t.Errorf("should not open a duplicate issue, created %d", len(client.createdIssues))
+	}
+}
+
+func TestCheckRepo_RepoConfigDisableCustomProperties(t *testing.T) {
+	t.Parallel()
+
+	engine := testEngineWithMode(false, "api")
+	client := repoConfigClient("disableCustomProperties: true\n")
+	client.contents["org/repo/CODEOWNERS"] = true
+	client.contents["org/repo/.github/dependabot.yml"] = true
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if len(client.setProperties) != 0 {
+		t.Errorf("should not set custom properties, set %d", len(client.setProperties))
+	}
+}
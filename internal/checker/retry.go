@@ -0,0 +1,230 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+)
+
+// ErrDeadJobNotFound is returned by Requeue when no dead-letter job has the
+// given ID.
+var ErrDeadJobNotFound = errors.New("dead-letter job not found")
+
+// RetryPolicy controls how jobs that fail with a retryable error are
+// re-enqueued.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first,
+	// before a job is moved to the dead-letter list. 1 disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the first retry. It doubles with
+	// each further attempt.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used unless SetRetryPolicy is called.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    30 * time.Minute,
+	}
+}
+
+// backoff returns the delay before retrying a job that has failed attempt
+// times. Half the delay is random so that jobs which failed together, e.g.
+// during a GitHub outage, do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+
+	d = min(d, p.MaxDelay)
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + rand.N(d/2+1) //nolint:gosec // Jitter does not need a cryptographic source.
+}
+
+// done records the outcome of a processed job and releases its repository.
+// Successful and permanently failed jobs are removed from the store;
+// deferred jobs wait for their PR window and rate-limited jobs for the rate
+// limit to allow them, neither counting an attempt; other retryable failures
+// are re-queued with backoff until MaxAttempts, then moved to the dead-letter
+// list.
+func (q *Queue) done(log *slog.Logger, qj *queuedJob, jobErr error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	defer func() {
+		delete(q.inFlight, qj.job.key())
+		q.release(qj.job.InstallationID)
+		q.cond.Broadcast()
+	}()
+
+	var (
+		err      error
+		deferred *DeferredError
+	)
+
+	limitedUntil, rateLimited := ghclient.RateLimitedUntil(jobErr)
+
+	switch {
+	case jobErr == nil:
+		err = q.store.Remove(qj.id)
+	case q.aborted:
+		err = q.abandon(log, qj, jobErr)
+	case q.suspended[qj.job.InstallationID]:
+		log.Warn("job failed for a suspended installation, dropping", "owner", qj.job.Owner, "repo", qj.job.Repo, "error", jobErr)
+		err = q.store.Remove(qj.id)
+	case errors.As(jobErr, &deferred):
+		err = q.deferJob(log, qj, deferred.Until)
+	case rateLimited:
+		err = q.postpone(log, qj, limitedUntil)
+	case !ghclient.IsRetryable(jobErr):
+		log.Warn("job failed with a permanent error, dropping", "owner", qj.job.Owner, "repo", qj.job.Repo, "error", jobErr)
+		err = q.store.Remove(qj.id)
+	case qj.job.Attempt+1 >= q.retry.MaxAttempts:
+		err = q.bury(log, qj, jobErr)
+	default:
+		err = q.scheduleRetry(log, qj)
+	}
+
+	if err != nil {
+		log.Error("failed to update job store", "job_id", qj.id, "error", err)
+		metrics.ErrorsTotal.WithLabelValues("queue_ack").Inc()
+	}
+}
+
+// scheduleRetry stores the job again with its attempt count and backoff and
+// queues it. The caller must hold q.mu.
+func (q *Queue) scheduleRetry(log *slog.Logger, qj *queuedJob) error {
+	job := qj.job
+	job.Attempt++
+	delay := q.retry.backoff(job.Attempt)
+	job.RetryAt = time.Now().Add(delay)
+
+	if err := q.replace(qj, job); err != nil {
+		return err
+	}
+
+	metrics.JobRetriesTotal.WithLabelValues(string(job.Trigger)).Inc()
+	log.Info("job will be retried",
+		"owner", job.Owner,
+		"repo", job.Repo,
+		"attempt", job.Attempt,
+		"max_attempts", q.retry.MaxAttempts,
+		"retry_in", delay,
+	)
+
+	return nil
+}
+
+// postpone stores the job again to run once the rate limit allows, without
+// counting an attempt, and queues it. The caller must hold q.mu.
+func (q *Queue) postpone(log *slog.Logger, qj *queuedJob, until time.Time) error {
+	job := qj.job
+	job.RetryAt = until
+
+	if err := q.replace(qj, job); err != nil {
+		return err
+	}
+
+	metrics.JobsRateLimitedTotal.WithLabelValues(string(job.Trigger)).Inc()
+	log.Info("job postponed until the rate limit allows it",
+		"owner", job.Owner,
+		"repo", job.Repo,
+		"until", until,
+	)
+
+	return nil
+}
+
+// replace stores job in place of the processed qj and queues it. The caller
+// must hold q.mu.
+func (q *Queue) replace(qj *queuedJob, job RepoJob) error {
+	// Store the new record before removing the old one; if the process dies
+	// in between, the duplicate is coalesced on restart.
+	id, err := q.store.Add(job)
+	if err != nil {
+		return err
+	}
+
+	if err := q.store.Remove(qj.id); err != nil {
+		return err
+	}
+
+	return q.add(id, job)
+}
+
+// bury moves a job that exhausted its attempts to the dead-letter list.
+// The caller must hold q.mu.
+func (q *Queue) bury(log *slog.Logger, qj *queuedJob, jobErr error) error {
+	job := qj.job
+	job.Attempt++
+	job.RetryAt = time.Time{}
+
+	dead := DeadJob{ID: qj.id, Job: job, Error: jobErr.Error(), FailedAt: time.Now()}
+	if err := q.store.Bury(dead); err != nil {
+		return err
+	}
+
+	metrics.JobsDeadLetteredTotal.Inc()
+	metrics.DeadLetterJobs.Inc()
+	log.Error("job exhausted retries, moved to dead letters",
+		"owner", job.Owner,
+		"repo", job.Repo,
+		"job_id", qj.id,
+		"attempts", job.Attempt,
+		"error", jobErr,
+	)
+
+	return nil
+}
+
+// DeadLetters returns the jobs that exhausted their retries.
+func (q *Queue) DeadLetters() ([]DeadJob, error) {
+	return q.store.DeadLetters()
+}
+
+// Requeue moves a dead-letter job back onto the queue as a manual job with a
+// fresh attempt count.
+func (q *Queue) Requeue(id uint64) (RepoJob, error) {
+	dead, ok, err := q.store.Unbury(id)
+	if err != nil {
+		return RepoJob{}, err
+	}
+
+	if !ok {
+		return RepoJob{}, ErrDeadJobNotFound
+	}
+
+	job := dead.Job
+	job.Trigger = TriggerManual
+	job.Attempt = 0
+	job.RetryAt = time.Time{}
+
+	if err := q.Enqueue(job); err != nil {
+		// Put it back so the job is not lost.
+		if buryErr := q.store.Bury(dead); buryErr != nil {
+			return RepoJob{}, errors.Join(err, buryErr)
+		}
+
+		return RepoJob{}, fmt.Errorf("requeueing dead-letter job %d: %w", id, err)
+	}
+
+	metrics.DeadLetterJobs.Dec()
+	q.logger.Info("requeued dead-letter job", "job_id", id, "owner", job.Owner, "repo", job.Repo)
+
+	return job, nil
+}
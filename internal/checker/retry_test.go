@@ -0,0 +1,212 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"testing"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{attempt: 1, min: 500 * time.Millisecond, max: time.Second},
+		{attempt: 2, min: time.Second, max: 2 * time.Second},
+		{attempt: 3, min: 2 * time.Second, max: 4 * time.Second},
+		{attempt: 8, min: 5 * time.Second, max: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			if d := p.backoff(tt.attempt); d < tt.min || d > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+// runUntil starts q and waits until cond holds, then stops the queue.
+func runUntil(t *testing.T, q *Queue, client *mockClient, cond func() bool) {
+	t.Helper()
+
+	q.Start(context.Background(), 1, testEngine(true), client)
+	defer q.Stop()
+
+	deadline := time.After(5 * time.Second)
+
+	for !cond() {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for queue")
+		default:
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+func TestQueue_RetriesThenDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	q.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+
+	client := newMockClient()
+	client.getRepoErr = &url.Error{Op: "Get", URL: "https://api.github.com/repos/org/repo", Err: errors.New("connection reset")}
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerWebhook}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	runUntil(t, q, client, func() bool {
+		dead, _ := store.DeadLetters()
+		return len(dead) == 1
+	})
+
+	dead, err := store.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters: %v", err)
+	}
+
+	if dead[0].Job.Attempt != 3 || dead[0].Error == "" {
+		t.Errorf("dead-letter job = %+v, want 3 attempts and an error", dead[0])
+	}
+
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("expected no pending jobs, got %+v", pending)
+	}
+}
+
+func TestQueue_PermanentErrorIsDropped(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	client := newMockClient()
+	client.getRepoErr = errors.New("repository is gone")
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerWebhook}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	runUntil(t, q, client, func() bool {
+		pending, _ := store.Pending()
+		return len(pending) == 0 && q.Len() == 0
+	})
+
+	if dead, _ := store.DeadLetters(); len(dead) != 0 {
+		t.Errorf("permanent failures should not be dead-lettered, got %+v", dead)
+	}
+}
+
+func TestQueue_RateLimitedJobIsPostponed(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(10, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	q.SetRetryPolicy(RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	client := newMockClient()
+	client.getRepoErr = &ghclient.RateLimitWaitError{Until: until}
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerScheduler}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	runUntil(t, q, client, func() bool {
+		pending, _ := store.Pending()
+		return len(pending) == 1 && !pending[0].Job.RetryAt.IsZero()
+	})
+
+	pending, _ := store.Pending()
+	if job := pending[0].Job; !job.RetryAt.Equal(until) || job.Attempt != 0 {
+		t.Errorf("postponed job = %+v, want RetryAt %v and no attempt counted", job, until)
+	}
+
+	if dead, _ := store.DeadLetters(); len(dead) != 0 {
+		t.Errorf("rate-limited jobs should not be dead-lettered, got %+v", dead)
+	}
+}
+
+func TestQueue_RetrySucceeds(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(10, slog.Default())
+	q.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	// Simulate a failed first attempt that was already scheduled for retry.
+	if err := q.Enqueue(RepoJob{
+		Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerWebhook,
+		Attempt: 1, RetryAt: time.Now().Add(20 * time.Millisecond),
+	}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+
+	start := time.Now()
+
+	runUntil(t, q, client, func() bool { return client.processedJobs.Load() == 1 })
+
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("job ran after %v, before its retry backoff elapsed", elapsed)
+	}
+}
+
+func TestMergeJobs(t *testing.T) {
+	t.Parallel()
+
+	retry := RepoJob{Owner: "org", Repo: "repo", Trigger: TriggerWebhook, Attempt: 2, RetryAt: time.Now().Add(time.Hour)}
+	fresh := RepoJob{Owner: "org", Repo: "repo", Trigger: TriggerScheduler}
+
+	got := mergeJobs(retry, fresh)
+	if got.Trigger != TriggerWebhook || got.Attempt != 0 || !got.RetryAt.IsZero() {
+		t.Errorf("mergeJobs(retry, fresh) = %+v, want webhook trigger with retry cleared", got)
+	}
+
+	if got := mergeJobs(fresh, retry); got.Attempt != 0 || got.Trigger != TriggerWebhook {
+		t.Errorf("mergeJobs(fresh, retry) = %+v, want fresh job with webhook trigger", got)
+	}
+
+	props := RepoJob{Owner: "org", Repo: "repo", Trigger: TriggerWebhook, Kind: KindProperties}
+
+	if got := mergeJobs(props, fresh); got.Kind != KindFull || got.Trigger != TriggerWebhook {
+		t.Errorf("mergeJobs(props, fresh) = %+v, want full webhook job", got)
+	}
+
+	if got := mergeJobs(fresh, props); got.Kind != KindFull {
+		t.Errorf("mergeJobs(fresh, props) = %+v, want full job", got)
+	}
+
+	if got := mergeJobs(props, props); got.Kind != KindProperties {
+		t.Errorf("mergeJobs(props, props) = %+v, want properties job", got)
+	}
+}
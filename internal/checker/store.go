@@ -0,0 +1,156 @@
+package checker
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// JobStore persists the jobs held by a Queue. A job is added when it is
+// enqueued and removed once a worker has finished with it, so jobs still in
+// the store when the process stops are resumed by the next Queue opened on
+// the same store.
+type JobStore interface {
+	// Add persists job and returns its ID. IDs increase monotonically.
+	Add(job RepoJob) (uint64, error)
+
+	// Remove deletes the job with the given ID. Removing an unknown ID is
+	// not an error.
+	Remove(id uint64) error
+
+	// Pending returns every stored job in ID order.
+	Pending() ([]StoredJob, error)
+
+	// Bury removes the pending job dead.ID and adds dead to the dead-letter
+	// list.
+	Bury(dead DeadJob) error
+
+	// DeadLetters returns the dead-letter list in ID order.
+	DeadLetters() ([]DeadJob, error)
+
+	// Unbury removes a job from the dead-letter list and returns it. The
+	// bool is false if no dead-letter job has the given ID.
+	Unbury(id uint64) (DeadJob, bool, error)
+
+	// Persistent reports whether stored jobs outlive the process.
+	Persistent() bool
+
+	// Close releases the store's resources.
+	Close() error
+}
+
+// StoredJob is a job persisted in a JobStore.
+type StoredJob struct {
+	ID  uint64
+	Job RepoJob
+}
+
+// DeadJob is a job that failed on every attempt and was moved to the
+// dead-letter list.
+type DeadJob struct {
+	ID       uint64    `json:"id"`
+	Job      RepoJob   `json:"job"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// MemoryStore is a JobStore that keeps jobs in memory only. It is the default
+// store; pending jobs are lost when the process exits.
+type MemoryStore struct {
+	mu     sync.Mutex
+	nextID uint64
+	jobs   map[uint64]RepoJob
+	dead   map[uint64]DeadJob
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs: make(map[uint64]RepoJob),
+		dead: make(map[uint64]DeadJob),
+	}
+}
+
+// Add stores job in memory.
+func (s *MemoryStore) Add(job RepoJob) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	s.jobs[s.nextID] = job
+
+	return s.nextID, nil
+}
+
+// Remove deletes the job with the given ID.
+func (s *MemoryStore) Remove(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+
+	return nil
+}
+
+// Pending returns the stored jobs in ID order.
+func (s *MemoryStore) Pending() ([]StoredJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := make([]StoredJob, 0, len(s.jobs))
+	for id, job := range s.jobs {
+		pending = append(pending, StoredJob{ID: id, Job: job})
+	}
+
+	slices.SortFunc(pending, func(a, b StoredJob) int { return cmp.Compare(a.ID, b.ID) })
+
+	return pending, nil
+}
+
+// Bury moves a pending job to the dead-letter list.
+func (s *MemoryStore) Bury(dead DeadJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, dead.ID)
+	s.dead[dead.ID] = dead
+
+	return nil
+}
+
+// DeadLetters returns the dead-letter list in ID order.
+func (s *MemoryStore) DeadLetters() ([]DeadJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dead := make([]DeadJob, 0, len(s.dead))
+	for _, d := range s.dead {
+		dead = append(dead, d)
+	}
+
+	slices.SortFunc(dead, func(a, b DeadJob) int { return cmp.Compare(a.ID, b.ID) })
+
+	return dead, nil
+}
+
+// Unbury removes and returns a dead-letter job.
+func (s *MemoryStore) Unbury(id uint64) (DeadJob, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dead, ok := s.dead[id]
+	delete(s.dead, id)
+
+	return dead, ok, nil
+}
+
+// Persistent returns false: jobs are lost when the process exits.
+func (*MemoryStore) Persistent() bool {
+	return false
+}
+
+// Close is a no-op.
+func (*MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package checker
+
+import "testing"
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	ids := make([]uint64, 0, 3)
+
+	for _, repo := range []string{"a", "b", "c"} {
+		id, err := store.Add(RepoJob{Owner: "org", Repo: repo})
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := store.Remove(ids[1]); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	pending, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	if len(pending) != 2 || pending[0].Job.Repo != "a" || pending[1].Job.Repo != "c" {
+		t.Errorf("Pending = %+v, want jobs a and c in order", pending)
+	}
+}
@@ -0,0 +1,125 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/schedule"
+)
+
+// DeferredError is returned when a check needs to open a pull request
+// outside the allowed PR windows. The queue runs the job again once the
+// next window opens, without counting a failed attempt.
+type DeferredError struct {
+	// Until is when the next PR window opens.
+	Until time.Time
+}
+
+func (e *DeferredError) Error() string {
+	return "pull request creation deferred until " + e.Until.UTC().Format(time.RFC3339)
+}
+
+// SetPRWindows restricts when new pull requests are opened. Outside the
+// windows, checks that would open one fail with a DeferredError. Updates to
+// pull requests that are already open are not restricted. An empty set, the
+// default, allows any time.
+func (e *Engine) SetPRWindows(windows schedule.Windows) {
+	e.prWindows = windows
+}
+
+// deferPR returns a DeferredError if no new pull request may be opened now.
+func (e *Engine) deferPR() error {
+	now := e.now()
+	if e.prWindows.Open(now) {
+		return nil
+	}
+
+	return &DeferredError{Until: e.prWindows.NextOpen(now)}
+}
+
+// isDeferred reports whether err is a DeferredError.
+func isDeferred(err error) bool {
+	var deferred *DeferredError
+	return errors.As(err, &deferred)
+}
+
+// deferJob stores the job again to run once its PR window opens, without
+// counting an attempt, and sets it aside. The caller must hold q.mu.
+func (q *Queue) deferJob(log *slog.Logger, qj *queuedJob, until time.Time) error {
+	job := qj.job
+	job.DeferredUntil = until
+
+	if err := q.replace(qj, job); err != nil {
+		return err
+	}
+
+	metrics.JobsDeferredTotal.WithLabelValues(string(job.Trigger)).Inc()
+	log.Info("job deferred until the PR window opens",
+		"owner", job.Owner,
+		"repo", job.Repo,
+		"until", until,
+	)
+
+	return nil
+}
+
+// delay sets a job aside until its PR window opens. The caller must hold
+// q.mu.
+func (q *Queue) delay(qj *queuedJob) {
+	q.delayed[qj.job.key()] = qj
+	metrics.DeferredJobs.Set(float64(len(q.delayed)))
+}
+
+// undelay moves the job waiting for a PR window for key, if any, to the
+// pending jobs. It still waits for its window there unless it is merged with
+// a fresh job. The caller must hold q.mu.
+func (q *Queue) undelay(key repoKey) {
+	qj, ok := q.delayed[key]
+	if !ok {
+		return
+	}
+
+	delete(q.delayed, key)
+	metrics.DeferredJobs.Set(float64(len(q.delayed)))
+
+	q.pending = append(q.pending, qj)
+	q.byKey[key] = qj
+	metrics.QueueLaneDepth.WithLabelValues(laneNames[qj.job.lane()]).Inc()
+}
+
+// promote moves the jobs whose PR window has opened to the pending jobs and
+// returns when the next of the others is due, or the zero Time. The caller
+// must hold q.mu.
+func (q *Queue) promote(now time.Time) time.Time {
+	var next time.Time
+
+	for key, qj := range q.delayed {
+		until := qj.job.DeferredUntil
+		if !until.After(now) {
+			q.undelay(key)
+			continue
+		}
+
+		if next.IsZero() || until.Before(next) {
+			next = until
+		}
+	}
+
+	return next
+}
+
+// removeDelayed removes a job waiting for a PR window from the queue and the
+// store. The caller must hold q.mu.
+func (q *Queue) removeDelayed(qj *queuedJob) error {
+	delete(q.delayed, qj.job.key())
+	metrics.DeferredJobs.Set(float64(len(q.delayed)))
+
+	if err := q.store.Remove(qj.id); err != nil {
+		return fmt.Errorf("removing discarded job: %w", err)
+	}
+
+	return nil
+}
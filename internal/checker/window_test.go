@@ -0,0 +1,177 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/schedule"
+)
+
+// windowNow is a Saturday, outside the test PR windows. It is far enough
+// ahead that a deferred job is not run again during the test.
+var windowNow = time.Date(2099, time.March, 7, 12, 0, 0, 0, time.UTC)
+
+// windowOpens is when the test PR windows next open.
+var windowOpens = time.Date(2099, time.March, 9, 9, 0, 0, 0, time.UTC)
+
+func windowEngine(t *testing.T, customPropertiesMode string) *Engine {
+	t.Helper()
+
+	windows, err := schedule.ParseWindows("Mon-Fri 09:00-17:00")
+	if err != nil {
+		t.Fatalf("ParseWindows: %v", err)
+	}
+
+	e := testEngineWithMode(false, customPropertiesMode)
+	e.SetPRWindows(windows)
+	e.now = func() time.Time { return windowNow }
+
+	return e
+}
+
+func TestCheckRepo_OutsidePRWindow(t *testing.T) {
+	t.Parallel()
+
+	engine := windowEngine(t, "")
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+	client.branchSHAs["org/repo/main"] = "abc123"
+
+	err := engine.CheckRepo(context.Background(), client, "org", "repo")
+
+	var deferred *DeferredError
+	if !errors.As(err, &deferred) {
+		t.Fatalf("CheckRepo error = %v, want a DeferredError", err)
+	}
+
+	if !deferred.Until.Equal(windowOpens) {
+		t.Errorf("Until = %v, want %v", deferred.Until, windowOpens)
+	}
+
+	if len(client.createdBranches) != 0 || client.createdPR != nil {
+		t.Errorf("nothing should be written outside the window, got branches %v and PR %+v",
+			client.createdBranches, client.createdPR)
+	}
+}
+
+func TestCheckRepo_OutsidePRWindowChecksProperties(t *testing.T) {
+	t.Parallel()
+
+	engine := windowEngine(t, "api")
+	client := basePropertiesClient()
+	client.fileContents["org/my-service/catalog-info.yaml"] = validCatalogInfo
+
+	err := engine.CheckRepo(context.Background(), client, "org", "my-service")
+
+	var deferred *DeferredError
+	if !errors.As(err, &deferred) {
+		t.Fatalf("CheckRepo error = %v, want a DeferredError", err)
+	}
+
+	if len(client.setProperties) == 0 {
+		t.Error("expected properties to be set while the PR is deferred")
+	}
+}
+
+func TestCheckRepo_OutsidePRWindowUpdatesOpenPR(t *testing.T) {
+	t.Parallel()
+
+	engine := windowEngine(t, "")
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+	client.branchSHAs["org/repo/main"] = "abc123"
+	client.branchSHAs["org/repo/"+BranchName] = "def456"
+	client.openPRs = []*ghclient.PullRequest{
+		{Number: 5, Title: PRTitle, Head: BranchName, State: "open"},
+	}
+
+	if err := engine.CheckRepo(context.Background(), client, "org", "repo"); err != nil {
+		t.Fatalf("CheckRepo: %v", err)
+	}
+
+	if len(client.commits) != 1 {
+		t.Errorf("expected the open PR to be updated, got %d commits", len(client.commits))
+	}
+}
+
+func TestQueue_DefersJobUntilPRWindow(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	q, err := NewQueueWithStore(1, store, slog.Default())
+	if err != nil {
+		t.Fatalf("NewQueueWithStore: %v", err)
+	}
+
+	client := newMockClient()
+	client.repo = &ghclient.Repository{Owner: "org", Name: "repo", HasBranch: true, DefaultRef: "main"}
+	client.branchSHAs["org/repo/main"] = "abc123"
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, Trigger: TriggerScheduler}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	q.Start(context.Background(), 1, windowEngine(t, ""), client)
+	defer q.Stop()
+
+	deadline := time.After(5 * time.Second)
+
+	for {
+		pending, _ := store.Pending()
+		if len(pending) == 1 && !pending[0].Job.DeferredUntil.IsZero() {
+			if job := pending[0].Job; !job.DeferredUntil.Equal(windowOpens) || job.Attempt != 0 {
+				t.Errorf("deferred job = %+v, want DeferredUntil %v and no attempt counted", job, windowOpens)
+			}
+
+			// The deferred job leaves room for other work.
+			if n := q.Len(); n != 0 {
+				t.Errorf("Len = %d, want 0", n)
+			}
+
+			if err := q.Enqueue(RepoJob{Owner: "org", Repo: "other", InstallationID: 1, Trigger: TriggerWebhook}); err != nil {
+				t.Errorf("Enqueue with a deferred job waiting: %v", err)
+			}
+
+			return
+		}
+
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for the job to be deferred")
+		default:
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+func TestQueue_DeferredJobRunsWhenWindowOpens(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue(1, slog.Default())
+	until := time.Now().Add(50 * time.Millisecond)
+
+	if err := q.Enqueue(RepoJob{Owner: "org", Repo: "repo", InstallationID: 1, DeferredUntil: until}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if n := q.Len(); n != 0 {
+		t.Errorf("Len = %d, want the deferred job outside the queue", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	qj, ok := q.next(ctx)
+	if !ok {
+		t.Fatal("next returned no job")
+	}
+
+	if time.Now().Before(until) {
+		t.Errorf("job %+v ran before its PR window opened", qj.job)
+	}
+}
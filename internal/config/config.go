@@ -8,6 +8,8 @@ import (
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/donaldgifford/repo-guardian/internal/schedule"
 )
 
 // Config holds all configuration values for repo-guardian.
@@ -21,24 +23,125 @@ type Config struct {
 	// GitHubWebhookSecret is the HMAC secret for validating webhook payloads.
 	GitHubWebhookSecret string
 
+	// GitHubWebhookSecretFile is a file listing the accepted webhook secrets,
+	// one per line, current first. When set it replaces GitHubWebhookSecret
+	// and is re-read every WebhookSecretReloadInterval.
+	GitHubWebhookSecretFile string
+
+	// WebhookSecretReloadInterval is how often GitHubWebhookSecretFile is
+	// re-read.
+	WebhookSecretReloadInterval time.Duration
+
 	// ListenAddr is the HTTP listen address for the webhook server.
 	ListenAddr string
 
 	// MetricsAddr is the HTTP listen address for the Prometheus metrics server.
 	MetricsAddr string
 
+	// AdminAddr is the HTTP listen address for the unauthenticated admin
+	// endpoints. It defaults to localhost so they are reachable only from
+	// inside the pod, e.g. through kubectl port-forward.
+	AdminAddr string
+
 	// WorkerCount is the number of concurrent repo check workers.
 	WorkerCount int
 
 	// QueueSize is the work queue buffer size.
 	QueueSize int
 
+	// InstallationMaxWorkers caps how many workers may process jobs of one
+	// GitHub App installation at once. 0 means no cap.
+	InstallationMaxWorkers int
+
+	// QueueBackend selects where pending jobs are kept: "memory" (default)
+	// or "bolt" (a BoltDB file at QueuePath that survives restarts).
+	QueueBackend string
+
+	// QueuePath is the BoltDB file used by the "bolt" queue backend.
+	QueuePath string
+
+	// JobMaxAttempts is how many times a job is tried before it is moved
+	// to the dead-letter list. 1 disables retries.
+	JobMaxAttempts int
+
+	// JobRetryBaseDelay is the backoff before the first retry of a failed
+	// job; it doubles with each attempt.
+	JobRetryBaseDelay time.Duration
+
+	// JobRetryMaxDelay caps the retry backoff.
+	JobRetryMaxDelay time.Duration
+
+	// JobTimeout bounds how long one repo check may run. Zero disables the
+	// deadline.
+	JobTimeout time.Duration
+
+	// GitHubCallTimeout bounds each GitHub API call. Zero disables the
+	// timeout.
+	GitHubCallTimeout time.Duration
+
+	// JobShutdownGrace is how long in-flight jobs may keep running after a
+	// shutdown signal before they are aborted.
+	JobShutdownGrace time.Duration
+
+	// WebhookDedupeWindow is how long webhook delivery IDs are remembered
+	// to drop redeliveries. Zero disables deduplication.
+	WebhookDedupeWindow time.Duration
+
+	// WebhookIntakeSize is how many accepted webhook deliveries may wait to
+	// be handled before new ones are rejected with 503.
+	WebhookIntakeSize int
+
+	// WebhookIntakeWorkers is the number of goroutines handling accepted
+	// webhook deliveries.
+	WebhookIntakeWorkers int
+
+	// WebhookPayloadDir is the directory validated webhook payloads are kept
+	// in for debugging and replay. Empty disables the payload store.
+	WebhookPayloadDir string
+
+	// WebhookPayloadLimit is how many payloads the payload store keeps; the
+	// oldest are deleted first.
+	WebhookPayloadLimit int
+
 	// TemplateDir is the directory containing template overrides (ConfigMap mount).
 	TemplateDir string
 
+	// RulesFile is the path to a YAML or JSON rules document. When the file
+	// does not exist, the built-in default rules are used.
+	RulesFile string
+
+	// OwnerTeamsFile is the path to a YAML document mapping catalog owners
+	// to GitHub team slugs for generated CODEOWNERS files. When the file
+	// does not exist, team slugs are inferred from group names.
+	OwnerTeamsFile string
+
 	// ScheduleInterval is the reconciliation interval.
 	ScheduleInterval time.Duration
 
+	// ScheduleCron is a five-field cron expression, evaluated in UTC, for
+	// when to reconcile. When set, it replaces ScheduleInterval.
+	ScheduleCron string
+
+	// ScheduleStatePath is a file recording when the last complete
+	// reconciliation ran, so a restart does not reconcile again before the
+	// schedule is due. Empty reconciles at every start.
+	ScheduleStatePath string
+
+	// ReconcileRepoCost is the estimated number of GitHub API calls one repo
+	// check makes. The scheduler paces each installation's repos so their
+	// checks stay within its hourly rate limit. Zero disables this pacing.
+	ReconcileRepoCost int
+
+	// ReconcileSpread is the longest a reconciliation pass is stretched over
+	// to even out the load, capped at the time until the next pass. Zero
+	// paces by rate limit budget only.
+	ReconcileSpread time.Duration
+
+	// PRWindows restricts when new pull requests are opened, e.g.
+	// "Mon-Fri 09:00-17:00", in UTC. Checks that would open one outside the
+	// windows are deferred until the next window. Empty allows any time.
+	PRWindows string
+
 	// SkipForks controls whether forked repositories are skipped.
 	SkipForks bool
 
@@ -55,6 +158,11 @@ type Config struct {
 	// at which pre-emptive throttling begins (e.g., 0.10 = 10%).
 	RateLimitThreshold float64
 
+	// DeclineBackoff is how long repo-guardian waits before proposing rules
+	// again after its PR was closed without merging. Zero means never, until
+	// the repo-guardian label is removed from the closed PR.
+	DeclineBackoff time.Duration
+
 	// CustomPropertiesMode controls how custom properties are managed.
 	// Valid values: "" (disabled), "github-action" (PR with GHA workflow),
 	// "api" (direct API write).
@@ -81,7 +189,12 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		ListenAddr:           envOrDefault("LISTEN_ADDR", ":8080"),
 		MetricsAddr:          envOrDefault("METRICS_ADDR", ":9090"),
+		AdminAddr:            envOrDefault("ADMIN_ADDR", "127.0.0.1:9091"),
 		TemplateDir:          envOrDefault("TEMPLATE_DIR", "/etc/repo-guardian/templates"),
+		RulesFile:            envOrDefault("RULES_FILE", "/etc/repo-guardian/rules/rules.yaml"),
+		OwnerTeamsFile:       envOrDefault("OWNER_TEAMS_FILE", "/etc/repo-guardian/owners/teams.yaml"),
+		QueueBackend:         envOrDefault("QUEUE_BACKEND", "memory"),
+		QueuePath:            envOrDefault("QUEUE_PATH", "/var/lib/repo-guardian/queue.db"),
 		SkipForks:            skipForks,
 		SkipArchived:         skipArchived,
 		DryRun:               dryRun,
@@ -107,19 +220,32 @@ func Load() (*Config, error) {
 
 	cfg.WorkerCount = workerCount
 
-	queueSize, err := envOrDefaultInt("QUEUE_SIZE", 1000)
-	if err != nil {
+	if err := loadQueueConfig(cfg); err != nil {
 		return nil, err
 	}
 
-	cfg.QueueSize = queueSize
+	if err := loadRetryConfig(cfg); err != nil {
+		return nil, err
+	}
 
-	interval, err := envOrDefaultDuration("SCHEDULE_INTERVAL", 168*time.Hour)
+	if err := loadTimeoutConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadWebhookConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadScheduleConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	declineBackoff, err := envOrDefaultDuration("DECLINE_BACKOFF", 30*24*time.Hour)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg.ScheduleInterval = interval
+	cfg.DeclineBackoff = declineBackoff
 
 	rateLimitThreshold, err := envOrDefaultFloat("RATE_LIMIT_THRESHOLD", 0.10)
 	if err != nil {
@@ -148,8 +274,75 @@ func (c *Config) Validate() error {
 		errs = append(errs, errors.New("GITHUB_PRIVATE_KEY_PATH is required"))
 	}
 
-	if c.GitHubWebhookSecret == "" {
-		errs = append(errs, errors.New("GITHUB_WEBHOOK_SECRET is required"))
+	if c.GitHubWebhookSecret == "" && c.GitHubWebhookSecretFile == "" {
+		errs = append(errs, errors.New("GITHUB_WEBHOOK_SECRET or GITHUB_WEBHOOK_SECRET_FILE is required"))
+	}
+
+	if c.WebhookSecretReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf(
+			"WEBHOOK_SECRET_RELOAD_INTERVAL must be positive, got %s", c.WebhookSecretReloadInterval,
+		))
+	}
+
+	if c.QueueBackend != "memory" && c.QueueBackend != "bolt" {
+		errs = append(errs, fmt.Errorf("QUEUE_BACKEND must be \"memory\" or \"bolt\", got %q", c.QueueBackend))
+	}
+
+	if c.InstallationMaxWorkers < 0 {
+		errs = append(errs, fmt.Errorf("INSTALLATION_MAX_WORKERS must not be negative, got %d", c.InstallationMaxWorkers))
+	}
+
+	errs = append(errs, c.validateRetry()...)
+
+	for _, timeout := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"JOB_TIMEOUT", c.JobTimeout},
+		{"GITHUB_CALL_TIMEOUT", c.GitHubCallTimeout},
+		{"JOB_SHUTDOWN_GRACE", c.JobShutdownGrace},
+	} {
+		if timeout.d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", timeout.name, timeout.d))
+		}
+	}
+
+	if c.WebhookDedupeWindow < 0 {
+		errs = append(errs, fmt.Errorf("WEBHOOK_DEDUPE_WINDOW must not be negative, got %s", c.WebhookDedupeWindow))
+	}
+
+	if c.WebhookIntakeSize < 1 {
+		errs = append(errs, fmt.Errorf("WEBHOOK_INTAKE_SIZE must be at least 1, got %d", c.WebhookIntakeSize))
+	}
+
+	if c.WebhookIntakeWorkers < 1 {
+		errs = append(errs, fmt.Errorf("WEBHOOK_INTAKE_WORKERS must be at least 1, got %d", c.WebhookIntakeWorkers))
+	}
+
+	if c.WebhookPayloadLimit < 1 {
+		errs = append(errs, fmt.Errorf("WEBHOOK_PAYLOAD_LIMIT must be at least 1, got %d", c.WebhookPayloadLimit))
+	}
+
+	if c.ReconcileRepoCost < 0 {
+		errs = append(errs, fmt.Errorf("RECONCILE_REPO_COST must not be negative, got %d", c.ReconcileRepoCost))
+	}
+
+	if c.ReconcileSpread < 0 {
+		errs = append(errs, fmt.Errorf("RECONCILE_SPREAD must not be negative, got %s", c.ReconcileSpread))
+	}
+
+	if c.ScheduleCron != "" {
+		if _, err := schedule.ParseCron(c.ScheduleCron); err != nil {
+			errs = append(errs, fmt.Errorf("SCHEDULE_CRON: %w", err))
+		}
+	}
+
+	if _, err := schedule.ParseWindows(c.PRWindows); err != nil {
+		errs = append(errs, fmt.Errorf("PR_WINDOWS: %w", err))
+	}
+
+	if c.DeclineBackoff < 0 {
+		errs = append(errs, fmt.Errorf("DECLINE_BACKOFF must not be negative, got %s", c.DeclineBackoff))
 	}
 
 	if c.CustomPropertiesMode != "" &&
@@ -164,6 +357,157 @@ func (c *Config) Validate() error {
 	return errors.Join(errs...)
 }
 
+// validateRetry checks the job retry settings.
+func (c *Config) validateRetry() []error {
+	var errs []error
+
+	if c.JobMaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("JOB_MAX_ATTEMPTS must be at least 1, got %d", c.JobMaxAttempts))
+	}
+
+	if c.JobRetryBaseDelay <= 0 {
+		errs = append(errs, fmt.Errorf("JOB_RETRY_BASE_DELAY must be positive, got %s", c.JobRetryBaseDelay))
+	}
+
+	if c.JobRetryMaxDelay <= 0 {
+		errs = append(errs, fmt.Errorf("JOB_RETRY_MAX_DELAY must be positive, got %s", c.JobRetryMaxDelay))
+	}
+
+	if c.JobRetryBaseDelay > c.JobRetryMaxDelay {
+		errs = append(errs, fmt.Errorf("JOB_RETRY_BASE_DELAY (%s) must not exceed JOB_RETRY_MAX_DELAY (%s)",
+			c.JobRetryBaseDelay, c.JobRetryMaxDelay))
+	}
+
+	return errs
+}
+
+func loadQueueConfig(cfg *Config) error {
+	queueSize, err := envOrDefaultInt("QUEUE_SIZE", 1000)
+	if err != nil {
+		return err
+	}
+
+	installationMaxWorkers, err := envOrDefaultInt("INSTALLATION_MAX_WORKERS", 0)
+	if err != nil {
+		return err
+	}
+
+	cfg.QueueSize = queueSize
+	cfg.InstallationMaxWorkers = installationMaxWorkers
+
+	return nil
+}
+
+func loadRetryConfig(cfg *Config) error {
+	maxAttempts, err := envOrDefaultInt("JOB_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return err
+	}
+
+	baseDelay, err := envOrDefaultDuration("JOB_RETRY_BASE_DELAY", 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	maxDelay, err := envOrDefaultDuration("JOB_RETRY_MAX_DELAY", 30*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	cfg.JobMaxAttempts = maxAttempts
+	cfg.JobRetryBaseDelay = baseDelay
+	cfg.JobRetryMaxDelay = maxDelay
+
+	return nil
+}
+
+func loadTimeoutConfig(cfg *Config) error {
+	jobTimeout, err := envOrDefaultDuration("JOB_TIMEOUT", 10*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	callTimeout, err := envOrDefaultDuration("GITHUB_CALL_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	grace, err := envOrDefaultDuration("JOB_SHUTDOWN_GRACE", 10*time.Second)
+	if err != nil {
+		return err
+	}
+
+	cfg.JobTimeout = jobTimeout
+	cfg.GitHubCallTimeout = callTimeout
+	cfg.JobShutdownGrace = grace
+
+	return nil
+}
+
+func loadWebhookConfig(cfg *Config) error {
+	dedupeWindow, err := envOrDefaultDuration("WEBHOOK_DEDUPE_WINDOW", time.Hour)
+	if err != nil {
+		return err
+	}
+
+	intakeSize, err := envOrDefaultInt("WEBHOOK_INTAKE_SIZE", 100)
+	if err != nil {
+		return err
+	}
+
+	intakeWorkers, err := envOrDefaultInt("WEBHOOK_INTAKE_WORKERS", 2)
+	if err != nil {
+		return err
+	}
+
+	payloadLimit, err := envOrDefaultInt("WEBHOOK_PAYLOAD_LIMIT", 500)
+	if err != nil {
+		return err
+	}
+
+	secretReload, err := envOrDefaultDuration("WEBHOOK_SECRET_RELOAD_INTERVAL", time.Minute)
+	if err != nil {
+		return err
+	}
+
+	cfg.GitHubWebhookSecretFile = os.Getenv("GITHUB_WEBHOOK_SECRET_FILE")
+	cfg.WebhookSecretReloadInterval = secretReload
+
+	cfg.WebhookDedupeWindow = dedupeWindow
+	cfg.WebhookIntakeSize = intakeSize
+	cfg.WebhookIntakeWorkers = intakeWorkers
+	cfg.WebhookPayloadDir = os.Getenv("WEBHOOK_PAYLOAD_DIR")
+	cfg.WebhookPayloadLimit = payloadLimit
+
+	return nil
+}
+
+func loadScheduleConfig(cfg *Config) error {
+	interval, err := envOrDefaultDuration("SCHEDULE_INTERVAL", 168*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	repoCost, err := envOrDefaultInt("RECONCILE_REPO_COST", 20)
+	if err != nil {
+		return err
+	}
+
+	spread, err := envOrDefaultDuration("RECONCILE_SPREAD", 0)
+	if err != nil {
+		return err
+	}
+
+	cfg.ScheduleInterval = interval
+	cfg.ReconcileRepoCost = repoCost
+	cfg.ReconcileSpread = spread
+	cfg.ScheduleCron = os.Getenv("SCHEDULE_CRON")
+	cfg.ScheduleStatePath = os.Getenv("SCHEDULE_STATE_PATH")
+	cfg.PRWindows = os.Getenv("PR_WINDOWS")
+
+	return nil
+}
+
 func envOrDefault(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
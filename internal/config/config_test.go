@@ -25,6 +25,10 @@ func TestLoadDefaults(t *testing.T) {
 		t.Errorf("MetricsAddr = %q, want :9090", cfg.MetricsAddr)
 	}
 
+	if cfg.AdminAddr != "127.0.0.1:9091" {
+		t.Errorf("AdminAddr = %q, want 127.0.0.1:9091", cfg.AdminAddr)
+	}
+
 	if cfg.WorkerCount != 5 {
 		t.Errorf("WorkerCount = %d, want 5", cfg.WorkerCount)
 	}
@@ -33,10 +37,43 @@ func TestLoadDefaults(t *testing.T) {
 		t.Errorf("QueueSize = %d, want 1000", cfg.QueueSize)
 	}
 
+	if cfg.InstallationMaxWorkers != 0 {
+		t.Errorf("InstallationMaxWorkers = %d, want 0", cfg.InstallationMaxWorkers)
+	}
+
 	if cfg.ScheduleInterval != 168*time.Hour {
 		t.Errorf("ScheduleInterval = %v, want 168h", cfg.ScheduleInterval)
 	}
 
+	if cfg.ReconcileRepoCost != 20 || cfg.ReconcileSpread != 0 {
+		t.Errorf("reconcile pacing = %d/%v, want 20/0s", cfg.ReconcileRepoCost, cfg.ReconcileSpread)
+	}
+
+	if cfg.JobMaxAttempts != 5 || cfg.JobRetryBaseDelay != 30*time.Second || cfg.JobRetryMaxDelay != 30*time.Minute {
+		t.Errorf("retry config = %d/%v/%v, want 5/30s/30m", cfg.JobMaxAttempts, cfg.JobRetryBaseDelay, cfg.JobRetryMaxDelay)
+	}
+
+	if cfg.JobTimeout != 10*time.Minute || cfg.GitHubCallTimeout != 30*time.Second || cfg.JobShutdownGrace != 10*time.Second {
+		t.Errorf("timeouts = %v/%v/%v, want 10m/30s/10s", cfg.JobTimeout, cfg.GitHubCallTimeout, cfg.JobShutdownGrace)
+	}
+
+	if cfg.QueueBackend != "memory" {
+		t.Errorf("QueueBackend = %q, want memory", cfg.QueueBackend)
+	}
+
+	if cfg.WebhookIntakeSize != 100 || cfg.WebhookIntakeWorkers != 2 {
+		t.Errorf("webhook intake = %d/%d, want 100/2", cfg.WebhookIntakeSize, cfg.WebhookIntakeWorkers)
+	}
+
+	if cfg.WebhookDedupeWindow != time.Hour || cfg.WebhookPayloadDir != "" || cfg.WebhookPayloadLimit != 500 {
+		t.Errorf("webhook config = %v/%q/%d, want 1h/\"\"/500",
+			cfg.WebhookDedupeWindow, cfg.WebhookPayloadDir, cfg.WebhookPayloadLimit)
+	}
+
+	if cfg.DeclineBackoff != 720*time.Hour {
+		t.Errorf("DeclineBackoff = %v, want 720h", cfg.DeclineBackoff)
+	}
+
 	if !cfg.SkipForks {
 		t.Error("SkipForks should default to true")
 	}
@@ -93,10 +130,12 @@ func TestLoadOverrides(t *testing.T) {
 	t.Setenv("GITHUB_WEBHOOK_SECRET", "mysecret")
 	t.Setenv("LISTEN_ADDR", ":9999")
 	t.Setenv("METRICS_ADDR", ":7777")
+	t.Setenv("ADMIN_ADDR", "127.0.0.1:6666")
 	t.Setenv("WORKER_COUNT", "10")
 	t.Setenv("QUEUE_SIZE", "500")
 	t.Setenv("TEMPLATE_DIR", "/custom/templates")
 	t.Setenv("SCHEDULE_INTERVAL", "24h")
+	t.Setenv("DECLINE_BACKOFF", "0")
 	t.Setenv("SKIP_FORKS", "false")
 	t.Setenv("SKIP_ARCHIVED", "false")
 	t.Setenv("DRY_RUN", "true")
@@ -128,6 +167,10 @@ func TestLoadOverrides(t *testing.T) {
 		t.Errorf("MetricsAddr = %q, want :7777", cfg.MetricsAddr)
 	}
 
+	if cfg.AdminAddr != "127.0.0.1:6666" {
+		t.Errorf("AdminAddr = %q, want 127.0.0.1:6666", cfg.AdminAddr)
+	}
+
 	if cfg.WorkerCount != 10 {
 		t.Errorf("WorkerCount = %d, want 10", cfg.WorkerCount)
 	}
@@ -144,6 +187,10 @@ func TestLoadOverrides(t *testing.T) {
 		t.Errorf("ScheduleInterval = %v, want 24h", cfg.ScheduleInterval)
 	}
 
+	if cfg.DeclineBackoff != 0 {
+		t.Errorf("DeclineBackoff = %v, want 0", cfg.DeclineBackoff)
+	}
+
 	if cfg.SkipForks {
 		t.Error("SkipForks should be false")
 	}
@@ -228,6 +275,205 @@ func TestLoadInvalidScheduleInterval(t *testing.T) {
 	}
 }
 
+func TestLoadSchedule(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("SCHEDULE_CRON", "0 2 * * 1-5")
+	t.Setenv("SCHEDULE_STATE_PATH", "/var/lib/repo-guardian/scheduler.json")
+	t.Setenv("PR_WINDOWS", "Mon-Fri 09:00-17:00")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.ScheduleCron != "0 2 * * 1-5" {
+		t.Errorf("ScheduleCron = %q, want %q", cfg.ScheduleCron, "0 2 * * 1-5")
+	}
+
+	if cfg.ScheduleStatePath != "/var/lib/repo-guardian/scheduler.json" {
+		t.Errorf("ScheduleStatePath = %q", cfg.ScheduleStatePath)
+	}
+
+	if cfg.PRWindows != "Mon-Fri 09:00-17:00" {
+		t.Errorf("PRWindows = %q, want %q", cfg.PRWindows, "Mon-Fri 09:00-17:00")
+	}
+}
+
+func TestLoadNegativeReconcileSpread(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("RECONCILE_SPREAD", "-1h")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "RECONCILE_SPREAD") {
+		t.Fatalf("expected RECONCILE_SPREAD error, got %v", err)
+	}
+}
+
+func TestLoadInvalidScheduleCron(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("SCHEDULE_CRON", "0 2 * *")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "SCHEDULE_CRON") {
+		t.Fatalf("expected SCHEDULE_CRON error, got %v", err)
+	}
+}
+
+func TestLoadInvalidPRWindows(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("PR_WINDOWS", "Mon-Fri 9-17")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "PR_WINDOWS") {
+		t.Fatalf("expected PR_WINDOWS error, got %v", err)
+	}
+}
+
+func TestLoadInvalidQueueBackend(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("QUEUE_BACKEND", "redis")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "QUEUE_BACKEND") {
+		t.Fatalf("expected QUEUE_BACKEND error, got %v", err)
+	}
+}
+
+func TestLoadInvalidJobMaxAttempts(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("JOB_MAX_ATTEMPTS", "0")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "JOB_MAX_ATTEMPTS") {
+		t.Fatalf("expected JOB_MAX_ATTEMPTS error, got %v", err)
+	}
+}
+
+func TestLoadInvalidJobRetryDelays(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseDelay string
+		maxDelay  string
+		wantErr   string
+	}{
+		{name: "zero base delay", baseDelay: "0s", wantErr: "JOB_RETRY_BASE_DELAY must be positive"},
+		{name: "negative base delay", baseDelay: "-30s", wantErr: "JOB_RETRY_BASE_DELAY must be positive"},
+		{name: "zero max delay", maxDelay: "0s", wantErr: "JOB_RETRY_MAX_DELAY must be positive"},
+		{name: "negative max delay", maxDelay: "-1m", wantErr: "JOB_RETRY_MAX_DELAY must be positive"},
+		{name: "base above max", baseDelay: "1h", maxDelay: "30m", wantErr: "must not exceed JOB_RETRY_MAX_DELAY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_APP_ID", "123")
+			t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+			t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+
+			if tt.baseDelay != "" {
+				t.Setenv("JOB_RETRY_BASE_DELAY", tt.baseDelay)
+			}
+
+			if tt.maxDelay != "" {
+				t.Setenv("JOB_RETRY_MAX_DELAY", tt.maxDelay)
+			}
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadNegativeInstallationMaxWorkers(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("INSTALLATION_MAX_WORKERS", "-1")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "INSTALLATION_MAX_WORKERS") {
+		t.Fatalf("expected INSTALLATION_MAX_WORKERS error, got %v", err)
+	}
+}
+
+func TestLoadNegativeJobTimeout(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("JOB_TIMEOUT", "-1m")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "JOB_TIMEOUT") {
+		t.Fatalf("expected JOB_TIMEOUT error, got %v", err)
+	}
+}
+
+func TestLoadInvalidWebhookPayloadLimit(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("WEBHOOK_PAYLOAD_LIMIT", "0")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "WEBHOOK_PAYLOAD_LIMIT") {
+		t.Fatalf("expected WEBHOOK_PAYLOAD_LIMIT error, got %v", err)
+	}
+}
+
+func TestLoadInvalidWebhookIntakeWorkers(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("WEBHOOK_INTAKE_WORKERS", "0")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "WEBHOOK_INTAKE_WORKERS") {
+		t.Fatalf("expected WEBHOOK_INTAKE_WORKERS error, got %v", err)
+	}
+}
+
+func TestLoadWebhookSecretFile(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "")
+	t.Setenv("GITHUB_WEBHOOK_SECRET_FILE", "/etc/repo-guardian/webhook/secrets")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.GitHubWebhookSecretFile != "/etc/repo-guardian/webhook/secrets" || cfg.WebhookSecretReloadInterval != time.Minute {
+		t.Errorf("secret file config = %q/%v, want /etc/repo-guardian/webhook/secrets/1m",
+			cfg.GitHubWebhookSecretFile, cfg.WebhookSecretReloadInterval)
+	}
+}
+
+func TestLoadNegativeDeclineBackoff(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_PRIVATE_KEY_PATH", "/key.pem")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "secret")
+	t.Setenv("DECLINE_BACKOFF", "-1h")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "DECLINE_BACKOFF") {
+		t.Fatalf("expected DECLINE_BACKOFF error, got %v", err)
+	}
+}
+
 func TestCustomPropertiesMode_ValidValues(t *testing.T) {
 	for _, mode := range []string{"", "github-action", "api"} {
 		t.Setenv("GITHUB_APP_ID", "123")
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	gh "github.com/google/go-github/v68/github"
@@ -25,9 +27,19 @@ type GitHubClient struct {
 	scopedGHClient *gh.Client
 }
 
-// NewClient creates a new GitHubClient configured as a GitHub App.
-func NewClient(appID int64, privateKeyPath string, logger *slog.Logger, rateLimitThreshold float64) (*GitHubClient, error) {
-	transport, err := ghinstallation.NewAppsTransportKeyFromFile(http.DefaultTransport, appID, privateKeyPath)
+// NewClient creates a new GitHubClient configured as a GitHub App. Each API
+// call, including installation token requests, is bounded by callTimeout;
+// zero disables the timeout.
+func NewClient(
+	appID int64,
+	privateKeyPath string,
+	logger *slog.Logger,
+	rateLimitThreshold float64,
+	callTimeout time.Duration,
+) (*GitHubClient, error) {
+	base := newTimeoutTransport(http.DefaultTransport, callTimeout)
+
+	transport, err := ghinstallation.NewAppsTransportKeyFromFile(base, appID, privateKeyPath)
 	if err != nil {
 		return nil, fmt.Errorf("creating GitHub App transport: %w", err)
 	}
@@ -92,6 +104,7 @@ func (c *GitHubClient) ListOpenPullRequests(ctx context.Context, owner, repo str
 				Title:  pr.GetTitle(),
 				Head:   pr.GetHead().GetRef(),
 				State:  pr.GetState(),
+				Body:   pr.GetBody(),
 			})
 		}
 
@@ -105,6 +118,48 @@ func (c *GitHubClient) ListOpenPullRequests(ctx context.Context, owner, repo str
 	return allPRs, nil
 }
 
+// ListClosedPullRequests returns up to 100 of the most recently updated
+// closed pull requests whose head is branch. Only the first page is read;
+// older PRs are not relevant to repo-guardian's decisions.
+func (c *GitHubClient) ListClosedPullRequests(ctx context.Context, owner, repo, branch string) ([]*PullRequest, error) {
+	opts := &gh.PullRequestListOptions{
+		State:     "closed",
+		Head:      owner + ":" + branch,
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: gh.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	prs, _, err := c.ghClient().PullRequests.List(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("listing closed pull requests for %s/%s: %w", owner, repo, err)
+	}
+
+	result := make([]*PullRequest, 0, len(prs))
+
+	for _, pr := range prs {
+		labels := make([]string, 0, len(pr.Labels))
+		for _, l := range pr.Labels {
+			labels = append(labels, l.GetName())
+		}
+
+		result = append(result, &PullRequest{
+			Number:   pr.GetNumber(),
+			Title:    pr.GetTitle(),
+			Head:     pr.GetHead().GetRef(),
+			State:    pr.GetState(),
+			Body:     pr.GetBody(),
+			Merged:   pr.MergedAt != nil,
+			ClosedAt: pr.GetClosedAt().Time,
+			Labels:   labels,
+		})
+	}
+
+	return result, nil
+}
+
 // GetRepository returns repository metadata.
 func (c *GitHubClient) GetRepository(ctx context.Context, owner, repo string) (*Repository, error) {
 	r, _, err := c.ghClient().Repositories.Get(ctx, owner, repo)
@@ -153,33 +208,94 @@ func (c *GitHubClient) CreateBranch(ctx context.Context, owner, repo, branch, ba
 	return nil
 }
 
-// DeleteBranch deletes a branch from the repository.
+// DeleteBranch deletes a branch from the repository. Deleting a branch that
+// no longer exists is not an error.
 func (c *GitHubClient) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
-	_, err := c.ghClient().Git.DeleteRef(ctx, owner, repo, "refs/heads/"+branch)
+	resp, err := c.ghClient().Git.DeleteRef(ctx, owner, repo, "refs/heads/"+branch)
 	if err != nil {
+		// GitHub answers 422 for a ref that does not exist, e.g. a merged
+		// branch the repository already deleted automatically.
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity) {
+			return nil
+		}
+
 		return fmt.Errorf("deleting branch %s for %s/%s: %w", branch, owner, repo, err)
 	}
 
 	return nil
 }
 
-// CreateOrUpdateFile creates or updates a file on the given branch.
-func (c *GitHubClient) CreateOrUpdateFile(
+// CommitFiles writes all files to the branch in a single commit using the
+// Git Data API (blobs, tree, commit, ref update). The ref is updated without
+// force, so a concurrent push to the branch fails the call instead of being
+// overwritten. If the resulting tree equals the current one, no commit is
+// created and the existing head SHA is returned.
+func (c *GitHubClient) CommitFiles(
 	ctx context.Context,
-	owner, repo, branch, path, content, message string,
-) error {
-	opts := &gh.RepositoryContentFileOptions{
+	owner, repo, branch, message string,
+	files []FileChange,
+) (string, error) {
+	git := c.ghClient().Git
+
+	ref, _, err := git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("getting branch %s for %s/%s: %w", branch, owner, repo, err)
+	}
+
+	headSHA := ref.GetObject().GetSHA()
+
+	head, _, err := git.GetCommit(ctx, owner, repo, headSHA)
+	if err != nil {
+		return "", fmt.Errorf("getting commit %s for %s/%s: %w", headSHA, owner, repo, err)
+	}
+
+	entries := make([]*gh.TreeEntry, 0, len(files))
+
+	for _, f := range files {
+		blob, _, err := git.CreateBlob(ctx, owner, repo, &gh.Blob{
+			Content:  gh.Ptr(f.Content),
+			Encoding: gh.Ptr("utf-8"),
+		})
+		if err != nil {
+			return "", fmt.Errorf("creating blob for %s in %s/%s: %w", f.Path, owner, repo, err)
+		}
+
+		entries = append(entries, &gh.TreeEntry{
+			Path: gh.Ptr(f.Path),
+			Mode: gh.Ptr("100644"),
+			Type: gh.Ptr("blob"),
+			SHA:  blob.SHA,
+		})
+	}
+
+	tree, _, err := git.CreateTree(ctx, owner, repo, head.GetTree().GetSHA(), entries)
+	if err != nil {
+		return "", fmt.Errorf("creating tree for %s/%s: %w", owner, repo, err)
+	}
+
+	if tree.GetSHA() == head.GetTree().GetSHA() {
+		c.logger.Debug("files already committed, skipping empty commit", "owner", owner, "repo", repo, "branch", branch)
+		return headSHA, nil
+	}
+
+	commit, _, err := git.CreateCommit(ctx, owner, repo, &gh.Commit{
 		Message: gh.Ptr(message),
-		Content: []byte(content),
-		Branch:  gh.Ptr(branch),
+		Tree:    &gh.Tree{SHA: tree.SHA},
+		Parents: []*gh.Commit{{SHA: gh.Ptr(headSHA)}},
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating commit for %s/%s: %w", owner, repo, err)
 	}
 
-	_, _, err := c.ghClient().Repositories.CreateFile(ctx, owner, repo, path, opts)
+	_, _, err = git.UpdateRef(ctx, owner, repo, &gh.Reference{
+		Ref:    gh.Ptr("refs/heads/" + branch),
+		Object: &gh.GitObject{SHA: commit.SHA},
+	}, false)
 	if err != nil {
-		return fmt.Errorf("creating file %s in %s/%s: %w", path, owner, repo, err)
+		return "", fmt.Errorf("updating branch %s for %s/%s: %w", branch, owner, repo, err)
 	}
 
-	return nil
+	return commit.GetSHA(), nil
 }
 
 // CreatePullRequest creates a new pull request and returns it.
@@ -202,9 +318,37 @@ func (c *GitHubClient) CreatePullRequest(
 		Title:  pr.GetTitle(),
 		Head:   pr.GetHead().GetRef(),
 		State:  pr.GetState(),
+		Body:   pr.GetBody(),
 	}, nil
 }
 
+// UpdatePullRequest replaces the body of a pull request.
+func (c *GitHubClient) UpdatePullRequest(ctx context.Context, owner, repo string, number int, body string) error {
+	if _, _, err := c.ghClient().PullRequests.Edit(ctx, owner, repo, number, &gh.PullRequest{Body: gh.Ptr(body)}); err != nil {
+		return fmt.Errorf("updating PR %s/%s#%d: %w", owner, repo, number, err)
+	}
+
+	return nil
+}
+
+// ClosePullRequest closes a pull request without merging it.
+func (c *GitHubClient) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	if _, _, err := c.ghClient().PullRequests.Edit(ctx, owner, repo, number, &gh.PullRequest{State: gh.Ptr("closed")}); err != nil {
+		return fmt.Errorf("closing PR %s/%s#%d: %w", owner, repo, number, err)
+	}
+
+	return nil
+}
+
+// AddLabels adds labels to a pull request or issue.
+func (c *GitHubClient) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	if _, _, err := c.ghClient().Issues.AddLabelsToIssue(ctx, owner, repo, number, labels); err != nil {
+		return fmt.Errorf("adding labels to %s/%s#%d: %w", owner, repo, number, err)
+	}
+
+	return nil
+}
+
 // ListInstallations returns all installations for this GitHub App.
 func (c *GitHubClient) ListInstallations(ctx context.Context) ([]*Installation, error) {
 	opts := &gh.ListOptions{PerPage: 100}
@@ -219,8 +363,9 @@ func (c *GitHubClient) ListInstallations(ctx context.Context) ([]*Installation,
 
 		for _, install := range installs {
 			allInstalls = append(allInstalls, &Installation{
-				ID:      install.GetID(),
-				Account: install.GetAccount().GetLogin(),
+				ID:        install.GetID(),
+				Account:   install.GetAccount().GetLogin(),
+				Suspended: install.SuspendedAt != nil,
 			})
 		}
 
@@ -287,6 +432,35 @@ func (c *GitHubClient) CreateInstallationClient(_ context.Context, installationI
 	}, nil
 }
 
+// ForgetInstallation drops the cached client, and with it the access token,
+// of an installation. A later call for the installation starts afresh.
+func (c *GitHubClient) ForgetInstallation(installationID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.installClients, installationID)
+}
+
+// InstallationRateLimit returns the rate limit GitHub last reported for an
+// installation's token, and false if the installation has made no call
+// since its client was created.
+func (c *GitHubClient) InstallationRateLimit(installationID int64) (RateLimit, bool) {
+	c.mu.Lock()
+	client, ok := c.installClients[installationID]
+	c.mu.Unlock()
+
+	if !ok {
+		return RateLimit{}, false
+	}
+
+	transport, ok := client.Client().Transport.(*rateLimitTransport)
+	if !ok {
+		return RateLimit{}, false
+	}
+
+	return transport.snapshot()
+}
+
 // GetFileContent returns the decoded content of a file in a repository.
 // Returns empty string and no error if the file does not exist.
 func (c *GitHubClient) GetFileContent(ctx context.Context, owner, repo, path string) (string, error) {
@@ -359,6 +533,109 @@ func (c *GitHubClient) SetCustomPropertyValues(
 	return nil
 }
 
+// ListTree returns the paths of all files (blobs) in the repository at the
+// given ref, recursively. Very large trees may be truncated by GitHub.
+func (c *GitHubClient) ListTree(ctx context.Context, owner, repo, ref string) ([]string, error) {
+	tree, _, err := c.ghClient().Git.GetTree(ctx, owner, repo, ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("getting tree %s for %s/%s: %w", ref, owner, repo, err)
+	}
+
+	if tree.GetTruncated() {
+		c.logger.Warn("repository tree truncated", "owner", owner, "repo", repo, "entries", len(tree.Entries))
+	}
+
+	paths := make([]string, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			paths = append(paths, entry.GetPath())
+		}
+	}
+
+	return paths, nil
+}
+
+// ListLanguages returns the languages detected in a repository, ordered by
+// number of bytes (largest first).
+func (c *GitHubClient) ListLanguages(ctx context.Context, owner, repo string) ([]string, error) {
+	langs, _, err := c.ghClient().Repositories.ListLanguages(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("listing languages for %s/%s: %w", owner, repo, err)
+	}
+
+	names := make([]string, 0, len(langs))
+	for name := range langs {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if langs[names[i]] != langs[names[j]] {
+			return langs[names[i]] > langs[names[j]]
+		}
+
+		return names[i] < names[j]
+	})
+
+	return names, nil
+}
+
+// ListOpenIssues returns all open issues (excluding pull requests) for a repository.
+func (c *GitHubClient) ListOpenIssues(ctx context.Context, owner, repo string) ([]*Issue, error) {
+	opts := &gh.IssueListByRepoOptions{
+		State: "open",
+		ListOptions: gh.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var allIssues []*Issue
+
+	for {
+		issues, resp, err := c.ghClient().Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing issues for %s/%s: %w", owner, repo, err)
+		}
+
+		for _, issue := range issues {
+			// The issues API also returns pull requests.
+			if issue.IsPullRequest() {
+				continue
+			}
+
+			allIssues = append(allIssues, &Issue{
+				Number: issue.GetNumber(),
+				Title:  issue.GetTitle(),
+				State:  issue.GetState(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return allIssues, nil
+}
+
+// CreateIssue creates a new issue and returns it.
+func (c *GitHubClient) CreateIssue(ctx context.Context, owner, repo, title, body string) (*Issue, error) {
+	issue, _, err := c.ghClient().Issues.Create(ctx, owner, repo, &gh.IssueRequest{
+		Title: gh.Ptr(title),
+		Body:  gh.Ptr(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating issue for %s/%s: %w", owner, repo, err)
+	}
+
+	return &Issue{
+		Number: issue.GetNumber(),
+		Title:  issue.GetTitle(),
+		State:  issue.GetState(),
+	}, nil
+}
+
 func (c *GitHubClient) getInstallClient(installationID int64) (*gh.Client, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -378,3 +655,17 @@ func (c *GitHubClient) getInstallClient(installationID int64) (*gh.Client, error
 
 	return client, nil
 }
+
+// TeamExists reports whether a team with the given slug exists in the org.
+func (c *GitHubClient) TeamExists(ctx context.Context, org, slug string) (bool, error) {
+	_, resp, err := c.ghClient().Teams.GetTeamBySlug(ctx, org, slug)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("getting team %s/%s: %w", org, slug, err)
+	}
+
+	return true, nil
+}
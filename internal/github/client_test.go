@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	gh "github.com/google/go-github/v68/github"
 )
@@ -143,6 +144,97 @@ func TestListOpenPullRequests(t *testing.T) {
 	}
 }
 
+func TestListClosedPullRequests(t *testing.T) {
+	t.Parallel()
+
+	closedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != "closed" || q.Get("head") != "owner:my-branch" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		prs := []*gh.PullRequest{
+			{
+				Number:   gh.Ptr(3),
+				Head:     &gh.PullRequestBranch{Ref: gh.Ptr("my-branch")},
+				State:    gh.Ptr("closed"),
+				Body:     gh.Ptr("body"),
+				ClosedAt: &gh.Timestamp{Time: closedAt},
+				Labels:   []*gh.Label{{Name: gh.Ptr("repo-guardian")}},
+			},
+			{
+				Number:   gh.Ptr(2),
+				Head:     &gh.PullRequestBranch{Ref: gh.Ptr("my-branch")},
+				State:    gh.Ptr("closed"),
+				ClosedAt: &gh.Timestamp{Time: closedAt},
+				MergedAt: &gh.Timestamp{Time: closedAt},
+			},
+		}
+
+		if err := json.NewEncoder(w).Encode(prs); err != nil {
+			t.Errorf("encoding response: %v", err)
+		}
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	prs, err := client.ListClosedPullRequests(context.Background(), "owner", "repo", "my-branch")
+	if err != nil {
+		t.Fatalf("ListClosedPullRequests: %v", err)
+	}
+
+	if len(prs) != 2 {
+		t.Fatalf("expected 2 PRs, got %d", len(prs))
+	}
+
+	if prs[0].Merged || !prs[0].ClosedAt.Equal(closedAt) || prs[0].Body != "body" {
+		t.Errorf("unexpected first PR: %+v", prs[0])
+	}
+
+	if len(prs[0].Labels) != 1 || prs[0].Labels[0] != "repo-guardian" {
+		t.Errorf("expected label repo-guardian, got %v", prs[0].Labels)
+	}
+
+	if !prs[1].Merged {
+		t.Error("expected second PR to be merged")
+	}
+}
+
+func TestAddLabels(t *testing.T) {
+	t.Parallel()
+
+	var receivedBody []byte
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/issues/7/labels", func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		receivedBody, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name":"repo-guardian"}]`))
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	if err := client.AddLabels(context.Background(), "owner", "repo", 7, []string{"repo-guardian"}); err != nil {
+		t.Fatalf("AddLabels: %v", err)
+	}
+
+	if !strings.Contains(string(receivedBody), "repo-guardian") {
+		t.Errorf("request body missing label: %s", receivedBody)
+	}
+}
+
 func TestGetRepository(t *testing.T) {
 	t.Parallel()
 
@@ -299,6 +391,30 @@ func TestGetBranchSHA_NotFound(t *testing.T) {
 	}
 }
 
+func TestDeleteBranch_AlreadyDeleted(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/v3/repos/owner/repo/git/refs/heads/repo-guardian/add-missing-files", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message": "Reference does not exist"}`))
+	})
+	mux.HandleFunc("DELETE /api/v3/repos/owner/repo/git/refs/heads/protected", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	if err := client.DeleteBranch(context.Background(), "owner", "repo", "repo-guardian/add-missing-files"); err != nil {
+		t.Errorf("deleting a missing branch should succeed, got %v", err)
+	}
+
+	if err := client.DeleteBranch(context.Background(), "owner", "repo", "protected"); err == nil {
+		t.Error("expected error for a forbidden delete")
+	}
+}
+
 func TestGetFileContent_Exists(t *testing.T) {
 	t.Parallel()
 
@@ -446,3 +562,372 @@ func TestSetCustomPropertyValues(t *testing.T) {
 		t.Errorf("request body missing expected properties: %s", bodyStr)
 	}
 }
+
+func TestListOpenIssues_ExcludesPullRequests(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/issues", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		issues := []*gh.Issue{
+			{Number: gh.Ptr(1), Title: gh.Ptr("bug report"), State: gh.Ptr("open")},
+			{
+				Number:           gh.Ptr(2),
+				Title:            gh.Ptr("a pull request"),
+				State:            gh.Ptr("open"),
+				PullRequestLinks: &gh.PullRequestLinks{URL: gh.Ptr("https://example.com/pulls/2")},
+			},
+		}
+
+		if err := json.NewEncoder(w).Encode(issues); err != nil {
+			t.Errorf("encoding response: %v", err)
+		}
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	issues, err := client.ListOpenIssues(context.Background(), "owner", "repo")
+	if err != nil {
+		t.Fatalf("ListOpenIssues: %v", err)
+	}
+
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d", len(issues))
+	}
+
+	if issues[0].Title != "bug report" {
+		t.Errorf("expected title 'bug report', got %q", issues[0].Title)
+	}
+}
+
+func TestCreateIssue(t *testing.T) {
+	t.Parallel()
+
+	var received gh.IssueRequest
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/issues", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+
+		issue := &gh.Issue{Number: gh.Ptr(7), Title: received.Title, State: gh.Ptr("open")}
+
+		if err := json.NewEncoder(w).Encode(issue); err != nil {
+			t.Errorf("encoding response: %v", err)
+		}
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	issue, err := client.CreateIssue(context.Background(), "owner", "repo", "title", "body")
+	if err != nil {
+		t.Fatalf("CreateIssue: %v", err)
+	}
+
+	if issue.Number != 7 {
+		t.Errorf("expected issue number 7, got %d", issue.Number)
+	}
+
+	if received.GetTitle() != "title" || received.GetBody() != "body" {
+		t.Errorf("unexpected request: title=%q body=%q", received.GetTitle(), received.GetBody())
+	}
+}
+
+func TestListLanguages_SortedByBytes(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/languages", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if _, err := io.WriteString(w, `{"Shell": 120, "Go": 9000, "Dockerfile": 120}`); err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	langs, err := client.ListLanguages(context.Background(), "owner", "repo")
+	if err != nil {
+		t.Fatalf("ListLanguages: %v", err)
+	}
+
+	want := []string{"Go", "Dockerfile", "Shell"}
+	if strings.Join(langs, ",") != strings.Join(want, ",") {
+		t.Errorf("ListLanguages = %v, want %v", langs, want)
+	}
+}
+
+func TestListTree_BlobsOnly(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/git/trees/main", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("recursive") != "1" {
+			t.Errorf("expected recursive=1, got %q", r.URL.RawQuery)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		tree := &gh.Tree{
+			SHA: gh.Ptr("abc"),
+			Entries: []*gh.TreeEntry{
+				{Path: gh.Ptr("go.mod"), Type: gh.Ptr("blob")},
+				{Path: gh.Ptr("web"), Type: gh.Ptr("tree")},
+				{Path: gh.Ptr("web/package.json"), Type: gh.Ptr("blob")},
+			},
+		}
+
+		if err := json.NewEncoder(w).Encode(tree); err != nil {
+			t.Errorf("encoding response: %v", err)
+		}
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	paths, err := client.ListTree(context.Background(), "owner", "repo", "main")
+	if err != nil {
+		t.Fatalf("ListTree: %v", err)
+	}
+
+	if strings.Join(paths, ",") != "go.mod,web/package.json" {
+		t.Errorf("ListTree = %v, want [go.mod web/package.json]", paths)
+	}
+}
+
+func TestTeamExists(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/orgs/org/teams/payments", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if _, err := io.WriteString(w, `{"id": 1, "slug": "payments"}`); err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	})
+	mux.HandleFunc("GET /api/v3/orgs/org/teams/missing", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	client, server := newTestClient(t, mux)
+	defer server.Close()
+
+	exists, err := client.TeamExists(context.Background(), "org", "payments")
+	if err != nil {
+		t.Fatalf("TeamExists(payments): %v", err)
+	}
+
+	if !exists {
+		t.Error("expected payments team to exist")
+	}
+
+	exists, err = client.TeamExists(context.Background(), "org", "missing")
+	if err != nil {
+		t.Fatalf("TeamExists(missing): %v", err)
+	}
+
+	if exists {
+		t.Error("expected missing team not to exist")
+	}
+}
+
+func commitFilesMux(t *testing.T, treeSHA string, calls *[]string) *http.ServeMux {
+	t.Helper()
+
+	record := func(name string) { *calls = append(*calls, name) }
+
+	writeJSON := func(w http.ResponseWriter, body string) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if _, err := io.WriteString(w, body); err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/git/ref/heads/feature", func(w http.ResponseWriter, _ *http.Request) {
+		record("get-ref")
+		writeJSON(w, `{"ref": "refs/heads/feature", "object": {"sha": "head1"}}`)
+	})
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/git/commits/head1", func(w http.ResponseWriter, _ *http.Request) {
+		record("get-commit")
+		writeJSON(w, `{"sha": "head1", "tree": {"sha": "tree1"}}`)
+	})
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/git/blobs", func(w http.ResponseWriter, _ *http.Request) {
+		record("create-blob")
+		writeJSON(w, `{"sha": "blob1"}`)
+	})
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		record("create-tree")
+
+		var body struct {
+			BaseTree string `json:"base_tree"`
+			Tree     []struct {
+				Path string `json:"path"`
+				SHA  string `json:"sha"`
+			} `json:"tree"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding tree request: %v", err)
+		}
+
+		if body.BaseTree != "tree1" || len(body.Tree) != 2 {
+			t.Errorf("unexpected tree request %+v", body)
+		}
+
+		writeJSON(w, `{"sha": "`+treeSHA+`"}`)
+	})
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/git/commits", func(w http.ResponseWriter, _ *http.Request) {
+		record("create-commit")
+		writeJSON(w, `{"sha": "commit2"}`)
+	})
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/git/refs/heads/feature", func(w http.ResponseWriter, r *http.Request) {
+		record("update-ref")
+
+		var body struct {
+			SHA   string `json:"sha"`
+			Force bool   `json:"force"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding ref request: %v", err)
+		}
+
+		if body.SHA != "commit2" || body.Force {
+			t.Errorf("unexpected ref update %+v", body)
+		}
+
+		writeJSON(w, `{"ref": "refs/heads/feature", "object": {"sha": "commit2"}}`)
+	})
+
+	return mux
+}
+
+func TestCommitFiles(t *testing.T) {
+	t.Parallel()
+
+	files := []FileChange{
+		{Path: ".github/CODEOWNERS", Content: "* @org/team\n"},
+		{Path: ".github/dependabot.yml", Content: "version: 2\n"},
+	}
+
+	tests := []struct {
+		name      string
+		treeSHA   string
+		wantSHA   string
+		wantCalls string
+	}{
+		{
+			name:      "new commit",
+			treeSHA:   "tree2",
+			wantSHA:   "commit2",
+			wantCalls: "get-ref,get-commit,create-blob,create-blob,create-tree,create-commit,update-ref",
+		},
+		{
+			name:      "unchanged tree",
+			treeSHA:   "tree1",
+			wantSHA:   "head1",
+			wantCalls: "get-ref,get-commit,create-blob,create-blob,create-tree",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+
+			client, server := newTestClient(t, commitFilesMux(t, tt.treeSHA, &calls))
+			defer server.Close()
+
+			sha, err := client.CommitFiles(context.Background(), "owner", "repo", "feature", "chore: add files", files)
+			if err != nil {
+				t.Fatalf("CommitFiles: %v", err)
+			}
+
+			if sha != tt.wantSHA {
+				t.Errorf("sha = %q, want %q", sha, tt.wantSHA)
+			}
+
+			if got := strings.Join(calls, ","); got != tt.wantCalls {
+				t.Errorf("calls = %s, want %s", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestForgetInstallation(t *testing.T) {
+	t.Parallel()
+
+	client := &GitHubClient{
+		logger:         slog.Default(),
+		installClients: map[int64]*gh.Client{1: gh.NewClient(nil), 2: gh.NewClient(nil)},
+	}
+
+	client.ForgetInstallation(1)
+
+	if _, ok := client.installClients[1]; ok {
+		t.Error("expected installation 1 to be forgotten")
+	}
+
+	if _, ok := client.installClients[2]; !ok {
+		t.Error("expected installation 2 to be kept")
+	}
+}
+
+func TestInstallationRateLimit(t *testing.T) {
+	t.Parallel()
+
+	resetAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		withRateLimitHeaders(w, 4200, 5000, resetAt)
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	transport := newRateLimitTransport(http.DefaultTransport, slog.Default(), 0.10)
+	client := &GitHubClient{
+		logger:         slog.Default(),
+		installClients: map[int64]*gh.Client{1: gh.NewClient(&http.Client{Transport: transport})},
+	}
+
+	if _, ok := client.InstallationRateLimit(1); ok {
+		t.Fatal("expected no rate limit before the first call")
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	got, ok := client.InstallationRateLimit(1)
+	want := RateLimit{Limit: 5000, Remaining: 4200, ResetAt: resetAt}
+
+	if !ok || got.Limit != want.Limit || got.Remaining != want.Remaining || !got.ResetAt.Equal(want.ResetAt) {
+		t.Errorf("InstallationRateLimit = %+v, %v; want %+v, true", got, ok, want)
+	}
+
+	if _, ok := client.InstallationRateLimit(2); ok {
+		t.Error("expected no rate limit for an unknown installation")
+	}
+}
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	gh "github.com/google/go-github/v68/github"
+)
+
+// RateLimitWaitError is returned instead of waiting for the rate limit when
+// the wait would outlast the request's deadline, so a caller with a deadline
+// does not spend it asleep.
+type RateLimitWaitError struct {
+	// Until is when the wait would have ended.
+	Until time.Time
+}
+
+func (e *RateLimitWaitError) Error() string {
+	return "rate limit wait until " + e.Until.UTC().Format(time.RFC3339) + " exceeds the request deadline"
+}
+
+// IsRetryable reports whether err is likely transient, so repeating the
+// operation later may succeed: rate limits, 5xx responses, timeouts and
+// network failures. Any other error, including 4xx responses such as a
+// missing repository or permission, is permanent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var (
+		waitErr      *RateLimitWaitError
+		rateLimitErr *gh.RateLimitError
+		abuseErr     *gh.AbuseRateLimitError
+		acceptedErr  *gh.AcceptedError
+		responseErr  *gh.ErrorResponse
+		installErr   *ghinstallation.HTTPError
+		networkErr   net.Error
+	)
+
+	switch {
+	case errors.As(err, &waitErr), errors.As(err, &rateLimitErr), errors.As(err, &abuseErr), errors.As(err, &acceptedErr):
+		return true
+	case errors.As(err, &responseErr) && responseErr.Response != nil:
+		return retryableStatus(responseErr.Response.StatusCode)
+	case errors.As(err, &installErr):
+		// Without a response the token request never reached GitHub.
+		return installErr.Response == nil || retryableStatus(installErr.Response.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return true
+	case errors.As(err, &networkErr), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET):
+		return true
+	default:
+		return false
+	}
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return code >= http.StatusInternalServerError
+	}
+}
+
+// RateLimitedUntil reports whether err was caused by the GitHub rate limit
+// and, if so, when the limit allows requests again.
+func RateLimitedUntil(err error) (time.Time, bool) {
+	var (
+		waitErr      *RateLimitWaitError
+		rateLimitErr *gh.RateLimitError
+		abuseErr     *gh.AbuseRateLimitError
+	)
+
+	switch {
+	case errors.As(err, &waitErr):
+		return waitErr.Until, true
+	case errors.As(err, &rateLimitErr):
+		return rateLimitErr.Rate.Reset.Time, true
+	case errors.As(err, &abuseErr):
+		return time.Now().Add(abuseErr.GetRetryAfter()), true
+	default:
+		return time.Time{}, false
+	}
+}
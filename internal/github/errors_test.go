@@ -0,0 +1,98 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	gh "github.com/google/go-github/v68/github"
+)
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	status := func(code int) error {
+		return fmt.Errorf("getting repository: %w", &gh.ErrorResponse{Response: &http.Response{StatusCode: code}})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "502", err: status(http.StatusBadGateway), want: true},
+		{name: "429", err: status(http.StatusTooManyRequests), want: true},
+		{name: "404", err: status(http.StatusNotFound), want: false},
+		{name: "403", err: status(http.StatusForbidden), want: false},
+		{name: "rate limit", err: &gh.RateLimitError{Response: &http.Response{StatusCode: http.StatusForbidden}}, want: true},
+		{name: "rate limit wait", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: &RateLimitWaitError{}}, want: true},
+		{name: "deadline", err: fmt.Errorf("listing: %w", context.DeadlineExceeded), want: true},
+		{name: "network", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: errors.New("connection refused")}, want: true},
+		{
+			name: "installation token 401",
+			err:  &ghinstallation.HTTPError{Response: &http.Response{StatusCode: http.StatusUnauthorized}},
+			want: false,
+		},
+		{
+			name: "installation token 503",
+			err:  &ghinstallation.HTTPError{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+			want: true,
+		},
+		{name: "plain error", err: errors.New("default branch main has no SHA"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitedUntil(t *testing.T) {
+	t.Parallel()
+
+	reset := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "wait past deadline",
+			err:    fmt.Errorf("getting repository: %w", &url.Error{Op: "Get", URL: "https://api.github.com", Err: &RateLimitWaitError{Until: reset}}),
+			want:   reset,
+			wantOK: true,
+		},
+		{
+			name:   "rate limit",
+			err:    &gh.RateLimitError{Rate: gh.Rate{Reset: gh.Timestamp{Time: reset}}},
+			want:   reset,
+			wantOK: true,
+		},
+		{name: "deadline", err: context.DeadlineExceeded},
+		{name: "nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := RateLimitedUntil(tt.err)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("RateLimitedUntil(%v) = %v, %v, want %v, %v", tt.err, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@
 // interacting with the GitHub API as a GitHub App.
 package github
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // PullRequest represents a GitHub pull request with the fields
 // relevant to repo-guardian's operations.
@@ -11,12 +14,34 @@ type PullRequest struct {
 	Title  string
 	Head   string // Branch name.
 	State  string // "open", "closed".
+	Body   string
+
+	// Merged, ClosedAt and Labels are only populated for closed pull
+	// requests, by ListClosedPullRequests or from a pull_request webhook.
+	Merged   bool
+	ClosedAt time.Time
+	Labels   []string
+}
+
+// FileChange is a file to be written by CommitFiles.
+type FileChange struct {
+	Path    string
+	Content string
+}
+
+// Issue represents a GitHub issue with the fields relevant to
+// repo-guardian's operations.
+type Issue struct {
+	Number int
+	Title  string
+	State  string // "open", "closed".
 }
 
 // Installation represents a GitHub App installation on an org or user account.
 type Installation struct {
-	ID      int64
-	Account string
+	ID        int64
+	Account   string
+	Suspended bool
 }
 
 // Repository represents a GitHub repository with metadata needed
@@ -30,6 +55,13 @@ type Repository struct {
 	DefaultRef string // Default branch name (e.g., "main").
 }
 
+// RateLimit is the rate limit GitHub last reported for a token.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
 // CustomPropertyValue represents a single custom property key-value pair
 // on a GitHub repository.
 type CustomPropertyValue struct {
@@ -46,6 +78,10 @@ type Client interface {
 	// ListOpenPullRequests returns all open pull requests for a repository.
 	ListOpenPullRequests(ctx context.Context, owner, repo string) ([]*PullRequest, error)
 
+	// ListClosedPullRequests returns the most recently updated closed pull
+	// requests (merged or not) whose head is the given branch of the repository.
+	ListClosedPullRequests(ctx context.Context, owner, repo, branch string) ([]*PullRequest, error)
+
 	// GetRepository returns repository metadata including archive/fork status and default branch.
 	GetRepository(ctx context.Context, owner, repo string) (*Repository, error)
 
@@ -55,15 +91,28 @@ type Client interface {
 	// CreateBranch creates a new branch from the given base SHA.
 	CreateBranch(ctx context.Context, owner, repo, branch, baseSHA string) error
 
-	// DeleteBranch deletes a branch from the repository.
+	// DeleteBranch deletes a branch from the repository. Deleting a branch
+	// that no longer exists is not an error.
 	DeleteBranch(ctx context.Context, owner, repo, branch string) error
 
-	// CreateOrUpdateFile creates or updates a file on the given branch.
-	CreateOrUpdateFile(ctx context.Context, owner, repo, branch, path, content, message string) error
+	// CommitFiles writes all files to the branch in a single commit built on
+	// the branch's current head and returns the new head SHA. If the files
+	// are already present with the same content, no commit is created.
+	CommitFiles(ctx context.Context, owner, repo, branch, message string, files []FileChange) (string, error)
 
 	// CreatePullRequest creates a new pull request and returns it.
 	CreatePullRequest(ctx context.Context, owner, repo, title, body, head, base string) (*PullRequest, error)
 
+	// UpdatePullRequest replaces the body of a pull request.
+	UpdatePullRequest(ctx context.Context, owner, repo string, number int, body string) error
+
+	// ClosePullRequest closes a pull request without merging it.
+	ClosePullRequest(ctx context.Context, owner, repo string, number int) error
+
+	// AddLabels adds labels to a pull request or issue, creating labels that
+	// do not exist in the repository.
+	AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error
+
 	// ListInstallations returns all installations for this GitHub App.
 	ListInstallations(ctx context.Context) ([]*Installation, error)
 
@@ -74,6 +123,10 @@ type Client interface {
 	// This is needed because each installation has its own access token.
 	CreateInstallationClient(ctx context.Context, installationID int64) (Client, error)
 
+	// ForgetInstallation drops the cached client and access token of an
+	// installation that was deleted or suspended.
+	ForgetInstallation(installationID int64)
+
 	// GetFileContent returns the decoded content of a file in a repository.
 	// Returns empty string and no error if the file does not exist.
 	GetFileContent(ctx context.Context, owner, repo, path string) (string, error)
@@ -83,4 +136,21 @@ type Client interface {
 
 	// SetCustomPropertyValues creates or updates custom property values on a repository.
 	SetCustomPropertyValues(ctx context.Context, owner, repo string, properties []*CustomPropertyValue) error
+
+	// ListTree returns the paths of all files (blobs) in the repository at the
+	// given ref, recursively. Very large trees may be truncated by GitHub.
+	ListTree(ctx context.Context, owner, repo, ref string) ([]string, error)
+
+	// ListLanguages returns the languages detected in a repository, ordered by
+	// number of bytes (largest first).
+	ListLanguages(ctx context.Context, owner, repo string) ([]string, error)
+
+	// ListOpenIssues returns all open issues (excluding pull requests) for a repository.
+	ListOpenIssues(ctx context.Context, owner, repo string) ([]*Issue, error)
+
+	// CreateIssue creates a new issue and returns it.
+	CreateIssue(ctx context.Context, owner, repo, title, body string) (*Issue, error)
+
+	// TeamExists reports whether a team with the given slug exists in the org.
+	TeamExists(ctx context.Context, org, slug string) (bool, error)
 }
@@ -70,7 +70,7 @@ func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error
 	metrics.GitHubRateLimitWaitsTotal.WithLabelValues(reason).Inc()
 	metrics.GitHubRateLimitWaitSeconds.Observe(delay.Seconds())
 
-	if err := sleepWithContext(req.Context(), delay); err != nil {
+	if err := waitWithin(req.Context(), delay); err != nil {
 		return nil, err
 	}
 
@@ -144,7 +144,30 @@ func (t *rateLimitTransport) waitIfNeeded(ctx context.Context) error {
 	metrics.GitHubRateLimitWaitsTotal.WithLabelValues("preemptive").Inc()
 	metrics.GitHubRateLimitWaitSeconds.Observe(delay.Seconds())
 
-	return sleepWithContext(ctx, delay)
+	return waitWithin(ctx, delay)
+}
+
+// waitWithin sleeps for d, or fails right away with a RateLimitWaitError if
+// ctx would end first.
+func waitWithin(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return &RateLimitWaitError{Until: time.Now().Add(d)}
+	}
+
+	return sleepWithContext(ctx, d)
+}
+
+// snapshot returns the rate limit last reported by GitHub, and false until a
+// response carried rate limit headers.
+func (t *rateLimitTransport) snapshot() (RateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limit == 0 {
+		return RateLimit{}, false
+	}
+
+	return RateLimit{Limit: t.limit, Remaining: t.remaining, ResetAt: t.resetAt}, true
 }
 
 // updateFromResponse parses rate limit headers and updates internal state.
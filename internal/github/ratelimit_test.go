@@ -2,6 +2,7 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -273,6 +274,53 @@ func TestRateLimitTransport_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestRateLimitTransport_WaitPastDeadline(t *testing.T) {
+	t.Parallel()
+
+	resetAt := time.Now().Add(10 * time.Minute)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		withRateLimitHeaders(w, 0, 5000, resetAt)
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"message": "API rate limit exceeded"}`)
+	}))
+	defer server.Close()
+
+	transport := newRateLimitTransport(
+		http.DefaultTransport,
+		slog.Default(),
+		0.10,
+	)
+
+	client := &http.Client{Transport: transport}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	start := time.Now()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, http.NoBody)
+
+	resp, err := client.Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	var waitErr *RateLimitWaitError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("expected a RateLimitWaitError, got %v", err)
+	}
+
+	if waitErr.Until.Before(resetAt.Add(-time.Second)) {
+		t.Errorf("Until = %v, want about %v", waitErr.Until, resetAt)
+	}
+
+	// The deadline is not spent waiting.
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected an immediate error, took %v", elapsed)
+	}
+}
+
 func TestRateLimitTransport_RetryExhausted(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// timeoutTransport is an http.RoundTripper that bounds each GitHub API call,
+// including reading the response body, so a hung connection cannot block a
+// job forever. It sits below rateLimitTransport so that waiting for the rate
+// limit to reset does not count against the timeout.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration // Zero disables the timeout.
+}
+
+// newTimeoutTransport wraps the given transport with a per-request timeout.
+func newTimeoutTransport(next http.RoundTripper, timeout time.Duration) *timeoutTransport {
+	return &timeoutTransport{next: next, timeout: timeout}
+}
+
+// RoundTrip executes an HTTP request with a deadline of now plus the timeout.
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The deadline must outlive RoundTrip until the body has been read.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context when its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
@@ -32,6 +32,21 @@ var (
 		Help: "Missing files detected.",
 	}, []string{"rule_name"})
 
+	// ContentAssertionsFailedTotal counts content assertions that failed on
+	// existing files, labeled by rule name.
+	ContentAssertionsFailedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_content_assertions_failed_total",
+		Help: "Content assertions that failed on existing files.",
+	}, []string{"rule_name"})
+
+	// ManagedFileDriftTotal counts managed files whose content differs from
+	// the current rendered template, labeled by rule name and outcome
+	// ("updated" or "edited", when human edits are left alone).
+	ManagedFileDriftTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_managed_file_drift_total",
+		Help: "Managed files that differ from the current template.",
+	}, []string{"rule_name", "outcome"})
+
 	// CheckDurationSeconds records the time to check a single repo.
 	CheckDurationSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
 		Name:    "repo_guardian_check_duration_seconds",
@@ -70,6 +85,12 @@ var (
 		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
 	})
 
+	// RepoConfigInvalidTotal counts repositories skipped due to an invalid .github/repo-guardian.yml.
+	RepoConfigInvalidTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "repo_guardian_repo_config_invalid_total",
+		Help: "Total repositories skipped because of an invalid per-repo config file.",
+	})
+
 	// PropertiesCheckedTotal counts repos where custom properties were evaluated.
 	PropertiesCheckedTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "repo_guardian_properties_checked_total",
@@ -93,4 +114,169 @@ var (
 		Name: "repo_guardian_properties_already_correct_total",
 		Help: "Total repositories where custom properties already matched desired values.",
 	})
+
+	// RuleDeclinesTotal counts rules found in a repo-guardian PR closed
+	// without merging, by whether the decline was still active or had expired.
+	RuleDeclinesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_rule_declines_total",
+		Help: "Rules matched to a declined repo-guardian PR, by state (active, expired).",
+	}, []string{"rule_name", "state"})
+
+	// QueueJobsRecoveredTotal counts pending jobs reloaded from a persistent
+	// queue store at startup.
+	QueueJobsRecoveredTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "repo_guardian_queue_jobs_recovered_total",
+		Help: "Total pending jobs resumed from the persistent queue at startup.",
+	})
+
+	// QueueJobsCoalescedTotal counts enqueued jobs merged into a pending job
+	// for the same repository, labeled by the trigger of the merged job.
+	QueueJobsCoalescedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_queue_jobs_coalesced_total",
+		Help: "Jobs merged into an already pending job for the same repository.",
+	}, []string{"trigger"})
+
+	// JobRetriesTotal counts jobs re-queued after a retryable error.
+	JobRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_job_retries_total",
+		Help: "Jobs re-queued with backoff after a retryable error.",
+	}, []string{"trigger"})
+
+	// JobsDeferredTotal counts jobs put off until a PR window opens.
+	JobsDeferredTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_jobs_deferred_total",
+		Help: "Jobs re-queued until the next PR window because they would open a pull request outside it.",
+	}, []string{"trigger"})
+
+	// JobsRateLimitedTotal counts jobs put off until the GitHub rate limit
+	// allows them, instead of waiting past their deadline.
+	JobsRateLimitedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_jobs_rate_limited_total",
+		Help: "Jobs re-queued until the GitHub rate limit resets, without counting an attempt.",
+	}, []string{"trigger"})
+
+	// DeferredJobs tracks jobs waiting for a PR window. They do not count
+	// toward the queue size.
+	DeferredJobs = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "repo_guardian_deferred_jobs",
+		Help: "Jobs waiting for the next PR window, outside the queue size.",
+	})
+
+	// JobsDeadLetteredTotal counts jobs moved to the dead-letter list.
+	JobsDeadLetteredTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "repo_guardian_jobs_dead_lettered_total",
+		Help: "Total jobs moved to the dead-letter list after exhausting retries.",
+	})
+
+	// DeadLetterJobs tracks the current size of the dead-letter list.
+	DeadLetterJobs = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "repo_guardian_dead_letter_jobs",
+		Help: "Jobs currently in the dead-letter list.",
+	})
+
+	// QueueLaneDepth tracks pending jobs in each priority lane.
+	QueueLaneDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "repo_guardian_queue_lane_depth",
+		Help: "Pending jobs in each queue priority lane (manual, webhook, scheduler).",
+	}, []string{"lane"})
+
+	// QueueWaitSeconds observes how long a job waited in its lane before a
+	// worker picked it up, excluding retry backoff.
+	QueueWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repo_guardian_queue_wait_seconds",
+		Help:    "Time jobs spent ready in the queue before dispatch, by priority lane.",
+		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{"lane"})
+
+	// JobsTimedOutTotal counts jobs canceled at their per-job deadline.
+	JobsTimedOutTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_jobs_timed_out_total",
+		Help: "Jobs canceled because they exceeded the per-job deadline.",
+	}, []string{"trigger"})
+
+	// JobsAbortedTotal counts in-flight jobs aborted when the shutdown grace
+	// period expired, by whether they were kept for the next process
+	// (requeued) or lost (dropped).
+	JobsAbortedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_jobs_aborted_total",
+		Help: "In-flight jobs aborted at shutdown, by outcome (requeued, dropped).",
+	}, []string{"outcome"})
+
+	// PRsMergedTotal counts repo-guardian PRs merged, by kind (files,
+	// properties, catalog-info).
+	PRsMergedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_prs_merged_total",
+		Help: "repo-guardian PRs merged, by kind (files, properties, catalog-info).",
+	}, []string{"kind"})
+
+	// PRsDeclinedTotal counts repo-guardian PRs closed without merging, by
+	// kind.
+	PRsDeclinedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_prs_declined_total",
+		Help: "repo-guardian PRs closed without merging, by kind (files, properties, catalog-info).",
+	}, []string{"kind"})
+
+	// PRsWithdrawnTotal counts repo-guardian PRs closed by repo-guardian
+	// because their repository was transferred away, by kind.
+	PRsWithdrawnTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_prs_withdrawn_total",
+		Help: "repo-guardian PRs closed because their repository was transferred away, by kind.",
+	}, []string{"kind"})
+
+	// WebhookDuplicatesTotal counts webhook deliveries dropped because their
+	// delivery ID was already seen, by event type.
+	WebhookDuplicatesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_webhook_duplicates_total",
+		Help: "Webhook deliveries dropped as duplicates.",
+	}, []string{"event_type"})
+
+	// WebhookReplayedTotal counts stored webhook deliveries replayed through
+	// the admin endpoint, by event type.
+	WebhookReplayedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_webhook_replayed_total",
+		Help: "Stored webhook deliveries replayed.",
+	}, []string{"event_type"})
+
+	// WebhookAcceptedTotal counts webhook deliveries accepted into the
+	// intake, by event type.
+	WebhookAcceptedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_webhook_accepted_total",
+		Help: "Webhook deliveries accepted for processing.",
+	}, []string{"event_type"})
+
+	// WebhookRejectedTotal counts webhook deliveries refused, by reason
+	// (invalid_signature, bad_payload, intake_full, stopped).
+	WebhookRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_webhook_rejected_total",
+		Help: "Webhook deliveries rejected, by reason.",
+	}, []string{"reason"})
+
+	// WebhookProcessedTotal counts accepted webhook deliveries handled by
+	// the intake workers, by event type and outcome (success, failed).
+	WebhookProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_webhook_processed_total",
+		Help: "Accepted webhook deliveries handled, by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+
+	// WebhookIntakeDepth tracks accepted webhook deliveries waiting to be
+	// handled.
+	WebhookIntakeDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "repo_guardian_webhook_intake_depth",
+		Help: "Accepted webhook deliveries waiting to be handled.",
+	})
+
+	// WebhookSecretMatchedTotal counts validated webhook deliveries by the
+	// index of the secret that matched (0 is the current secret).
+	WebhookSecretMatchedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_guardian_webhook_secret_matched_total",
+		Help: "Validated webhook deliveries, by index of the matching secret (0 is current).",
+	}, []string{"secret_index"})
+
+	// ReconcileCompletionTime records when the last full reconciliation
+	// pass was projected to finish enqueuing, as planned by its pacing, and
+	// when it actually did.
+	ReconcileCompletionTime = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "repo_guardian_reconcile_completion_timestamp_seconds",
+		Help: "Unix time the last reconciliation pass was projected to finish enqueuing, and actually did.",
+	}, []string{"estimate"})
 )
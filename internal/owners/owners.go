@@ -0,0 +1,135 @@
+// Package owners maps Backstage catalog owners (e.g. "group:default/payments")
+// to GitHub team slugs used in generated CODEOWNERS files.
+package owners
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// teamSlugPattern matches the slugs GitHub generates for team names.
+var teamSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Mapping translates catalog owner references to GitHub team slugs.
+// A nil Mapping resolves nothing.
+type Mapping struct {
+	// Teams maps a catalog owner reference, as written in spec.owner
+	// (case-insensitive), to a team slug.
+	Teams map[string]string `yaml:"teams"`
+
+	// InferFromGroupName resolves owners missing from Teams by using the
+	// group's entity name as the slug, e.g. "group:default/payments-team"
+	// becomes "payments-team". User owners are never inferred.
+	InferFromGroupName *bool `yaml:"inferFromGroupName"`
+}
+
+// Default returns the Mapping used when no mapping file is configured:
+// no explicit entries, with team slugs inferred from group names.
+func Default() *Mapping {
+	infer := true
+
+	return &Mapping{InferFromGroupName: &infer}
+}
+
+// Load reads a Mapping from the YAML document at path. If path is empty or
+// the file does not exist, Default is returned.
+func Load(path string) (*Mapping, error) {
+	if path == "" {
+		return Default(), nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Default(), nil
+		}
+
+		return nil, fmt.Errorf("reading owner mapping %s: %w", path, err)
+	}
+
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing owner mapping %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Parse decodes and validates a mapping document. Unknown fields are
+// rejected. InferFromGroupName defaults to true when omitted.
+func Parse(data []byte) (*Mapping, error) {
+	m := &Mapping{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if m.InferFromGroupName == nil {
+		m.InferFromGroupName = Default().InferFromGroupName
+	}
+
+	var errs []error
+
+	for owner, slug := range m.Teams {
+		if !teamSlugPattern.MatchString(slug) {
+			errs = append(errs, fmt.Errorf("teams.%s: %q is not a valid team slug", owner, slug))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// TeamFor returns the team slug for a catalog owner reference and true, or
+// an empty string and false if the owner cannot be mapped.
+func (m *Mapping) TeamFor(catalogOwner string) (string, bool) {
+	if m == nil || catalogOwner == "" {
+		return "", false
+	}
+
+	for owner, slug := range m.Teams {
+		if strings.EqualFold(owner, catalogOwner) {
+			return slug, true
+		}
+	}
+
+	if m.InferFromGroupName == nil || !*m.InferFromGroupName {
+		return "", false
+	}
+
+	// Entity references are [kind:][namespace/]name; the kind defaults to
+	// group for spec.owner.
+	kind, name, found := strings.Cut(catalogOwner, ":")
+	if !found {
+		kind, name = "group", catalogOwner
+	}
+
+	if !strings.EqualFold(kind, "group") {
+		return "", false
+	}
+
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	slug := strings.ToLower(name)
+	if !teamSlugPattern.MatchString(slug) {
+		return "", false
+	}
+
+	return slug, true
+}
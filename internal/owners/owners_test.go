@@ -0,0 +1,126 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTeamFor(t *testing.T) {
+	t.Parallel()
+
+	m, err := Parse([]byte("teams:\n  group:default/payments-team: payments-eng\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		owner  string
+		want   string
+		wantOK bool
+	}{
+		{owner: "group:default/payments-team", want: "payments-eng", wantOK: true},
+		{owner: "Group:Default/Payments-Team", want: "payments-eng", wantOK: true},
+		{owner: "group:default/platform", want: "platform", wantOK: true},
+		{owner: "group:Platform", want: "platform", wantOK: true},
+		{owner: "search-team", want: "search-team", wantOK: true},
+		{owner: "user:default/jdoe", wantOK: false},
+		{owner: "Unclassified", want: "unclassified", wantOK: true},
+		{owner: "group:default/has space", wantOK: false},
+		{owner: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.owner, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := m.TeamFor(tt.owner)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("TeamFor(%q) = %q, %v; want %q, %v", tt.owner, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTeamFor_InferDisabled(t *testing.T) {
+	t.Parallel()
+
+	m, err := Parse([]byte("inferFromGroupName: false\nteams:\n  group:default/a: team-a\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if slug, ok := m.TeamFor("group:default/a"); !ok || slug != "team-a" {
+		t.Errorf("explicit mapping should resolve, got %q, %v", slug, ok)
+	}
+
+	if _, ok := m.TeamFor("group:default/b"); ok {
+		t.Error("unmapped owner should not resolve when inference is disabled")
+	}
+}
+
+func TestTeamFor_NilMapping(t *testing.T) {
+	t.Parallel()
+
+	var m *Mapping
+
+	if _, ok := m.TeamFor("group:default/a"); ok {
+		t.Error("nil mapping should not resolve")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "unknown field", doc: "team:\n  a: b\n", wantErr: "team"},
+		{name: "invalid slug", doc: "teams:\n  group:default/a: \"@org/a\"\n", wantErr: "not a valid team slug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse([]byte(tt.doc))
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q should contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	m, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Load(missing): %v", err)
+	}
+
+	if slug, ok := m.TeamFor("group:default/a"); !ok || slug != "a" {
+		t.Errorf("default mapping should infer from group name, got %q, %v", slug, ok)
+	}
+
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	if err := os.WriteFile(path, []byte("teams:\n  group:default/a: team-a\n"), 0o600); err != nil {
+		t.Fatalf("writing mapping: %v", err)
+	}
+
+	m, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if slug, _ := m.TeamFor("group:default/a"); slug != "team-a" {
+		t.Errorf("TeamFor = %q, want team-a", slug)
+	}
+}
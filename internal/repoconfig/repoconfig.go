@@ -0,0 +1,132 @@
+// Package repoconfig parses the per-repository `.github/repo-guardian.yml`
+// file that lets teams opt out of rules and record overrides in-repo.
+package repoconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Path is the location of the per-repository configuration file.
+const Path = ".github/repo-guardian.yml"
+
+// Config holds the per-repository settings. A missing or empty file yields
+// a zero Config, which means "enforce all enabled rules".
+type Config struct {
+	// Exclude lists rule names (case-insensitive) that should not be
+	// enforced for this repository.
+	Exclude []string `yaml:"exclude"`
+
+	// Overrides customizes individual rules, keyed by rule name
+	// (case-insensitive).
+	Overrides map[string]Override `yaml:"overrides"`
+
+	// DisableCustomProperties skips custom properties management for
+	// this repository.
+	DisableCustomProperties bool `yaml:"disableCustomProperties"`
+}
+
+// Override holds per-rule customizations.
+type Override struct {
+	// TargetPath replaces the rule's default TargetPath.
+	TargetPath string `yaml:"targetPath"`
+
+	// Template replaces the rule's DefaultTemplateName.
+	Template string `yaml:"template"`
+}
+
+// Parse unmarshals and validates the content of a repo-guardian.yml file.
+// Empty content returns a zero Config. Unknown fields are rejected so that
+// typos are reported rather than silently ignored.
+func Parse(content string) (*Config, error) {
+	cfg := &Config{}
+
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &Config{}, nil
+		}
+
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Excludes reports whether the named rule is excluded.
+func (c *Config) Excludes(ruleName string) bool {
+	if c == nil {
+		return false
+	}
+
+	for _, name := range c.Exclude {
+		if strings.EqualFold(name, ruleName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OverrideFor returns the override for the named rule and true, or a zero
+// Override and false if none is configured.
+func (c *Config) OverrideFor(ruleName string) (Override, bool) {
+	if c == nil {
+		return Override{}, false
+	}
+
+	for name, o := range c.Overrides {
+		if strings.EqualFold(name, ruleName) {
+			return o, true
+		}
+	}
+
+	return Override{}, false
+}
+
+// RuleNames returns every rule name referenced by Exclude or Overrides.
+func (c *Config) RuleNames() []string {
+	if c == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(c.Exclude)+len(c.Overrides))
+	names = append(names, c.Exclude...)
+
+	for name := range c.Overrides {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (c *Config) validate() error {
+	var errs []error
+
+	for name, o := range c.Overrides {
+		if o.TargetPath == "" && o.Template == "" {
+			errs = append(errs, fmt.Errorf("overrides.%s: must set targetPath or template", name))
+		}
+
+		if o.TargetPath != "" &&
+			(strings.HasPrefix(o.TargetPath, "/") || strings.Contains(o.TargetPath, "..")) {
+			errs = append(errs, fmt.Errorf(
+				"overrides.%s.targetPath %q: must be a path relative to the repository root",
+				name, o.TargetPath,
+			))
+		}
+	}
+
+	return errors.Join(errs...)
+}
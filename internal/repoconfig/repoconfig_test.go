@@ -0,0 +1,107 @@
+package repoconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_Valid(t *testing.T) {
+	t.Parallel()
+
+	content := `
+exclude:
+  - renovate
+  - Dependabot
+overrides:
+  codeowners:
+    targetPath: CODEOWNERS
+    template: codeowners-minimal
+disableCustomProperties: true
+`
+
+	cfg, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if !cfg.Excludes("Renovate") || !cfg.Excludes("dependabot") {
+		t.Errorf("expected case-insensitive excludes, got %v", cfg.Exclude)
+	}
+
+	if cfg.Excludes("CODEOWNERS") {
+		t.Error("CODEOWNERS should not be excluded")
+	}
+
+	o, ok := cfg.OverrideFor("CODEOWNERS")
+	if !ok {
+		t.Fatal("expected CODEOWNERS override")
+	}
+
+	if o.TargetPath != "CODEOWNERS" || o.Template != "codeowners-minimal" {
+		t.Errorf("unexpected override: %+v", o)
+	}
+
+	if !cfg.DisableCustomProperties {
+		t.Error("expected DisableCustomProperties to be true")
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{"", "---\n", "# only a comment\n"} {
+		cfg, err := Parse(content)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", content, err)
+		}
+
+		if len(cfg.Exclude) != 0 || len(cfg.Overrides) != 0 || cfg.DisableCustomProperties {
+			t.Errorf("Parse(%q) should return a zero config, got %+v", content, cfg)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "malformed yaml", content: "exclude: [unclosed", wantErr: "yaml"},
+		{name: "unknown field", content: "excludes:\n  - renovate\n", wantErr: "excludes"},
+		{name: "wrong type", content: "exclude: renovate\n", wantErr: "cannot unmarshal"},
+		{name: "empty override", content: "overrides:\n  codeowners: {}\n", wantErr: "must set targetPath or template"},
+		{name: "absolute path", content: "overrides:\n  codeowners:\n    targetPath: /CODEOWNERS\n", wantErr: "relative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(tt.content)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q should contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNilConfig(t *testing.T) {
+	t.Parallel()
+
+	var cfg *Config
+
+	if cfg.Excludes("anything") {
+		t.Error("nil config should not exclude rules")
+	}
+
+	if _, ok := cfg.OverrideFor("anything"); ok {
+		t.Error("nil config should not have overrides")
+	}
+}
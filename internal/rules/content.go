@@ -0,0 +1,474 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Assertion is a requirement on the content of an existing file. Exactly one
+// of Pattern or YAMLPath is set.
+type Assertion struct {
+	// Description is a short human-readable summary used in logs and PR
+	// bodies, e.g. "catch-all `*` owner".
+	Description string
+
+	// Pattern is a regular expression that must match somewhere in the
+	// file. It is evaluated in multi-line mode, so ^ and $ match at line
+	// boundaries.
+	Pattern string
+
+	// YAMLPath is a dotted path into a YAML document. A segment ending in
+	// "[]" iterates over a sequence, e.g. "updates[].package-ecosystem".
+	YAMLPath string
+
+	// Equals is the scalar value that must appear at YAMLPath.
+	Equals string
+
+	// Fix is the content used to satisfy a failed assertion. For Pattern
+	// assertions it is appended to the file as-is. For YAMLPath assertions
+	// it is a YAML node appended to the sequence named by the first "[]"
+	// segment, which is created if missing. Without a Fix a failure is only
+	// reported.
+	Fix string
+}
+
+// AssertionResult is the outcome of evaluating a rule's assertions against
+// a file.
+type AssertionResult struct {
+	// Content is the file content with every available fix applied. It
+	// equals the input when nothing could be fixed.
+	Content string
+
+	// Failed lists the assertions that did not hold on the original content.
+	Failed []Assertion
+
+	// Invalid is why YAML assertions could not be evaluated, e.g. a syntax
+	// error in the file. Those assertions are in Failed, without a fix.
+	Invalid error
+}
+
+// errInvalidYAML marks content that cannot be parsed as YAML.
+var errInvalidYAML = errors.New("not valid YAML")
+
+// Patched reports whether at least one fix changed the content.
+func (r *AssertionResult) Patched(original string) bool {
+	return r.Content != original
+}
+
+// CheckAssertions evaluates assertions against content in order, applying the
+// fix of each failed assertion before evaluating the next. A YAML assertion
+// on content that is not valid YAML fails, and is not fixed.
+func CheckAssertions(content string, assertions []Assertion) (*AssertionResult, error) {
+	result := &AssertionResult{Content: content}
+
+	for i := range assertions {
+		a := &assertions[i]
+
+		ok, err := a.holds(result.Content)
+		if errors.Is(err, errInvalidYAML) {
+			if result.Invalid == nil {
+				result.Invalid = err
+			}
+
+			failed := *a
+			failed.Fix = ""
+			result.Failed = append(result.Failed, failed)
+
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("evaluating assertion %q: %w", a.Description, err)
+		}
+
+		if ok {
+			continue
+		}
+
+		result.Failed = append(result.Failed, *a)
+
+		if a.Fix == "" {
+			continue
+		}
+
+		fixed, err := a.apply(result.Content)
+		if err != nil {
+			return nil, fmt.Errorf("applying fix for %q: %w", a.Description, err)
+		}
+
+		result.Content = fixed
+	}
+
+	return result, nil
+}
+
+// Validate checks that the assertion is well formed.
+func (a *Assertion) Validate() error {
+	var errs []error
+
+	if a.Description == "" {
+		errs = append(errs, errors.New("description is required"))
+	}
+
+	switch {
+	case a.Pattern != "" && a.YAMLPath != "":
+		errs = append(errs, errors.New("only one of pattern or yamlPath may be set"))
+	case a.Pattern != "":
+		if _, err := compilePattern(a.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("pattern: %w", err))
+		}
+	case a.YAMLPath != "":
+		errs = append(errs, a.validateYAML()...)
+	default:
+		errs = append(errs, errors.New("one of pattern or yamlPath is required"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *Assertion) validateYAML() []error {
+	var errs []error
+
+	if a.Equals == "" {
+		errs = append(errs, errors.New("equals is required with yamlPath"))
+	}
+
+	for _, seg := range strings.Split(a.YAMLPath, ".") {
+		if strings.TrimSuffix(seg, "[]") == "" {
+			errs = append(errs, fmt.Errorf("yamlPath %q has an empty segment", a.YAMLPath))
+			break
+		}
+	}
+
+	if a.Fix == "" {
+		return errs
+	}
+
+	if !strings.Contains(a.YAMLPath, "[]") {
+		errs = append(errs, errors.New("fix requires a \"[]\" segment in yamlPath"))
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(a.Fix), &node); err != nil {
+		errs = append(errs, fmt.Errorf("fix is not valid YAML: %w", err))
+	}
+
+	return errs
+}
+
+func (a *Assertion) holds(content string) (bool, error) {
+	if a.Pattern != "" {
+		re, err := compilePattern(a.Pattern)
+		if err != nil {
+			return false, err
+		}
+
+		return re.MatchString(content), nil
+	}
+
+	doc, err := parseYAMLDocument(content)
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range yamlValues(doc, strings.Split(a.YAMLPath, ".")) {
+		if v.Kind == yaml.ScalarNode && v.Value == a.Equals {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (a *Assertion) apply(content string) (string, error) {
+	if a.Pattern != "" {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+
+		fix := a.Fix
+		if !strings.HasSuffix(fix, "\n") {
+			fix += "\n"
+		}
+
+		return content + fix, nil
+	}
+
+	return appendYAML(content, a.YAMLPath, a.Fix)
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?m)" + pattern)
+}
+
+// parseYAMLDocument returns the top-level node of content, or an empty
+// mapping for an empty document.
+func parseYAMLDocument(content string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidYAML, err)
+	}
+
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	return doc.Content[0], nil
+}
+
+// yamlValues returns every node reachable from node by following path.
+func yamlValues(node *yaml.Node, path []string) []*yaml.Node {
+	if len(path) == 0 {
+		return []*yaml.Node{node}
+	}
+
+	key, isSeq := strings.CutSuffix(path[0], "[]")
+
+	child := mappingValue(node, key)
+	if child == nil {
+		return nil
+	}
+
+	if !isSeq {
+		return yamlValues(child, path[1:])
+	}
+
+	if child.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var values []*yaml.Node
+	for _, item := range child.Content {
+		values = append(values, yamlValues(item, path[1:])...)
+	}
+
+	return values
+}
+
+// appendYAML appends the fix node to the sequence named by the first "[]"
+// segment of path, creating intermediate mappings and the sequence as needed.
+// The new lines are spliced into content, so every other line stays as it
+// was. Only a flow-style collection on the path, which cannot be extended
+// line by line, makes the whole document be encoded again.
+func appendYAML(content, path, fix string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidYAML, err)
+	}
+
+	var fixDoc yaml.Node
+	if err := yaml.Unmarshal([]byte(fix), &fixDoc); err != nil {
+		return "", fmt.Errorf("parsing fix: %w", err)
+	}
+
+	if len(fixDoc.Content) == 0 {
+		return content, nil
+	}
+
+	segs := strings.Split(path, ".")
+
+	if len(doc.Content) == 0 {
+		return appendToEmptyYAML(content, yamlPathNode(segs, fixDoc.Content))
+	}
+
+	node := doc.Content[0]
+
+	// end is the line where the region of node ends, 0 for the end of the
+	// file.
+	end := 0
+
+	for i, seg := range segs {
+		key, isSeq := strings.CutSuffix(seg, "[]")
+
+		if node.Kind != yaml.MappingNode {
+			return "", fmt.Errorf("%s: parent is not a mapping", key)
+		}
+
+		idx := mappingIndex(node, key)
+		if idx < 0 {
+			if !isBlockCollection(node) {
+				node.Content = append(node.Content, yamlPathNode(segs[i:], fixDoc.Content).Content...)
+				return encodeYAMLDocument(content, &doc)
+			}
+
+			return spliceYAML(content, end, node.Column-1, yamlPathNode(segs[i:], fixDoc.Content))
+		}
+
+		if idx+2 < len(node.Content) {
+			end = node.Content[idx+2].Line
+		}
+
+		child := node.Content[idx+1]
+
+		if isSeq {
+			if child.Kind != yaml.SequenceNode {
+				return "", fmt.Errorf("%s: not a sequence", key)
+			}
+
+			if !isBlockCollection(child) {
+				child.Content = append(child.Content, fixDoc.Content...)
+				return encodeYAMLDocument(content, &doc)
+			}
+
+			return spliceYAML(content, end, child.Column-1, &yaml.Node{Kind: yaml.SequenceNode, Content: fixDoc.Content})
+		}
+
+		node = child
+	}
+
+	return content, nil
+}
+
+// appendToEmptyYAML adds node to a document that has no content yet, after
+// any comments it holds.
+func appendToEmptyYAML(content string, node *yaml.Node) (string, error) {
+	text, err := encodeYAML(node)
+	if err != nil {
+		return "", err
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	return content + text, nil
+}
+
+// isBlockCollection reports whether node is a non-empty block-style mapping
+// or sequence, which new lines can be inserted into.
+func isBlockCollection(node *yaml.Node) bool {
+	return node.Style&yaml.FlowStyle == 0 && len(node.Content) > 0
+}
+
+// yamlPathNode returns a mapping that nests segs down to the first "[]"
+// segment, whose sequence holds items.
+func yamlPathNode(segs []string, items []*yaml.Node) *yaml.Node {
+	key, isSeq := strings.CutSuffix(segs[0], "[]")
+
+	value := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: items}
+	if !isSeq {
+		value = yamlPathNode(segs[1:], items)
+	}
+
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	}}
+}
+
+// spliceYAML encodes node, indents it by indent spaces and inserts it into
+// content after the last line before line end (1-based, 0 for the end of
+// the file) that is neither blank nor a comment. Comments and blank lines
+// that close the region stay below the new lines.
+func spliceYAML(content string, end, indent int, node *yaml.Node) (string, error) {
+	text, err := encodeYAML(node)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+
+	at := len(lines)
+	if end > 0 && end <= len(lines) {
+		at = end - 1
+	}
+
+	for at > 0 && isBlankOrComment(lines[at-1]) {
+		at--
+	}
+
+	var sb strings.Builder
+
+	for _, line := range lines[:at] {
+		sb.WriteString(line)
+	}
+
+	if at > 0 && !strings.HasSuffix(lines[at-1], "\n") {
+		sb.WriteString("\n")
+	}
+
+	pad := strings.Repeat(" ", indent)
+	for line := range strings.Lines(text) {
+		sb.WriteString(pad + line)
+	}
+
+	for _, line := range lines[at:] {
+		sb.WriteString(line)
+	}
+
+	return sb.String(), nil
+}
+
+func isBlankOrComment(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.HasPrefix(line, "#")
+}
+
+// encodeYAMLDocument encodes a whole document. The encoder drops the
+// document start marker, so it is written back if content had one.
+func encodeYAMLDocument(content string, doc *yaml.Node) (string, error) {
+	out, err := encodeYAML(doc)
+	if err != nil {
+		return "", err
+	}
+
+	for line := range strings.Lines(content) {
+		if strings.TrimRight(line, " \r\n") == "---" {
+			return "---\n" + out, nil
+		}
+
+		if !isBlankOrComment(line) {
+			break
+		}
+	}
+
+	return out, nil
+}
+
+func encodeYAML(node *yaml.Node) (string, error) {
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(node); err != nil {
+		return "", fmt.Errorf("encoding YAML: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("encoding YAML: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// mappingIndex returns the index in node.Content of the key named key, or
+// -1.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
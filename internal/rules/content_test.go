@@ -0,0 +1,243 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAssertions_Pattern(t *testing.T) {
+	t.Parallel()
+
+	assertions := []Assertion{
+		{Description: "ignores .env", Pattern: `^\.env$`, Fix: ".env"},
+		{Description: "ignores node_modules", Pattern: `^node_modules/?$`},
+	}
+
+	result, err := CheckAssertions("bin/\n.envrc", assertions)
+	if err != nil {
+		t.Fatalf("CheckAssertions: %v", err)
+	}
+
+	if len(result.Failed) != 2 {
+		t.Fatalf("expected 2 failed assertions, got %d", len(result.Failed))
+	}
+
+	if result.Content != "bin/\n.envrc\n.env\n" {
+		t.Errorf("unexpected patched content %q", result.Content)
+	}
+
+	if !result.Patched("bin/\n.envrc") {
+		t.Error("expected content to be patched")
+	}
+
+	result, err = CheckAssertions(".env\nnode_modules/\n", assertions)
+	if err != nil {
+		t.Fatalf("CheckAssertions: %v", err)
+	}
+
+	if len(result.Failed) != 0 {
+		t.Errorf("expected all assertions to pass, got %v", result.Failed)
+	}
+}
+
+func TestCheckAssertions_YAML(t *testing.T) {
+	t.Parallel()
+
+	assertion := Assertion{
+		Description: "github-actions ecosystem",
+		YAMLPath:    "updates[].package-ecosystem",
+		Equals:      "github-actions",
+		Fix:         "package-ecosystem: \"github-actions\"\ndirectory: \"/\"\nschedule:\n  interval: \"weekly\"\n",
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		wantFailed bool
+		wantCount  int
+	}{
+		{
+			name:      "present",
+			content:   "version: 2\nupdates:\n  - package-ecosystem: \"github-actions\"\n    directory: \"/\"\n",
+			wantCount: 1,
+		},
+		{
+			name:       "other ecosystem",
+			content:    "version: 2\nupdates:\n  - package-ecosystem: \"gomod\"\n    directory: \"/\"\n",
+			wantFailed: true,
+			wantCount:  2,
+		},
+		{
+			name:       "no updates key",
+			content:    "version: 2\n",
+			wantFailed: true,
+			wantCount:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := CheckAssertions(tt.content, []Assertion{assertion})
+			if err != nil {
+				t.Fatalf("CheckAssertions: %v", err)
+			}
+
+			if (len(result.Failed) > 0) != tt.wantFailed {
+				t.Fatalf("failed = %v, want failed %v", result.Failed, tt.wantFailed)
+			}
+
+			// The patched document must satisfy the assertion.
+			again, err := CheckAssertions(result.Content, []Assertion{assertion})
+			if err != nil {
+				t.Fatalf("CheckAssertions(patched): %v", err)
+			}
+
+			if len(again.Failed) != 0 {
+				t.Errorf("patched content still fails:\n%s", result.Content)
+			}
+
+			if got := strings.Count(result.Content, "package-ecosystem"); got != tt.wantCount {
+				t.Errorf("expected %d ecosystems, got %d:\n%s", tt.wantCount, got, result.Content)
+			}
+
+			if !strings.Contains(result.Content, "version: 2") {
+				t.Errorf("patched content lost existing keys:\n%s", result.Content)
+			}
+		})
+	}
+}
+
+func TestAppendYAML_KeepsUntouchedLines(t *testing.T) {
+	t.Parallel()
+
+	const fix = "package-ecosystem: \"github-actions\"\ndirectory: \"/\"\n"
+
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		want    string
+	}{
+		{
+			name: "existing sequence",
+			content: "# Dependabot config\n---\n\nversion: 2\nupdates:\n" +
+				"  - package-ecosystem: 'gomod'\n    directory:   \"/\"\n\n" +
+				"# Private registries\nregistries: {}\n",
+			path: "updates[]",
+			want: "# Dependabot config\n---\n\nversion: 2\nupdates:\n" +
+				"  - package-ecosystem: 'gomod'\n    directory:   \"/\"\n" +
+				"  - package-ecosystem: \"github-actions\"\n    directory: \"/\"\n\n" +
+				"# Private registries\nregistries: {}\n",
+		},
+		{
+			name:    "sequence at the end of the file",
+			content: "---\nupdates:\n- package-ecosystem: gomod\n  directory: /\n# end\n",
+			path:    "updates[]",
+			want: "---\nupdates:\n- package-ecosystem: gomod\n  directory: /\n" +
+				"- package-ecosystem: \"github-actions\"\n  directory: \"/\"\n# end\n",
+		},
+		{
+			name:    "missing nested key",
+			content: "config:\n  name:   'x'\n\nother: true\n",
+			path:    "config.updates[]",
+			want: "config:\n  name:   'x'\n" +
+				"  updates:\n    - package-ecosystem: \"github-actions\"\n      directory: \"/\"\n\nother: true\n",
+		},
+		{
+			name:    "missing key without trailing newline",
+			content: "version: 2",
+			path:    "updates[]",
+			want:    "version: 2\nupdates:\n  - package-ecosystem: \"github-actions\"\n    directory: \"/\"\n",
+		},
+		{
+			name:    "flow sequence keeps the document start",
+			content: "---\nversion: 2\nupdates: []\n",
+			path:    "updates[]",
+			want:    "---\nversion: 2\nupdates: [{package-ecosystem: \"github-actions\", directory: \"/\"}]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := appendYAML(tt.content, tt.path, fix)
+			if err != nil {
+				t.Fatalf("appendYAML: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("appendYAML =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAssertions_InvalidYAML(t *testing.T) {
+	t.Parallel()
+
+	assertions := []Assertion{
+		{Description: "x", YAMLPath: "a[]", Equals: "b", Fix: "b"},
+		{Description: "trailer", Pattern: `^# end$`, Fix: "# end"},
+	}
+
+	result, err := CheckAssertions("a: [unclosed", assertions)
+	if err != nil {
+		t.Fatalf("CheckAssertions: %v", err)
+	}
+
+	if result.Invalid == nil {
+		t.Error("expected Invalid to report the YAML error")
+	}
+
+	if len(result.Failed) != 2 || result.Failed[0].Fix != "" {
+		t.Errorf("expected both assertions to fail, the YAML one without a fix, got %+v", result.Failed)
+	}
+
+	// The other assertions are still evaluated and fixed.
+	if !strings.HasSuffix(result.Content, "# end\n") {
+		t.Errorf("expected the pattern fix to be applied, got:\n%s", result.Content)
+	}
+}
+
+func TestAssertionValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		assertion Assertion
+		wantErr   string
+	}{
+		{name: "valid pattern", assertion: Assertion{Description: "d", Pattern: `^\*\s`}},
+		{name: "valid yaml", assertion: Assertion{Description: "d", YAMLPath: "updates[].x", Equals: "y", Fix: "x: y"}},
+		{name: "no description", assertion: Assertion{Pattern: "a"}, wantErr: "description"},
+		{name: "neither", assertion: Assertion{Description: "d"}, wantErr: "one of pattern or yamlPath"},
+		{name: "both", assertion: Assertion{Description: "d", Pattern: "a", YAMLPath: "b", Equals: "c"}, wantErr: "only one"},
+		{name: "bad regex", assertion: Assertion{Description: "d", Pattern: "("}, wantErr: "pattern"},
+		{name: "no equals", assertion: Assertion{Description: "d", YAMLPath: "a"}, wantErr: "equals"},
+		{name: "empty segment", assertion: Assertion{Description: "d", YAMLPath: "a..b", Equals: "c"}, wantErr: "empty segment"},
+		{name: "fix without sequence", assertion: Assertion{Description: "d", YAMLPath: "a.b", Equals: "c", Fix: "x"}, wantErr: "[]"},
+		{name: "invalid fix", assertion: Assertion{Description: "d", YAMLPath: "a[]", Equals: "c", Fix: "[x"}, wantErr: "not valid YAML"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.assertion.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v should contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
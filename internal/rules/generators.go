@@ -0,0 +1,179 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Generator produces file content from repository context instead of a
+// static template. Returning an empty string means the generator has
+// nothing to contribute and the rule's DefaultTemplateName is rendered
+// instead.
+type Generator func(data *TemplateData) (string, error)
+
+// generators maps the names usable in FileRule.Generator to their
+// implementations.
+var generators = map[string]Generator{
+	"dependabot": GenerateDependabot,
+}
+
+// GeneratorByName returns the named generator and true, or nil and false
+// if no generator is registered under that name.
+func GeneratorByName(name string) (Generator, bool) {
+	gen, ok := generators[name]
+	return gen, ok
+}
+
+// RenderRule produces the content for rule's TargetPath. If the rule names a
+// Generator that returns content, that content is used; otherwise the rule's
+// DefaultTemplateName is rendered.
+func (ts *TemplateStore) RenderRule(rule *FileRule, data *TemplateData) (string, error) {
+	if rule.Generator != "" {
+		gen, ok := GeneratorByName(rule.Generator)
+		if !ok {
+			return "", fmt.Errorf("generator %q not found", rule.Generator)
+		}
+
+		content, err := gen(data)
+		if err != nil {
+			return "", fmt.Errorf("running generator %s: %w", rule.Generator, err)
+		}
+
+		if content != "" {
+			return content, nil
+		}
+	}
+
+	return ts.Render(rule.DefaultTemplateName, data)
+}
+
+// dependabotEcosystem describes how to detect a Dependabot package ecosystem
+// from a file path in the repository tree.
+type dependabotEcosystem struct {
+	name  string
+	match func(file string) bool
+}
+
+// dependabotEcosystems lists the supported ecosystems in the order they
+// appear in generated files.
+var dependabotEcosystems = []dependabotEcosystem{
+	{name: "github-actions", match: isWorkflowFile},
+	{name: "gomod", match: baseNameIn("go.mod")},
+	{name: "npm", match: baseNameIn("package.json")},
+	{name: "pip", match: baseNameIn("requirements.txt", "pyproject.toml", "Pipfile", "setup.py")},
+	{name: "maven", match: baseNameIn("pom.xml")},
+	{name: "docker", match: isDockerfile},
+	{name: "terraform", match: func(file string) bool { return strings.HasSuffix(file, ".tf") }},
+}
+
+// ignoredDirs are path segments whose contents are never scanned for
+// manifests (vendored or installed dependencies).
+var ignoredDirs = []string{"node_modules", "vendor", ".terraform"}
+
+// GenerateDependabot builds a dependabot.yml containing an `updates` entry
+// for each ecosystem detected in data.Files, one per directory. It returns
+// an empty string when no ecosystem is detected.
+func GenerateDependabot(data *TemplateData) (string, error) {
+	dirs := make(map[string]map[string]bool, len(dependabotEcosystems))
+
+	for _, file := range data.Files {
+		if isIgnored(file) {
+			continue
+		}
+
+		for _, eco := range dependabotEcosystems {
+			if !eco.match(file) {
+				continue
+			}
+
+			if dirs[eco.name] == nil {
+				dirs[eco.name] = make(map[string]bool)
+			}
+
+			dirs[eco.name][ecosystemDir(eco.name, file)] = true
+		}
+	}
+
+	if len(dirs) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("version: 2\n")
+	sb.WriteString("updates:\n")
+
+	for _, eco := range dependabotEcosystems {
+		ecoDirs := make([]string, 0, len(dirs[eco.name]))
+		for dir := range dirs[eco.name] {
+			ecoDirs = append(ecoDirs, dir)
+		}
+
+		sort.Strings(ecoDirs)
+
+		for _, dir := range ecoDirs {
+			fmt.Fprintf(&sb, "  - package-ecosystem: %q\n", eco.name)
+			fmt.Fprintf(&sb, "    directory: %q\n", dir)
+			sb.WriteString("    schedule:\n")
+			sb.WriteString("      interval: \"weekly\"\n")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// ecosystemDir returns the Dependabot `directory` value for a manifest.
+// Workflows are always configured at the repository root.
+func ecosystemDir(ecosystem, file string) string {
+	if ecosystem == "github-actions" {
+		return "/"
+	}
+
+	dir := path.Dir(file)
+	if dir == "." {
+		return "/"
+	}
+
+	return "/" + dir
+}
+
+func baseNameIn(names ...string) func(string) bool {
+	return func(file string) bool {
+		base := path.Base(file)
+		for _, name := range names {
+			if base == name {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func isWorkflowFile(file string) bool {
+	if path.Dir(file) != ".github/workflows" {
+		return false
+	}
+
+	return strings.HasSuffix(file, ".yml") || strings.HasSuffix(file, ".yaml")
+}
+
+func isDockerfile(file string) bool {
+	base := path.Base(file)
+
+	return base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile.") || strings.HasSuffix(base, ".Dockerfile")
+}
+
+func isIgnored(file string) bool {
+	for _, segment := range strings.Split(path.Dir(file), "/") {
+		for _, ignored := range ignoredDirs {
+			if segment == ignored {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,170 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateDependabot(t *testing.T) {
+	t.Parallel()
+
+	files := []string{
+		".github/workflows/ci.yml",
+		".github/workflows/release.yaml",
+		"go.mod",
+		"main.go",
+		"tools/go.mod",
+		"web/package.json",
+		"web/node_modules/left-pad/package.json",
+		"vendor/github.com/x/y/go.mod",
+		"Dockerfile",
+		"build/api.Dockerfile",
+		"build/Dockerfile.worker",
+		"scripts/requirements.txt",
+		"scripts/pyproject.toml",
+		"java/pom.xml",
+		"infra/main.tf",
+		"infra/variables.tf",
+		"infra/.terraform/modules/vpc/main.tf",
+	}
+
+	out, err := GenerateDependabot(&TemplateData{Files: files})
+	if err != nil {
+		t.Fatalf("GenerateDependabot: %v", err)
+	}
+
+	want := `version: 2
+updates:
+  - package-ecosystem: "github-actions"
+    directory: "/"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "gomod"
+    directory: "/"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "gomod"
+    directory: "/tools"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "npm"
+    directory: "/web"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "pip"
+    directory: "/scripts"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "maven"
+    directory: "/java"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "docker"
+    directory: "/"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "docker"
+    directory: "/build"
+    schedule:
+      interval: "weekly"
+  - package-ecosystem: "terraform"
+    directory: "/infra"
+    schedule:
+      interval: "weekly"
+`
+
+	if out != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestEcosystemDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		ecosystem string
+		file      string
+		want      string
+	}{
+		{name: "root file", ecosystem: "gomod", file: "go.mod", want: "/"},
+		{name: "dot directory", ecosystem: "docker", file: ".devcontainer/Dockerfile", want: "/.devcontainer"},
+		{name: "nested file", ecosystem: "npm", file: "apps/web/package.json", want: "/apps/web"},
+		{name: "workflow", ecosystem: "github-actions", file: ".github/workflows/ci.yml", want: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ecosystemDir(tt.ecosystem, tt.file); got != tt.want {
+				t.Errorf("ecosystemDir(%q, %q) = %q, want %q", tt.ecosystem, tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateDependabot_NoManifests(t *testing.T) {
+	t.Parallel()
+
+	out, err := GenerateDependabot(&TemplateData{Files: []string{"README.md", "docs/index.md"}})
+	if err != nil {
+		t.Fatalf("GenerateDependabot: %v", err)
+	}
+
+	if out != "" {
+		t.Errorf("expected empty output, got:\n%s", out)
+	}
+}
+
+func TestRenderRule(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	rule := &FileRule{Name: "Dependabot", DefaultTemplateName: "dependabot", Generator: "dependabot"}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "generated", files: []string{"go.mod"}, want: `package-ecosystem: "gomod"`},
+		{name: "template fallback", files: []string{"README.md"}, want: `package-ecosystem: "github-actions"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := &TemplateData{Files: tt.files}
+
+			out, err := ts.RenderRule(rule, data)
+			if err != nil {
+				t.Fatalf("RenderRule: %v", err)
+			}
+
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output should contain %q, got:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestRenderRule_UnknownGenerator(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	rule := &FileRule{Name: "X", DefaultTemplateName: "dependabot", Generator: "nope"}
+
+	if _, err := ts.RenderRule(rule, &TemplateData{}); err == nil {
+		t.Error("expected error for unknown generator")
+	}
+}
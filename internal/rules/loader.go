@@ -0,0 +1,247 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesDocument is the on-disk schema of a rules file. JSON documents are
+// accepted as well since JSON is a subset of YAML.
+type rulesDocument struct {
+	Rules []ruleSpec `yaml:"rules"`
+}
+
+// ruleSpec is the on-disk representation of a single FileRule. Enabled is a
+// pointer so that an omitted field can default to true.
+type ruleSpec struct {
+	Name                string          `yaml:"name"`
+	Paths               []string        `yaml:"paths"`
+	PRSearchTerms       []string        `yaml:"prSearchTerms"`
+	DefaultTemplateName string          `yaml:"defaultTemplateName"`
+	TargetPath          string          `yaml:"targetPath"`
+	Generator           string          `yaml:"generator"`
+	Assertions          []assertionSpec `yaml:"assertions"`
+	Managed             bool            `yaml:"managed"`
+	Strict              bool            `yaml:"strict"`
+	Enabled             *bool           `yaml:"enabled"`
+}
+
+// assertionSpec is the on-disk representation of an Assertion.
+type assertionSpec struct {
+	Description string `yaml:"description"`
+	Pattern     string `yaml:"pattern"`
+	YAMLPath    string `yaml:"yamlPath"`
+	Equals      string `yaml:"equals"`
+	Fix         string `yaml:"fix"`
+}
+
+// LoadRules reads FileRules from the YAML or JSON document at path. If path
+// is empty or the file does not exist, a copy of DefaultRules is returned,
+// mirroring how TemplateStore.Load falls back to the embedded templates.
+func LoadRules(path string) ([]FileRule, error) {
+	if path == "" {
+		return defaultRulesCopy(), nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return defaultRulesCopy(), nil
+		}
+
+		return nil, fmt.Errorf("reading rules file %s: %w", path, err)
+	}
+
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing rules file %s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+// ParseRules decodes and validates a rules document. Unknown fields are
+// rejected so that typos surface at startup rather than being ignored.
+func ParseRules(data []byte) ([]FileRule, error) {
+	var doc rulesDocument
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("rules document is empty")
+		}
+
+		return nil, err
+	}
+
+	if len(doc.Rules) == 0 {
+		return nil, errors.New("rules document must define at least one rule")
+	}
+
+	result := make([]FileRule, 0, len(doc.Rules))
+	for _, spec := range doc.Rules {
+		enabled := true
+		if spec.Enabled != nil {
+			enabled = *spec.Enabled
+		}
+
+		result = append(result, FileRule{
+			Name:                spec.Name,
+			Paths:               spec.Paths,
+			PRSearchTerms:       spec.PRSearchTerms,
+			DefaultTemplateName: spec.DefaultTemplateName,
+			TargetPath:          spec.TargetPath,
+			Generator:           spec.Generator,
+			Assertions:          assertionsFromSpecs(spec.Assertions),
+			Managed:             spec.Managed,
+			Strict:              spec.Strict,
+			Enabled:             enabled,
+		})
+	}
+
+	if err := ValidateRules(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ValidateRules checks that every rule is well formed and that rule names
+// are unique (case-insensitive, matching RuleByName).
+func ValidateRules(rr []FileRule) error {
+	var errs []error
+
+	seen := make(map[string]bool, len(rr))
+
+	for i := range rr {
+		rule := &rr[i]
+
+		label := rule.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %s: name is required", label))
+		} else {
+			key := strings.ToLower(rule.Name)
+			if seen[key] {
+				errs = append(errs, fmt.Errorf("rule %s: duplicate rule name", label))
+			}
+
+			seen[key] = true
+		}
+
+		if len(rule.Paths) == 0 {
+			errs = append(errs, fmt.Errorf("rule %s: at least one path is required", label))
+		}
+
+		for _, p := range rule.Paths {
+			if err := validateRepoPath(p); err != nil {
+				errs = append(errs, fmt.Errorf("rule %s: path %q: %w", label, p, err))
+			}
+		}
+
+		if rule.DefaultTemplateName == "" {
+			errs = append(errs, fmt.Errorf("rule %s: defaultTemplateName is required", label))
+		}
+
+		if rule.TargetPath == "" {
+			errs = append(errs, fmt.Errorf("rule %s: targetPath is required", label))
+		} else if err := validateRepoPath(rule.TargetPath); err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: targetPath %q: %w", label, rule.TargetPath, err))
+		}
+
+		errs = append(errs, validateRuleContent(label, rule)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateRuleContent checks the fields that control how file content is
+// produced and verified.
+func validateRuleContent(label string, rule *FileRule) []error {
+	var errs []error
+
+	if rule.Generator != "" {
+		if _, ok := GeneratorByName(rule.Generator); !ok {
+			errs = append(errs, fmt.Errorf("rule %s: unknown generator %q", label, rule.Generator))
+		}
+	}
+
+	for i := range rule.Assertions {
+		if err := rule.Assertions[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: assertion #%d: %w", label, i+1, err))
+		}
+	}
+
+	if rule.Strict && !rule.Managed {
+		errs = append(errs, fmt.Errorf("rule %s: strict requires managed", label))
+	}
+
+	if rule.Managed && rule.TargetPath != "" && !SupportsManagedMarker(rule.TargetPath) {
+		errs = append(errs, fmt.Errorf("rule %s: managed files need a comment syntax, %s has none", label, rule.TargetPath))
+	}
+
+	return errs
+}
+
+// ValidateTemplates checks that every rule in the registry references a
+// template that exists in the given store.
+func (r *Registry) ValidateTemplates(ts *TemplateStore) error {
+	var errs []error
+
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if _, err := ts.Get(rule.DefaultTemplateName); err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", rule.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateRepoPath rejects paths that cannot be looked up via the contents API.
+func validateRepoPath(p string) error {
+	switch {
+	case p == "":
+		return errors.New("must not be empty")
+	case strings.HasPrefix(p, "/"):
+		return errors.New("must be relative to the repository root")
+	case strings.ContainsAny(p, "*?["):
+		return errors.New("glob patterns are not supported")
+	case strings.Contains(p, ".."):
+		return errors.New("must not contain '..'")
+	default:
+		return nil
+	}
+}
+
+func assertionsFromSpecs(specs []assertionSpec) []Assertion {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	result := make([]Assertion, len(specs))
+	for i, spec := range specs {
+		result[i] = Assertion(spec)
+	}
+
+	return result
+}
+
+func defaultRulesCopy() []FileRule {
+	result := make([]FileRule, len(DefaultRules))
+	copy(result, DefaultRules)
+
+	return result
+}
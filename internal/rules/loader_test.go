@@ -0,0 +1,187 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validRulesYAML = `
+rules:
+  - name: CODEOWNERS
+    paths: [CODEOWNERS, .github/CODEOWNERS]
+    prSearchTerms: [codeowners]
+    defaultTemplateName: codeowners
+    targetPath: .github/CODEOWNERS
+    assertions:
+      - description: catch-all owner
+        pattern: '^\*\s+@'
+  - name: Renovate
+    paths: [renovate.json]
+    prSearchTerms: [renovate]
+    defaultTemplateName: renovate
+    targetPath: renovate.json
+    enabled: false
+`
+
+func TestLoadRules_FromFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(validRulesYAML), 0o644); err != nil {
+		t.Fatalf("writing rules file: %v", err)
+	}
+
+	rr, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("LoadRules: %v", err)
+	}
+
+	if len(rr) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rr))
+	}
+
+	if !rr[0].Enabled {
+		t.Error("enabled should default to true when omitted")
+	}
+
+	if rr[1].Enabled {
+		t.Error("expected Renovate to be disabled")
+	}
+
+	if rr[0].TargetPath != ".github/CODEOWNERS" {
+		t.Errorf("TargetPath = %q, want .github/CODEOWNERS", rr[0].TargetPath)
+	}
+
+	if len(rr[0].Assertions) != 1 || rr[0].Assertions[0].Pattern != `^\*\s+@` {
+		t.Errorf("unexpected assertions %+v", rr[0].Assertions)
+	}
+}
+
+func TestLoadRules_JSON(t *testing.T) {
+	t.Parallel()
+
+	doc := `{"rules": [{"name": "Dependabot", "paths": [".github/dependabot.yml"],
+		"defaultTemplateName": "dependabot", "targetPath": ".github/dependabot.yml"}]}`
+
+	rr, err := ParseRules([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+
+	if len(rr) != 1 || rr[0].Name != "Dependabot" {
+		t.Errorf("unexpected rules: %+v", rr)
+	}
+}
+
+func TestLoadRules_Fallback(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{"", "/nonexistent/rules.yaml"} {
+		rr, err := LoadRules(path)
+		if err != nil {
+			t.Fatalf("LoadRules(%q): %v", path, err)
+		}
+
+		if len(rr) != len(DefaultRules) {
+			t.Errorf("LoadRules(%q) returned %d rules, want %d", path, len(rr), len(DefaultRules))
+		}
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "empty", doc: "", wantErr: "empty"},
+		{name: "no rules", doc: "rules: []", wantErr: "at least one rule"},
+		{name: "unknown field", doc: "rules:\n  - name: x\n    path: [a]\n", wantErr: "path"},
+		{
+			name:    "missing fields",
+			doc:     "rules:\n  - name: x\n",
+			wantErr: "at least one path is required",
+		},
+		{
+			name: "duplicate name",
+			doc: "rules:\n" +
+				"  - {name: A, paths: [a], defaultTemplateName: t, targetPath: a}\n" +
+				"  - {name: a, paths: [b], defaultTemplateName: t, targetPath: b}\n",
+			wantErr: "duplicate rule name",
+		},
+		{
+			name:    "absolute path",
+			doc:     "rules:\n  - {name: A, paths: [/a], defaultTemplateName: t, targetPath: a}\n",
+			wantErr: "relative",
+		},
+		{
+			name:    "glob path",
+			doc:     "rules:\n  - {name: A, paths: [a], defaultTemplateName: t, targetPath: '*.md'}\n",
+			wantErr: "glob",
+		},
+		{
+			name:    "unknown generator",
+			doc:     "rules:\n  - {name: A, paths: [a], defaultTemplateName: t, targetPath: a, generator: nope}\n",
+			wantErr: "unknown generator",
+		},
+		{
+			name: "invalid assertion",
+			doc: "rules:\n  - name: A\n    paths: [a]\n    defaultTemplateName: t\n    targetPath: a\n" +
+				"    assertions:\n      - {description: d, pattern: \"(\"}\n",
+			wantErr: "assertion #1",
+		},
+		{
+			name:    "strict without managed",
+			doc:     "rules:\n  - {name: A, paths: [a], defaultTemplateName: t, targetPath: a, strict: true}\n",
+			wantErr: "strict requires managed",
+		},
+		{
+			name:    "managed json",
+			doc:     "rules:\n  - {name: A, paths: [a.json], defaultTemplateName: t, targetPath: a.json, managed: true}\n",
+			wantErr: "comment syntax",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseRules([]byte(tt.doc))
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q should contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if err := NewRegistry(DefaultRules).ValidateTemplates(ts); err != nil {
+		t.Errorf("default rules should validate: %v", err)
+	}
+
+	reg := NewRegistry([]FileRule{{Name: "Missing", DefaultTemplateName: "does-not-exist"}})
+
+	err := reg.ValidateTemplates(ts)
+	if err == nil {
+		t.Fatal("expected error for missing template")
+	}
+
+	if !strings.Contains(err.Error(), "Missing") {
+		t.Errorf("error should name the rule: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// managedMarkerPattern extracts the content hash from a managed-file marker.
+var managedMarkerPattern = regexp.MustCompile(`repo-guardian:managed sha256=([0-9a-f]{16})`)
+
+// ManagedFile is the parsed form of a file written by repo-guardian for a
+// managed rule.
+type ManagedFile struct {
+	// Body is the file content without the marker line.
+	Body string
+
+	// Hash is the content hash recorded in the marker when the file was
+	// written. Empty when the file has no marker.
+	Hash string
+}
+
+// Edited reports whether the body no longer matches the recorded hash,
+// i.e. someone changed the file after repo-guardian wrote it. Files without
+// a marker are always considered edited.
+func (m *ManagedFile) Edited() bool {
+	return m.Hash == "" || m.Hash != ContentHash(m.Body)
+}
+
+// ContentHash returns the short hash recorded in managed-file markers.
+func ContentHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// SupportsManagedMarker reports whether a marker comment can be written to
+// a file at filePath. JSON has no comment syntax.
+func SupportsManagedMarker(filePath string) bool {
+	_, _, ok := commentSyntax(filePath)
+	return ok
+}
+
+// StampManaged prepends a marker comment recording the hash of content. A
+// leading shebang or YAML document start ("---") stays on the first line,
+// with the marker after it. If the file type has no comment syntax, content
+// is returned unchanged.
+func StampManaged(filePath, content string) string {
+	open, closing, ok := commentSyntax(filePath)
+	if !ok {
+		return content
+	}
+
+	marker := fmt.Sprintf("%s repo-guardian:managed sha256=%s (local edits stop automatic updates)%s",
+		open, ContentHash(content), closing)
+
+	first, rest, found := strings.Cut(content, "\n")
+	if !mustStayFirst(first) {
+		return marker + "\n" + content
+	}
+
+	if !found {
+		return first + "\n" + marker
+	}
+
+	return first + "\n" + marker + "\n" + rest
+}
+
+// ParseManaged splits a file into its marker hash and body. A marker is only
+// recognized on the first line, or on the second after a shebang or YAML
+// document start.
+func ParseManaged(content string) *ManagedFile {
+	first, rest, found := strings.Cut(content, "\n")
+
+	if m := managedMarkerPattern.FindStringSubmatch(first); m != nil {
+		return &ManagedFile{Body: rest, Hash: m[1]}
+	}
+
+	if !found || !mustStayFirst(first) {
+		return &ManagedFile{Body: content}
+	}
+
+	second, rest, found := strings.Cut(rest, "\n")
+
+	m := managedMarkerPattern.FindStringSubmatch(second)
+	if m == nil {
+		return &ManagedFile{Body: content}
+	}
+
+	if !found {
+		return &ManagedFile{Body: first, Hash: m[1]}
+	}
+
+	return &ManagedFile{Body: first + "\n" + rest, Hash: m[1]}
+}
+
+// mustStayFirst reports whether line only works as the first line of a
+// file: a shebang or a YAML document start.
+func mustStayFirst(line string) bool {
+	return strings.HasPrefix(line, "#!") || strings.TrimRight(line, " \r") == "---"
+}
+
+// commentSyntax returns the line-comment delimiters for a file type.
+func commentSyntax(filePath string) (string, string, bool) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".json":
+		return "", "", false
+	case ".json5", ".js", ".ts", ".go":
+		return "//", "", true
+	case ".md", ".html", ".xml":
+		return "<!--", " -->", true
+	default:
+		return "#", "", true
+	}
+}
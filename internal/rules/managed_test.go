@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStampAndParseManaged(t *testing.T) {
+	t.Parallel()
+
+	body := "version: 2\nupdates: []\n"
+	stamped := StampManaged(".github/dependabot.yml", body)
+
+	if !strings.HasPrefix(stamped, "# repo-guardian:managed sha256=") {
+		t.Fatalf("expected marker on the first line, got:\n%s", stamped)
+	}
+
+	parsed := ParseManaged(stamped)
+	if parsed.Body != body {
+		t.Errorf("Body = %q, want %q", parsed.Body, body)
+	}
+
+	if parsed.Edited() {
+		t.Error("freshly stamped file should not be considered edited")
+	}
+
+	edited := ParseManaged(stamped + "  - package-ecosystem: npm\n")
+	if !edited.Edited() {
+		t.Error("changed body should be considered edited")
+	}
+
+	unmarked := ParseManaged(body)
+	if unmarked.Hash != "" || !unmarked.Edited() {
+		t.Error("file without marker should be considered edited")
+	}
+}
+
+func TestStampManaged_CommentSyntax(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path       string
+		wantPrefix string
+	}{
+		{path: ".github/CODEOWNERS", wantPrefix: "# repo-guardian:managed"},
+		{path: "docs/README.md", wantPrefix: "<!-- repo-guardian:managed"},
+		{path: "renovate.json5", wantPrefix: "// repo-guardian:managed"},
+		{path: "renovate.json", wantPrefix: "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			out := StampManaged(tt.path, "{}")
+			if !strings.HasPrefix(out, tt.wantPrefix) {
+				t.Errorf("StampManaged(%s) = %q, want prefix %q", tt.path, out, tt.wantPrefix)
+			}
+
+			if got := ParseManaged(out).Body; got != "{}" {
+				t.Errorf("round-trip body = %q", got)
+			}
+		})
+	}
+
+	if SupportsManagedMarker("renovate.json") {
+		t.Error("JSON should not support managed markers")
+	}
+}
+
+func TestStampManaged_LeadingLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		path      string
+		body      string
+		wantFirst string
+	}{
+		{
+			name:      "shebang",
+			path:      "scripts/setup.sh",
+			body:      "#!/usr/bin/env bash\nset -euo pipefail\n",
+			wantFirst: "#!/usr/bin/env bash",
+		},
+		{
+			name:      "YAML document start",
+			path:      ".github/dependabot.yml",
+			body:      "---\nversion: 2\n",
+			wantFirst: "---",
+		},
+		{
+			name:      "shebang only",
+			path:      "scripts/noop.sh",
+			body:      "#!/bin/sh",
+			wantFirst: "#!/bin/sh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamped := StampManaged(tt.path, tt.body)
+
+			lines := strings.SplitN(stamped, "\n", 3)
+			if len(lines) < 2 || lines[0] != tt.wantFirst || !strings.HasPrefix(lines[1], "# repo-guardian:managed") {
+				t.Fatalf("expected %q then the marker, got:\n%s", tt.wantFirst, stamped)
+			}
+
+			parsed := ParseManaged(stamped)
+			if parsed.Body != tt.body {
+				t.Errorf("Body = %q, want %q", parsed.Body, tt.body)
+			}
+
+			if parsed.Edited() {
+				t.Error("freshly stamped file should not be considered edited")
+			}
+		})
+	}
+}
+
+func TestParseManaged_LeadingLineWithoutMarker(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{
+		"#!/bin/sh\necho hi\n",
+		"---\nversion: 2\n",
+		"---",
+	} {
+		parsed := ParseManaged(content)
+		if parsed.Hash != "" || parsed.Body != content {
+			t.Errorf("ParseManaged(%q) = %+v, want no marker", content, parsed)
+		}
+	}
+}
@@ -4,10 +4,13 @@ package rules
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/template"
 )
 
 //go:embed templates/*.tmpl
@@ -32,6 +35,25 @@ type FileRule struct {
 	// TargetPath is where the default file will be created if missing.
 	TargetPath string
 
+	// Generator optionally names a content generator (see GeneratorByName)
+	// that builds the file from the repository's contents. When it produces
+	// no output, DefaultTemplateName is rendered instead.
+	Generator string
+
+	// Assertions are content requirements checked when the file already
+	// exists. Failed assertions with a Fix are proposed as a patch to the
+	// existing file.
+	Assertions []Assertion
+
+	// Managed marks files that repo-guardian owns. Files it creates carry a
+	// marker with a content hash, and when the rendered content changes an
+	// update PR is opened, unless someone has edited the file since.
+	Managed bool
+
+	// Strict enforces a managed file even after human edits or when it was
+	// not created by repo-guardian. Requires Managed.
+	Strict bool
+
 	// Enabled allows rules to be toggled without removal.
 	Enabled bool
 }
@@ -53,6 +75,7 @@ var DefaultRules = []FileRule{
 		PRSearchTerms:       []string{"dependabot"},
 		DefaultTemplateName: "dependabot",
 		TargetPath:          ".github/dependabot.yml",
+		Generator:           "dependabot",
 		Enabled:             true,
 	},
 	{
@@ -86,9 +109,9 @@ func NewRegistry(rules []FileRule) *Registry {
 func (r *Registry) EnabledRules() []FileRule {
 	var enabled []FileRule
 
-	for _, rule := range r.rules {
-		if rule.Enabled {
-			enabled = append(enabled, rule)
+	for i := range r.rules {
+		if r.rules[i].Enabled {
+			enabled = append(enabled, r.rules[i])
 		}
 	}
 
@@ -98,9 +121,9 @@ func (r *Registry) EnabledRules() []FileRule {
 // RuleByName returns the rule with the given name and true,
 // or a zero FileRule and false if not found.
 func (r *Registry) RuleByName(name string) (FileRule, bool) {
-	for _, rule := range r.rules {
-		if strings.EqualFold(rule.Name, name) {
-			return rule, true
+	for i := range r.rules {
+		if strings.EqualFold(r.rules[i].Name, name) {
+			return r.rules[i], true
 		}
 	}
 
@@ -117,19 +140,24 @@ func (r *Registry) AllRules() []FileRule {
 
 // TemplateStore loads and serves file templates, using embedded
 // defaults as fallbacks when a directory override is not available.
+// Templates are Go text/templates rendered with TemplateData.
 type TemplateStore struct {
 	templates map[string]string
+	parsed    map[string]*template.Template
 }
 
 // NewTemplateStore creates an empty TemplateStore.
 func NewTemplateStore() *TemplateStore {
 	return &TemplateStore{
 		templates: make(map[string]string),
+		parsed:    make(map[string]*template.Template),
 	}
 }
 
 // Load reads templates from the given directory (if non-empty and exists),
-// then fills in any missing templates from the embedded defaults.
+// then fills in any missing templates from the embedded defaults. Every
+// template is parsed and validated so that a broken override fails at
+// startup rather than producing a broken PR.
 func (ts *TemplateStore) Load(dir string) error {
 	// Load from directory if provided.
 	if dir != "" {
@@ -142,10 +170,14 @@ func (ts *TemplateStore) Load(dir string) error {
 	}
 
 	// Fill in missing templates from embedded defaults.
-	return ts.loadEmbeddedDefaults()
+	if err := ts.loadEmbeddedDefaults(); err != nil {
+		return err
+	}
+
+	return ts.parseAll()
 }
 
-// Get returns the template content for the given name.
+// Get returns the raw (unrendered) template content for the given name.
 func (ts *TemplateStore) Get(name string) (string, error) {
 	content, ok := ts.templates[name]
 	if !ok {
@@ -155,6 +187,29 @@ func (ts *TemplateStore) Get(name string) (string, error) {
 	return content, nil
 }
 
+func (ts *TemplateStore) parseAll() error {
+	names := make([]string, 0, len(ts.templates))
+	for name := range ts.templates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var errs []error
+
+	for _, name := range names {
+		tmpl, err := parseTemplate(name, ts.templates[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ts.parsed[name] = tmpl
+	}
+
+	return errors.Join(errs...)
+}
+
 func (ts *TemplateStore) loadFromDir(dir string) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
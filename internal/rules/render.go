@@ -0,0 +1,170 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/donaldgifford/repo-guardian/internal/catalog"
+)
+
+// TemplateData is the data model passed to every template in the
+// TemplateStore. Templates reference its fields with the usual
+// text/template syntax, e.g. `{{ .Owner }}/{{ .Repo }}`.
+type TemplateData struct {
+	// Owner is the repository owner (organization or user login).
+	Owner string
+
+	// Repo is the repository name.
+	Repo string
+
+	// DefaultBranch is the repository's default branch (e.g., "main").
+	DefaultBranch string
+
+	// Properties holds the values extracted from catalog-info.yaml, or the
+	// Unclassified defaults when the file is missing or invalid.
+	Properties *catalog.Properties
+
+	// Languages lists the languages GitHub detected in the repository,
+	// ordered by number of bytes (largest first).
+	Languages []string
+
+	// Files lists every file path in the default branch's tree. It is used
+	// by generators to detect package manifests.
+	Files []string
+
+	// CodeOwner is the GitHub team handle (e.g. "@org/payments") mapped from
+	// Properties.Owner, or empty when no existing team could be resolved.
+	// It is only populated when rendering a CODEOWNERS rule.
+	CodeOwner string
+
+	// InstallationAccount is the login of the account the App is installed
+	// on. Installations are per-account, so this is the repository owner.
+	InstallationAccount string
+}
+
+// sampleTemplateData is used to execute templates at load time so that
+// references to unknown fields fail fast instead of at PR creation.
+var sampleTemplateData = &TemplateData{
+	Owner:         "org",
+	Repo:          "repo",
+	DefaultBranch: "main",
+	Properties: &catalog.Properties{
+		Owner:       catalog.DefaultOwner,
+		Component:   catalog.DefaultComponent,
+		JiraProject: "PROJ",
+		JiraLabel:   "repo",
+	},
+	Languages:           []string{"Go"},
+	InstallationAccount: "org",
+}
+
+// templateFuncs are the helper functions available to every template.
+var templateFuncs = template.FuncMap{
+	// lower and upper change the case of a string.
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+
+	// trim removes leading and trailing whitespace.
+	"trim": strings.TrimSpace,
+
+	// trimPrefix removes a prefix: {{ .Properties.Owner | trimPrefix "group:" }}.
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+
+	// join concatenates a list: {{ .Languages | join ", " }}.
+	"join": func(sep string, elems []string) string { return strings.Join(elems, sep) },
+
+	// quote returns a double-quoted, escaped string.
+	"quote": strconv.Quote,
+
+	// default returns def when s is empty: {{ .Properties.JiraProject | default "TODO" }}.
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+
+		return s
+	},
+
+	// has reports whether list contains s (case-insensitive):
+	// {{ if has "Go" .Languages }}...{{ end }}.
+	"has": func(s string, list []string) bool {
+		return slices.ContainsFunc(list, func(item string) bool { return strings.EqualFold(item, s) })
+	},
+}
+
+// legacyPlaceholder is a placeholder that was substituted by plain string
+// replacement before templates were rendered with text/template.
+type legacyPlaceholder struct {
+	placeholder string
+	replacement string
+}
+
+// legacyPlaceholders lists, per template, the placeholders that used to be
+// substituted. An override still using them would now ship them verbatim.
+var legacyPlaceholders = map[string][]legacyPlaceholder{
+	"catalog-info": {
+		{placeholder: "REPO_NAME", replacement: "{{ .Repo }}"},
+		{placeholder: "ORG_NAME", replacement: "{{ .Owner }}"},
+	},
+	"set-custom-properties": {
+		{placeholder: "OWNER_VALUE", replacement: "{{ .Properties.Owner }}"},
+		{placeholder: "COMPONENT_VALUE", replacement: "{{ .Properties.Component }}"},
+		{placeholder: "JIRA_PROJECT_VALUE", replacement: "{{ .Properties.JiraProject }}"},
+		{placeholder: "JIRA_LABEL_VALUE", replacement: "{{ .Properties.JiraLabel }}"},
+	},
+}
+
+// checkLegacyPlaceholders rejects a template that still uses placeholders
+// from before text/template rendering, naming what to use instead.
+func checkLegacyPlaceholders(name, content string) error {
+	var errs []error
+
+	for _, legacy := range legacyPlaceholders[name] {
+		if strings.Contains(content, legacy.placeholder) {
+			errs = append(errs, fmt.Errorf("template %s uses the legacy placeholder %s, which is no longer replaced: use %s instead",
+				name, legacy.placeholder, legacy.replacement))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// parseTemplate parses content as a text/template with the helper funcs
+// and verifies that it executes against sample data.
+func parseTemplate(name, content string) (*template.Template, error) {
+	if err := checkLegacyPlaceholders(name, content); err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", name, err)
+	}
+
+	if err := tmpl.Execute(io.Discard, sampleTemplateData); err != nil {
+		return nil, fmt.Errorf("validating template %s: %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+// Render executes the named template with the given data.
+func (ts *TemplateStore) Render(name string, data *TemplateData) (string, error) {
+	tmpl, ok := ts.parsed[name]
+	if !ok {
+		return "", fmt.Errorf("template %q not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering template %s: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,224 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donaldgifford/repo-guardian/internal/catalog"
+)
+
+func TestRender_EmbeddedTemplates(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	data := &TemplateData{
+		Owner:         "myorg",
+		Repo:          "my-service",
+		DefaultBranch: "trunk",
+		Properties: &catalog.Properties{
+			Owner:     "platform-team",
+			Component: "my-service",
+		},
+	}
+
+	out, err := ts.Render("catalog-info", data)
+	if err != nil {
+		t.Fatalf("Render(catalog-info): %v", err)
+	}
+
+	if !strings.Contains(out, "url:https://github.com/myorg/my-service") {
+		t.Errorf("catalog-info should contain source location, got:\n%s", out)
+	}
+
+	out, err = ts.Render("set-custom-properties", data)
+	if err != nil {
+		t.Fatalf("Render(set-custom-properties): %v", err)
+	}
+
+	for _, want := range []string{
+		"properties[][value]=platform-team",
+		"- trunk",
+		"${{ secrets.GITHUB_TOKEN }}",
+		"repos/${{ github.repository }}/properties/values",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("set-custom-properties should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRender_HelperFuncs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := `{{ .Properties.Owner | trimPrefix "group:default/" | upper }}|` +
+		`{{ .Languages | join "," }}|` +
+		`{{ .Properties.JiraProject | default "NONE" }}|` +
+		`{{ if has "go" .Languages }}go{{ end }}|` +
+		`{{ .Repo | quote }}`
+
+	if err := os.WriteFile(filepath.Join(dir, "helpers.tmpl"), []byte(content), 0o644); err != nil {
+		t.Fatalf("writing template: %v", err)
+	}
+
+	ts := NewTemplateStore()
+	if err := ts.Load(dir); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	out, err := ts.Render("helpers", &TemplateData{
+		Repo:       "svc",
+		Properties: &catalog.Properties{Owner: "group:default/payments"},
+		Languages:  []string{"Go", "Shell"},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := `PAYMENTS|Go,Shell|NONE|go|"svc"`
+	if out != want {
+		t.Errorf("Render = %q, want %q", out, want)
+	}
+}
+
+func TestLoad_InvalidTemplateFailsFast(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "syntax error", content: "{{ .Repo "},
+		{name: "unknown field", content: "{{ .Repository }}"},
+		{name: "unknown func", content: "{{ .Repo | shout }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("writing template: %v", err)
+			}
+
+			err := NewTemplateStore().Load(dir)
+			if err == nil {
+				t.Fatal("expected Load to fail for broken template")
+			}
+
+			if !strings.Contains(err.Error(), "broken") {
+				t.Errorf("error should name the template: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_LegacyPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "catalog-info",
+			file:    "catalog-info.tmpl",
+			content: "metadata:\n  name: REPO_NAME\n  source: url:https://github.com/ORG_NAME/REPO_NAME\n",
+			want:    []string{"REPO_NAME", "{{ .Repo }}", "ORG_NAME", "{{ .Owner }}"},
+		},
+		{
+			name:    "set-custom-properties",
+			file:    "set-custom-properties.tmpl",
+			content: "value=OWNER_VALUE\n",
+			want:    []string{"OWNER_VALUE", "{{ .Properties.Owner }}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("writing template: %v", err)
+			}
+
+			err := NewTemplateStore().Load(dir)
+			if err == nil {
+				t.Fatal("expected Load to reject legacy placeholders")
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error should mention %q: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_LegacyPlaceholderNamesInOtherTemplates(t *testing.T) {
+	t.Parallel()
+
+	// Only the templates that used to be substituted are checked, so a
+	// custom template may use the same words.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "script.tmpl"), []byte("echo $REPO_NAME\n"), 0o644); err != nil {
+		t.Fatalf("writing template: %v", err)
+	}
+
+	if err := NewTemplateStore().Load(dir); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+}
+
+func TestRender_Missing(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if _, err := ts.Render("nonexistent", &TemplateData{}); err == nil {
+		t.Error("expected error for missing template")
+	}
+}
+
+func TestRender_CodeOwners(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTemplateStore()
+	if err := ts.Load(""); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	props := &catalog.Properties{Owner: "group:default/payments"}
+
+	out, err := ts.Render("codeowners", &TemplateData{Properties: props, CodeOwner: "@org/payments"})
+	if err != nil {
+		t.Fatalf("Render(codeowners): %v", err)
+	}
+
+	if !strings.Contains(out, "\n* @org/payments\n") || strings.Contains(out, "CHANGEME") {
+		t.Errorf("codeowners should assign the resolved team, got:\n%s", out)
+	}
+
+	out, err = ts.Render("codeowners", &TemplateData{Properties: props})
+	if err != nil {
+		t.Fatalf("Render(codeowners): %v", err)
+	}
+
+	if !strings.Contains(out, "\n* @org/CHANGEME\n") {
+		t.Errorf("codeowners should fall back to the placeholder, got:\n%s", out)
+	}
+}
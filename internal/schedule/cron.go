@@ -0,0 +1,209 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds how far ahead Next looks. Every valid expression
+// matches within eight years, the longest gap between two February 29ths.
+const cronSearchYears = 8
+
+// Cron is a Schedule defined by a standard five-field cron expression:
+// minute, hour, day of month, month and day of week. Fields accept *,
+// numbers, ranges (1-5), lists (1,3,5) and steps (*/15, 0-30/10); months
+// and days of week also accept three-letter names. Day of week 0 and 7 are
+// both Sunday. As in cron, when both day fields are restricted a day
+// matching either one matches.
+type Cron struct {
+	expr string
+
+	minute, hour, dom, month, dow uint64 // one bit per allowed value
+	domAny, dowAny                bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names[i] stands for min+i
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{
+		"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec",
+	}},
+	{name: "day of week", min: 0, max: 7, names: dayNames},
+}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseCron parses a five-field cron expression such as "0 2 * * mon-fri".
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q: want %d fields, got %d", expr, len(cronFields), len(fields))
+	}
+
+	var sets [len(cronFields)]uint64
+
+	for i, f := range fields {
+		set, err := cronFields[i].parse(f)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+
+		sets[i] = set
+	}
+
+	// 7 is Sunday too.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	c := &Cron{
+		expr:   expr,
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+	}
+
+	if c.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never matches", expr)
+	}
+
+	return c, nil
+}
+
+// Next returns the first minute strictly after the given time that matches
+// the expression, in UTC.
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(end) {
+		switch {
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(c.hour, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c *Cron) String() string {
+	return c.expr
+}
+
+func (c *Cron) matchDay(t time.Time) bool {
+	if !has(c.month, int(t.Month())) {
+		return false
+	}
+
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// parse returns the set of values a comma-separated field allows.
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+
+	for part := range strings.SplitSeq(s, ",") {
+		lo, hi, step, err := f.parseRange(part)
+		if err != nil {
+			return 0, err
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// parseRange parses one list element: *, a value or a range, optionally
+// followed by /step.
+func (f cronField) parseRange(s string) (lo, hi, step int, err error) {
+	rng, stepStr, hasStep := strings.Cut(s, "/")
+	step = 1
+
+	if hasStep {
+		step, err = strconv.Atoi(stepStr)
+		if err != nil || step < 1 {
+			return 0, 0, 0, fmt.Errorf("invalid %s step %q", f.name, stepStr)
+		}
+	}
+
+	switch loStr, hiStr, isRange := strings.Cut(rng, "-"); {
+	case rng == "*":
+		lo, hi = f.min, f.max
+	case isRange:
+		if lo, err = f.value(loStr); err != nil {
+			return 0, 0, 0, err
+		}
+
+		if hi, err = f.value(hiStr); err != nil {
+			return 0, 0, 0, err
+		}
+
+		if hi < lo {
+			return 0, 0, 0, fmt.Errorf("invalid %s range %q", f.name, rng)
+		}
+	default:
+		if lo, err = f.value(rng); err != nil {
+			return 0, 0, 0, err
+		}
+
+		// A single value with a step runs to the end of the field.
+		hi = lo
+		if hasStep {
+			hi = f.max
+		}
+	}
+
+	return lo, hi, step, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+
+	return v, nil
+}
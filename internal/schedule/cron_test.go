@@ -0,0 +1,125 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	t.Parallel()
+
+	// 2026-03-06 is a Friday.
+	from := time.Date(2026, time.March, 6, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			want: time.Date(2026, time.March, 6, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays at 02:00 skip the weekend",
+			expr: "0 2 * * 1-5",
+			want: time.Date(2026, time.March, 9, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day names",
+			expr: "0 2 * * mon-fri",
+			want: time.Date(2026, time.March, 9, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "later today",
+			expr: "45 10,22 * * *",
+			want: time.Date(2026, time.March, 6, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "step",
+			expr: "*/20 * * * *",
+			want: time.Date(2026, time.March, 6, 10, 40, 0, 0, time.UTC),
+		},
+		{
+			name: "value with step",
+			expr: "0 5/6 * * *",
+			want: time.Date(2026, time.March, 6, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 0 * * 7",
+			want: time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "month names",
+			expr: "0 0 1 jun *",
+			want: time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "restricted day of month or day of week",
+			expr: "0 0 15 * sun",
+			want: time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			want: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNext_ConvertsToUTC(t *testing.T) {
+	t.Parallel()
+
+	c, err := ParseCron("0 2 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+
+	// 03:30 in UTC+2 is 01:30 UTC, before the 02:00 UTC run.
+	from := time.Date(2026, time.March, 6, 3, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+
+	want := time.Date(2026, time.March, 6, 2, 0, 0, 0, time.UTC)
+	if got := c.Next(from); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"",
+		"0 2 * *",
+		"0 2 * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * * funday",
+		"0 0 31 2 *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
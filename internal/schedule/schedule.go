@@ -0,0 +1,24 @@
+// Package schedule parses the cron expressions and weekly time windows that
+// control when repo-guardian reconciles repositories and opens pull
+// requests. All times are evaluated in UTC.
+package schedule
+
+import "time"
+
+// Schedule reports when a recurring event next happens.
+type Schedule interface {
+	// Next returns the first activation strictly after the given time.
+	Next(after time.Time) time.Time
+}
+
+// Every is a Schedule that activates at a fixed interval.
+type Every time.Duration
+
+// Next returns after plus the interval.
+func (e Every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+func (e Every) String() string {
+	return "every " + time.Duration(e).String()
+}
@@ -0,0 +1,206 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// allDays has a bit set for every time.Weekday.
+const allDays = 1<<7 - 1
+
+// Window is a daily period on some days of the week, in UTC. A window
+// whose end is not after its start runs past midnight, and belongs to the
+// day it starts on.
+type Window struct {
+	days       uint8 // one bit per time.Weekday
+	start, end int   // minutes since midnight
+}
+
+// Windows is a set of Windows. An empty set is always open.
+type Windows []Window
+
+// ParseWindows parses windows separated by semicolons. Each window is an
+// optional list of days followed by a time range, e.g.
+// "Mon-Fri 09:00-17:00; Sat,Sun 10:00-12:00" or "22:00-06:00". Without days,
+// a window applies every day.
+func ParseWindows(spec string) (Windows, error) {
+	var ws Windows
+
+	for part := range strings.SplitSeq(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		w, err := parseWindow(part)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+
+		ws = append(ws, w)
+	}
+
+	return ws, nil
+}
+
+func parseWindow(s string) (Window, error) {
+	w := Window{days: allDays}
+	fields := strings.Fields(s)
+
+	switch len(fields) {
+	case 1:
+	case 2:
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return Window{}, err
+		}
+
+		w.days = days
+	default:
+		return Window{}, fmt.Errorf("want [days] HH:MM-HH:MM")
+	}
+
+	startStr, endStr, ok := strings.Cut(fields[len(fields)-1], "-")
+	if !ok {
+		return Window{}, fmt.Errorf("want a time range HH:MM-HH:MM")
+	}
+
+	var err error
+
+	if w.start, err = parseClock(startStr); err != nil {
+		return Window{}, err
+	}
+
+	if w.end, err = parseClock(endStr); err != nil {
+		return Window{}, err
+	}
+
+	if w.start == w.end {
+		return Window{}, fmt.Errorf("window is empty")
+	}
+
+	return w, nil
+}
+
+// parseDays parses a comma-separated list of day names and ranges such as
+// "Mon-Fri" or "Sat,Sun". A range may wrap past Sunday, e.g. "Fri-Mon".
+func parseDays(s string) (uint8, error) {
+	var days uint8
+
+	for part := range strings.SplitSeq(s, ",") {
+		loStr, hiStr, isRange := strings.Cut(part, "-")
+
+		lo, err := parseDay(loStr)
+		if err != nil {
+			return 0, err
+		}
+
+		hi := lo
+		if isRange {
+			if hi, err = parseDay(hiStr); err != nil {
+				return 0, err
+			}
+		}
+
+		for d := lo; ; d = (d + 1) % 7 {
+			days |= 1 << d
+
+			if d == hi {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func parseDay(s string) (int, error) {
+	for i, name := range dayNames {
+		if strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid day %q", s)
+}
+
+// parseClock parses HH:MM into minutes since midnight. 24:00 is the end of
+// the day.
+func parseClock(s string) (int, error) {
+	hStr, mStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	h, herr := strconv.Atoi(hStr)
+	m, merr := strconv.Atoi(mStr)
+
+	if herr != nil || merr != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	return h*60 + m, nil
+}
+
+// Open reports whether t falls inside any of the windows.
+func (ws Windows) Open(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+
+	t = t.UTC()
+	minute := t.Hour()*60 + t.Minute()
+	today, yesterday := t.Weekday(), (t.Weekday()+6)%7
+
+	for _, w := range ws {
+		if w.start < w.end {
+			if w.on(today) && minute >= w.start && minute < w.end {
+				return true
+			}
+
+			continue
+		}
+
+		if (w.on(today) && minute >= w.start) || (w.on(yesterday) && minute < w.end) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextOpen returns t if a window is open at t, and otherwise the time the
+// next window opens.
+func (ws Windows) NextOpen(t time.Time) time.Time {
+	if ws.Open(t) {
+		return t
+	}
+
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	var next time.Time
+
+	for d := range 8 {
+		day := midnight.AddDate(0, 0, d)
+
+		for _, w := range ws {
+			if !w.on(day.Weekday()) {
+				continue
+			}
+
+			start := day.Add(time.Duration(w.start) * time.Minute)
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+
+	return next
+}
+
+func (w Window) on(day time.Weekday) bool {
+	return w.days&(1<<uint(day)) != 0
+}
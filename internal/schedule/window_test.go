@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a time in the week of Monday 2026-03-02.
+func at(day time.Weekday, hour, minute int) time.Time {
+	offset := (int(day) + 6) % 7
+
+	return time.Date(2026, time.March, 2+offset, hour, minute, 0, 0, time.UTC)
+}
+
+func TestWindowsOpen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		spec string
+		at   time.Time
+		want bool
+	}{
+		{name: "no windows", spec: "", at: at(time.Sunday, 3, 0), want: true},
+		{name: "inside", spec: "Mon-Fri 09:00-17:00", at: at(time.Wednesday, 9, 0), want: true},
+		{name: "end is exclusive", spec: "Mon-Fri 09:00-17:00", at: at(time.Wednesday, 17, 0), want: false},
+		{name: "wrong day", spec: "Mon-Fri 09:00-17:00", at: at(time.Saturday, 10, 0), want: false},
+		{name: "second window", spec: "Mon-Fri 09:00-17:00; Sat 10:00-12:00", at: at(time.Saturday, 11, 0), want: true},
+		{name: "every day", spec: "09:00-17:00", at: at(time.Sunday, 12, 0), want: true},
+		{name: "overnight before midnight", spec: "Fri 22:00-06:00", at: at(time.Friday, 23, 0), want: true},
+		{name: "overnight after midnight", spec: "Fri 22:00-06:00", at: at(time.Saturday, 5, 59), want: true},
+		{name: "overnight belongs to start day", spec: "Fri 22:00-06:00", at: at(time.Friday, 5, 0), want: false},
+		{name: "day range wraps", spec: "Sat-Mon 00:00-24:00", at: at(time.Sunday, 12, 0), want: true},
+		{name: "day list", spec: "Mon,Wed 09:00-10:00", at: at(time.Tuesday, 9, 30), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ws, err := ParseWindows(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseWindows(%q): %v", tt.spec, err)
+			}
+
+			if got := ws.Open(tt.at); got != tt.want {
+				t.Errorf("Open(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowsNextOpen(t *testing.T) {
+	t.Parallel()
+
+	ws, err := ParseWindows("Mon-Fri 09:00-17:00")
+	if err != nil {
+		t.Fatalf("ParseWindows: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{name: "open now", at: at(time.Tuesday, 12, 0), want: at(time.Tuesday, 12, 0)},
+		{name: "later today", at: at(time.Tuesday, 2, 0), want: at(time.Tuesday, 9, 0)},
+		{name: "tomorrow", at: at(time.Tuesday, 18, 0), want: at(time.Wednesday, 9, 0)},
+		{name: "after the weekend", at: at(time.Friday, 17, 0), want: at(time.Monday, 9, 0).AddDate(0, 0, 7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ws.NextOpen(tt.at); !got.Equal(tt.want) {
+				t.Errorf("NextOpen(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWindows_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{
+		"09:00",
+		"Mon-Fri",
+		"Mon-Fri 9-17",
+		"Mon-Fri 09:00-25:00",
+		"Mon-Fri 09:60-17:00",
+		"Funday 09:00-17:00",
+		"Mon 09:00-09:00",
+		"Mon Tue 09:00-17:00",
+	} {
+		if _, err := ParseWindows(spec); err == nil {
+			t.Errorf("ParseWindows(%q) succeeded, want error", spec)
+		}
+	}
+}
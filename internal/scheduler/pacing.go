@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+)
+
+// defaultRateLimit is the hourly request limit assumed for an installation
+// whose rate limit has not been observed yet. It is the smallest limit
+// GitHub grants an installation token.
+const defaultRateLimit = 5000
+
+// RateLimits reports the GitHub rate limit last observed for an
+// installation. ghclient.GitHubClient implements it.
+type RateLimits interface {
+	InstallationRateLimit(installationID int64) (ghclient.RateLimit, bool)
+}
+
+// Pacing controls how a reconciliation pass spreads its enqueues over time.
+// The zero value enqueues every repo at once.
+type Pacing struct {
+	// RepoCost is the estimated number of API calls one repo check makes.
+	// Zero disables pacing by rate limit budget.
+	RepoCost int
+
+	// Reserve is the fraction of each installation's rate limit left for
+	// webhooks and other work.
+	Reserve float64
+
+	// Spread is the longest a full pass is stretched over to even out the
+	// load, capped at the time until the next scheduled pass. Zero paces by
+	// rate limit budget only.
+	Spread time.Duration
+
+	// Limits provides each installation's rate limit. When nil, or before
+	// an installation has made a call, defaultRateLimit is assumed.
+	Limits RateLimits
+}
+
+// SetPacing sets how reconciliation passes spread their enqueues. It must
+// be called before Start.
+func (s *Scheduler) SetPacing(p Pacing) {
+	s.pacing = p
+}
+
+// gap returns the time between two enqueues for an installation with n
+// repos: long enough to spread them over window, and, if checking them all
+// would exceed what is left of the rate limit, long enough for the checks
+// to stay within the hourly budget.
+func (p Pacing) gap(limit ghclient.RateLimit, known bool, n int, window time.Duration) time.Duration {
+	if n == 0 {
+		return 0
+	}
+
+	gap := window / time.Duration(n)
+
+	if p.RepoCost <= 0 {
+		return gap
+	}
+
+	if !known {
+		limit = ghclient.RateLimit{Limit: defaultRateLimit, Remaining: defaultRateLimit}
+	}
+
+	reserve := int(float64(limit.Limit) * p.Reserve)
+	if n*p.RepoCost <= limit.Remaining-reserve {
+		return gap
+	}
+
+	budget := limit.Limit - reserve
+	if budget <= 0 {
+		return gap
+	}
+
+	return max(gap, time.Hour*time.Duration(p.RepoCost)/time.Duration(budget))
+}
+
+// spreadWindow returns how long a full pass starting at start may take.
+func (s *Scheduler) spreadWindow(start time.Time) time.Duration {
+	if s.pacing.Spread <= 0 {
+		return 0
+	}
+
+	return min(s.pacing.Spread, s.schedule.Next(start).Sub(start))
+}
+
+func (s *Scheduler) rateLimit(installationID int64) (ghclient.RateLimit, bool) {
+	if s.pacing.Limits == nil {
+		return ghclient.RateLimit{}, false
+	}
+
+	return s.pacing.Limits.InstallationRateLimit(installationID)
+}
+
+// installPlan is the repos of one installation to enqueue and the time
+// between two enqueues.
+type installPlan struct {
+	installationID int64
+	repos          []*ghclient.Repository
+	gap            time.Duration
+}
+
+// duration returns how long enqueuing the plan is expected to take.
+func (p installPlan) duration() time.Duration {
+	return time.Duration(len(p.repos)) * p.gap
+}
+
+// offset returns when, after the start of the plan, repo i is enqueued: in
+// its own slot of length gap, at a random point so that installations and
+// repos paced alike do not hit the queue and GitHub in lockstep.
+func (p installPlan) offset(i int) time.Duration {
+	if p.gap <= 0 {
+		return 0
+	}
+
+	return time.Duration(i)*p.gap + rand.N(p.gap) //nolint:gosec // Jitter does not need a cryptographic source.
+}
+
+// sleepUntil waits until t, returning early with an error if ctx ends.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/donaldgifford/repo-guardian/internal/checker"
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+)
+
+// fixedLimits reports the same rate limit for every installation.
+type fixedLimits ghclient.RateLimit
+
+func (l fixedLimits) InstallationRateLimit(_ int64) (ghclient.RateLimit, bool) {
+	return ghclient.RateLimit(l), true
+}
+
+func TestPacingGap(t *testing.T) {
+	t.Parallel()
+
+	full := ghclient.RateLimit{Limit: 5000, Remaining: 5000}
+
+	tests := []struct {
+		name   string
+		pacing Pacing
+		limit  ghclient.RateLimit
+		known  bool
+		repos  int
+		window time.Duration
+		want   time.Duration
+	}{
+		{
+			name:  "no pacing",
+			repos: 100,
+			want:  0,
+		},
+		{
+			name:   "spread over the window",
+			repos:  10,
+			window: time.Hour,
+			want:   6 * time.Minute,
+		},
+		{
+			name:   "fits in the remaining budget",
+			pacing: Pacing{RepoCost: 20, Reserve: 0.1},
+			limit:  full,
+			known:  true,
+			repos:  100,
+			want:   0,
+		},
+		{
+			name:   "exceeds the remaining budget",
+			pacing: Pacing{RepoCost: 20, Reserve: 0.1},
+			limit:  ghclient.RateLimit{Limit: 5000, Remaining: 1000},
+			known:  true,
+			repos:  100,
+			want:   time.Hour * 20 / 4500,
+		},
+		{
+			name:   "unknown limit assumes the default",
+			pacing: Pacing{RepoCost: 50},
+			repos:  1000,
+			want:   time.Hour * 50 / 5000,
+		},
+		{
+			name:   "larger installations get a larger budget",
+			pacing: Pacing{RepoCost: 50},
+			limit:  ghclient.RateLimit{Limit: 12500, Remaining: 12500},
+			known:  true,
+			repos:  1000,
+			want:   time.Hour * 50 / 12500,
+		},
+		{
+			name:   "window wider than the budget needs",
+			pacing: Pacing{RepoCost: 20},
+			limit:  ghclient.RateLimit{Limit: 5000, Remaining: 0},
+			known:  true,
+			repos:  10,
+			window: 10 * time.Hour,
+			want:   time.Hour,
+		},
+		{
+			name:   "no repos",
+			pacing: Pacing{RepoCost: 20},
+			window: time.Hour,
+			want:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.pacing.gap(tt.limit, tt.known, tt.repos, tt.window); got != tt.want {
+				t.Errorf("gap = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallPlanOffset(t *testing.T) {
+	t.Parallel()
+
+	plan := installPlan{repos: make([]*ghclient.Repository, 5), gap: time.Minute}
+
+	for i := range plan.repos {
+		for range 20 {
+			if got := plan.offset(i); got < time.Duration(i)*time.Minute || got >= time.Duration(i+1)*time.Minute {
+				t.Fatalf("offset(%d) = %v, want within slot %d", i, got, i)
+			}
+		}
+	}
+
+	if got := (installPlan{repos: plan.repos}).offset(3); got != 0 {
+		t.Errorf("offset without a gap = %v, want 0", got)
+	}
+}
+
+func TestSpreadWindow(t *testing.T) {
+	t.Parallel()
+
+	s := NewScheduler(newMockClient(), checker.NewQueue(1, slog.Default()), 2*time.Hour, slog.Default(), true, true)
+	start := time.Now()
+
+	if got := s.spreadWindow(start); got != 0 {
+		t.Errorf("spreadWindow without Spread = %v, want 0", got)
+	}
+
+	s.SetPacing(Pacing{Spread: time.Hour})
+
+	if got := s.spreadWindow(start); got != time.Hour {
+		t.Errorf("spreadWindow = %v, want 1h", got)
+	}
+
+	// A pass never runs into the next one.
+	s.SetPacing(Pacing{Spread: 24 * time.Hour})
+
+	if got := s.spreadWindow(start); got != 2*time.Hour {
+		t.Errorf("spreadWindow = %v, want the 2h interval", got)
+	}
+}
+
+func TestReconcileAll_Paced(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	client.installations = []*ghclient.Installation{{ID: 1, Account: "org1"}, {ID: 2, Account: "org2"}}
+	client.installRepos[1] = []*ghclient.Repository{
+		{Owner: "org1", Name: "repo-a"},
+		{Owner: "org1", Name: "repo-b"},
+		{Owner: "org1", Name: "repo-c"},
+		{Owner: "org1", Name: "repo-d"},
+	}
+	client.installRepos[2] = []*ghclient.Repository{{Owner: "org2", Name: "repo-a"}}
+
+	q := checker.NewQueue(100, slog.Default())
+
+	s := NewScheduler(client, q, time.Hour, slog.Default(), true, true)
+	s.SetPacing(Pacing{Spread: 200 * time.Millisecond, Limits: fixedLimits{Limit: 5000, Remaining: 5000}})
+
+	start := time.Now()
+	stats := s.reconcileAll(context.Background())
+
+	if stats.enqueued != 5 || !stats.complete() {
+		t.Errorf("stats = %+v, want 5 enqueued", stats)
+	}
+
+	// Installation 1's last repo is enqueued in its fourth 50ms slot;
+	// installation 2 is paced alongside it, not after it.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("pass took %v, want it spread over about 200ms", elapsed)
+	}
+}
+
+func TestReconcileAll_PacedStopsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	client.installations = []*ghclient.Installation{{ID: 1, Account: "org1"}}
+	client.installRepos[1] = []*ghclient.Repository{
+		{Owner: "org1", Name: "repo-a"},
+		{Owner: "org1", Name: "repo-b"},
+		{Owner: "org1", Name: "repo-c"},
+	}
+
+	q := checker.NewQueue(100, slog.Default())
+
+	s := NewScheduler(client, q, 24*time.Hour, slog.Default(), true, true)
+	s.SetPacing(Pacing{Spread: 3 * time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	stats := s.reconcileAll(ctx)
+
+	if stats.enqueued+stats.dropped != 3 || stats.dropped < 2 {
+		t.Errorf("stats = %+v, want the repos not yet due dropped", stats)
+	}
+}
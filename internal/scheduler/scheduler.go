@@ -5,10 +5,15 @@ package scheduler
 import (
 	"context"
 	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/donaldgifford/repo-guardian/internal/checker"
 	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/schedule"
 )
 
 // Scheduler periodically reconciles all repositories across all
@@ -16,13 +21,22 @@ import (
 type Scheduler struct {
 	client       ghclient.Client
 	queue        *checker.Queue
-	interval     time.Duration
+	schedule     schedule.Schedule
+	statePath    string
+	pacing       Pacing
 	logger       *slog.Logger
 	skipForks    bool
 	skipArchived bool
+
+	// requests holds installations to reconcile ahead of the next pass.
+	requests chan int64
 }
 
-// NewScheduler creates a new Scheduler.
+// maxPendingRequests bounds the installations waiting for an on-demand
+// reconciliation.
+const maxPendingRequests = 64
+
+// NewScheduler creates a new Scheduler that reconciles every interval.
 func NewScheduler(
 	client ghclient.Client,
 	queue *checker.Queue,
@@ -33,52 +47,205 @@ func NewScheduler(
 	return &Scheduler{
 		client:       client,
 		queue:        queue,
-		interval:     interval,
+		schedule:     schedule.Every(interval),
 		logger:       logger,
 		skipForks:    skipForks,
 		skipArchived: skipArchived,
+		requests:     make(chan int64, maxPendingRequests),
 	}
 }
 
-// Start begins the reconciliation loop. It runs reconcileAll immediately
-// on startup, then repeats at the configured interval. It blocks until
-// the context is canceled.
+// SetSchedule replaces the interval given to NewScheduler, e.g. with a
+// schedule.Cron. It must be called before Start.
+func (s *Scheduler) SetSchedule(sched schedule.Schedule) {
+	s.schedule = sched
+}
+
+// SetStatePath sets the file the time of the last complete reconciliation
+// is kept in. With it, Start only reconciles at once if a run was missed
+// while the process was down. Empty, the default, reconciles at every start.
+// It must be called before Start.
+func (s *Scheduler) SetStatePath(path string) {
+	s.statePath = path
+}
+
+// Start begins the reconciliation loop. It reconciles all installations at
+// startup, unless the state file shows the last scheduled run is not yet
+// due, and then whenever the schedule next activates, reconciling single
+// installations requested with RequestReconcile in between. Passes run in
+// the background, as pacing may stretch them out; an activation while the
+// previous pass is still running is skipped. It blocks until the context is
+// canceled and its passes have stopped.
 func (s *Scheduler) Start(ctx context.Context) {
-	s.logger.Info("scheduler starting", "interval", s.interval)
+	var (
+		passes  sync.WaitGroup
+		running atomic.Bool // a full pass is in progress
+	)
 
-	// Run once on startup.
-	s.reconcileAll(ctx)
+	defer passes.Wait()
 
-	ticker := time.NewTicker(s.interval)
-	defer ticker.Stop()
+	next := s.firstRun(time.Now())
+	s.logger.Info("scheduler starting", "schedule", s.schedule, "next_run", next)
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			s.logger.Info("scheduler stopped")
 			return
-		case <-ticker.C:
-			s.reconcileAll(ctx)
+		case <-timer.C:
+			if running.CompareAndSwap(false, true) {
+				passes.Go(func() {
+					defer running.Store(false)
+
+					s.run(ctx)
+				})
+			} else {
+				s.logger.Warn("previous reconciliation still running, skipping this one")
+			}
+
+			next = s.schedule.Next(time.Now())
+			timer.Reset(time.Until(next))
+			s.logger.Info("next reconciliation scheduled", "next_run", next)
+		case id := <-s.requests:
+			passes.Go(func() { s.reconcileRequested(ctx, id) })
 		}
 	}
 }
 
-// reconcileAll lists all installations and their repos, enqueuing each for checking.
-func (s *Scheduler) reconcileAll(ctx context.Context) {
+// firstRun returns when the first reconciliation after startup is due: the
+// schedule's next activation after the last complete run, or now if that
+// has passed or no run is recorded.
+func (s *Scheduler) firstRun(now time.Time) time.Time {
+	if s.statePath == "" {
+		return now
+	}
+
+	last, err := loadLastRun(s.statePath)
+	if err != nil {
+		s.logger.Error("failed to read scheduler state, reconciling now", "path", s.statePath, "error", err)
+		return now
+	}
+
+	if last.IsZero() {
+		return now
+	}
+
+	next := s.schedule.Next(last)
+	if next.Before(now) {
+		return now
+	}
+
+	s.logger.Info("last reconciliation is recent, waiting for the schedule", "last_run", last)
+
+	return next
+}
+
+// run reconciles all installations and, if every repo was enqueued, records
+// the start of the run in the state file.
+func (s *Scheduler) run(ctx context.Context) {
+	start := time.Now()
+
+	stats := s.reconcileAll(ctx)
+	if !stats.complete() || s.statePath == "" {
+		return
+	}
+
+	if err := saveLastRun(s.statePath, start); err != nil {
+		s.logger.Error("failed to write scheduler state", "path", s.statePath, "error", err)
+		metrics.ErrorsTotal.WithLabelValues("save_scheduler_state").Inc()
+	}
+}
+
+// RequestReconcile asks the loop to reconcile one installation, e.g. after
+// it was unsuspended. It does not block, and reports false when too many
+// requests are already waiting.
+func (s *Scheduler) RequestReconcile(installationID int64) bool {
+	select {
+	case s.requests <- installationID:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Scheduler) reconcileRequested(ctx context.Context, installationID int64) {
+	start := time.Now()
+	log := s.logger.With("installation_id", installationID)
+	log.Info("starting installation reconciliation")
+
+	plan, err := s.plan(ctx, installationID, 0)
+	if err != nil {
+		log.Error("failed to list repos for installation", "error", err)
+		return
+	}
+
+	stats := s.enqueue(ctx, plan)
+
+	log.Info("installation reconciliation complete",
+		"enqueued", stats.enqueued,
+		"deferred", stats.deferred,
+		"dropped", stats.dropped,
+		"duration", time.Since(start),
+	)
+}
+
+// reconcileStats summarizes one reconciliation pass.
+type reconcileStats struct {
+	enqueued int // repos added to the queue, including deferred ones
+	deferred int // enqueued repos that had to wait for queue capacity
+	dropped  int // repos that could not be enqueued
+	failed   int // listings of installations or their repos that failed
+}
+
+// complete reports whether the pass reached every repository.
+func (st reconcileStats) complete() bool {
+	return st.dropped == 0 && st.failed == 0
+}
+
+func (st *reconcileStats) add(other reconcileStats) {
+	st.enqueued += other.enqueued
+	st.deferred += other.deferred
+	st.dropped += other.dropped
+	st.failed += other.failed
+}
+
+// reconcileAll lists all installations and their repos and enqueues each
+// repo for checking, pacing each installation's repos as its plan says.
+// Installations are enqueued concurrently. When the queue is full it waits
+// for capacity rather than dropping repos.
+func (s *Scheduler) reconcileAll(ctx context.Context) reconcileStats {
+	var stats reconcileStats
+
 	start := time.Now()
 	s.logger.Info("starting reconciliation")
 
 	installations, err := s.client.ListInstallations(ctx)
 	if err != nil {
 		s.logger.Error("failed to list installations", "error", err)
-		return
+
+		stats.failed++
+
+		return stats
 	}
 
-	var enqueued int
+	window := s.spreadWindow(start)
+	plans := make([]installPlan, 0, len(installations))
 
 	for _, install := range installations {
-		repos, err := s.client.ListInstallationRepos(ctx, install.ID)
+		// Suspended installations cannot get a token; their repos are
+		// checked again once an unsuspend event requests it.
+		if install.Suspended || s.queue.InstallationSuspended(install.ID) {
+			s.logger.Info("skipping suspended installation", "installation_id", install.ID)
+			continue
+		}
+
+		plan, err := s.plan(ctx, install.ID, window)
 		if err != nil {
+			stats.failed++
+
 			s.logger.Error("failed to list repos for installation",
 				"installation_id", install.ID,
 				"error", err,
@@ -87,42 +254,128 @@ func (s *Scheduler) reconcileAll(ctx context.Context) {
 			continue
 		}
 
-		for _, repo := range repos {
-			// Pre-filter archived and forked repos to avoid enqueuing work
-			// that the engine would skip anyway. The engine performs the
-			// authoritative check — this is an optimization to reduce
-			// unnecessary GitHub API calls during reconciliation.
-			if s.skipArchived && repo.Archived {
-				continue
-			}
-
-			if s.skipForks && repo.Fork {
-				continue
-			}
+		plans = append(plans, plan)
+	}
 
-			job := checker.RepoJob{
-				Owner:          repo.Owner,
-				Repo:           repo.Name,
-				InstallationID: install.ID,
-				Trigger:        checker.TriggerScheduler,
-			}
+	projected := start
+	for _, plan := range plans {
+		if end := start.Add(plan.duration()); end.After(projected) {
+			projected = end
+		}
+	}
 
-			if err := s.queue.Enqueue(job); err != nil {
-				s.logger.Error("failed to enqueue repo",
-					"owner", repo.Owner,
-					"repo", repo.Name,
-					"error", err,
-				)
+	metrics.ReconcileCompletionTime.WithLabelValues("projected").Set(float64(projected.Unix()))
+	s.logger.Info("reconciliation planned", "installations", len(plans), "projected_completion", projected)
 
-				continue
-			}
+	stats.add(s.enqueueAll(ctx, plans))
 
-			enqueued++
-		}
+	if ctx.Err() == nil {
+		metrics.ReconcileCompletionTime.WithLabelValues("actual").Set(float64(time.Now().Unix()))
 	}
 
 	s.logger.Info("reconciliation complete",
-		"enqueued", enqueued,
+		"enqueued", stats.enqueued,
+		"deferred", stats.deferred,
+		"dropped", stats.dropped,
 		"duration", time.Since(start),
+		"projected_duration", projected.Sub(start),
 	)
+
+	return stats
+}
+
+// plan lists the repos of one installation that need checking and paces
+// them within window. It only fails if the repos cannot be listed.
+func (s *Scheduler) plan(ctx context.Context, installationID int64, window time.Duration) (installPlan, error) {
+	repos, err := s.client.ListInstallationRepos(ctx, installationID)
+	if err != nil {
+		return installPlan{}, err
+	}
+
+	// Pre-filter archived and forked repos to avoid enqueuing work that the
+	// engine would skip anyway. The engine performs the authoritative check
+	// — this is an optimization to reduce unnecessary GitHub API calls
+	// during reconciliation.
+	repos = slices.DeleteFunc(repos, func(repo *ghclient.Repository) bool {
+		return (s.skipArchived && repo.Archived) || (s.skipForks && repo.Fork)
+	})
+
+	limit, known := s.rateLimit(installationID)
+
+	return installPlan{
+		installationID: installationID,
+		repos:          repos,
+		gap:            s.pacing.gap(limit, known, len(repos), window),
+	}, nil
+}
+
+// enqueueAll enqueues the plans concurrently and sums their stats.
+func (s *Scheduler) enqueueAll(ctx context.Context, plans []installPlan) reconcileStats {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		total reconcileStats
+	)
+
+	for _, plan := range plans {
+		wg.Go(func() {
+			stats := s.enqueue(ctx, plan)
+
+			mu.Lock()
+			total.add(stats)
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	return total
+}
+
+// enqueue adds the repos of plan to the queue, each at its jittered offset.
+func (s *Scheduler) enqueue(ctx context.Context, plan installPlan) reconcileStats {
+	var stats reconcileStats
+
+	start := time.Now()
+
+	for i, repo := range plan.repos {
+		if err := sleepUntil(ctx, start.Add(plan.offset(i))); err != nil {
+			stats.dropped += len(plan.repos) - i
+
+			s.logger.Warn("reconciliation stopped before all repos were enqueued",
+				"installation_id", plan.installationID,
+				"remaining", len(plan.repos)-i,
+			)
+
+			break
+		}
+
+		job := checker.RepoJob{
+			Owner:          repo.Owner,
+			Repo:           repo.Name,
+			InstallationID: plan.installationID,
+			Trigger:        checker.TriggerScheduler,
+		}
+
+		waited, err := s.queue.EnqueueWait(ctx, job)
+		if err != nil {
+			s.logger.Error("failed to enqueue repo",
+				"owner", repo.Owner,
+				"repo", repo.Name,
+				"error", err,
+			)
+
+			stats.dropped++
+
+			continue
+		}
+
+		stats.enqueued++
+
+		if waited {
+			stats.deferred++
+		}
+	}
+
+	return stats
 }
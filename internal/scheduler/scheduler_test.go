@@ -4,17 +4,22 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/donaldgifford/repo-guardian/internal/checker"
 	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/schedule"
 )
 
 // mockClient implements ghclient.Client for scheduler tests.
 type mockClient struct {
 	installations []*ghclient.Installation
-	installRepos  map[int64][]*ghclient.Repository
+
+	mu           sync.Mutex // guards installRepos
+	installRepos map[int64][]*ghclient.Repository
 
 	listInstallErr error
 	listReposErr   error
@@ -34,6 +39,10 @@ func (*mockClient) ListOpenPullRequests(_ context.Context, _, _ string) ([]*ghcl
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (*mockClient) ListClosedPullRequests(_ context.Context, _, _, _ string) ([]*ghclient.PullRequest, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (*mockClient) GetRepository(_ context.Context, _, _ string) (*ghclient.Repository, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -50,14 +59,26 @@ func (*mockClient) DeleteBranch(_ context.Context, _, _, _ string) error {
 	return fmt.Errorf("not implemented")
 }
 
-func (*mockClient) CreateOrUpdateFile(_ context.Context, _, _, _, _, _, _ string) error {
-	return fmt.Errorf("not implemented")
+func (*mockClient) CommitFiles(_ context.Context, _, _, _, _ string, _ []ghclient.FileChange) (string, error) {
+	return "", fmt.Errorf("not implemented")
 }
 
 func (*mockClient) CreatePullRequest(_ context.Context, _, _, _, _, _, _ string) (*ghclient.PullRequest, error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (*mockClient) UpdatePullRequest(_ context.Context, _, _ string, _ int, _ string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (*mockClient) ClosePullRequest(_ context.Context, _, _ string, _ int) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (*mockClient) AddLabels(_ context.Context, _, _ string, _ int, _ []string) error {
+	return fmt.Errorf("not implemented")
+}
+
 func (m *mockClient) ListInstallations(_ context.Context) ([]*ghclient.Installation, error) {
 	if m.listInstallErr != nil {
 		return nil, m.listInstallErr
@@ -71,6 +92,9 @@ func (m *mockClient) ListInstallationRepos(_ context.Context, installationID int
 		return nil, m.listReposErr
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	return m.installRepos[installationID], nil
 }
 
@@ -78,6 +102,8 @@ func (*mockClient) CreateInstallationClient(_ context.Context, _ int64) (ghclien
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (*mockClient) ForgetInstallation(_ int64) {}
+
 func (*mockClient) GetFileContent(_ context.Context, _, _, _ string) (string, error) {
 	return "", fmt.Errorf("not implemented")
 }
@@ -90,6 +116,26 @@ func (*mockClient) SetCustomPropertyValues(_ context.Context, _, _ string, _ []*
 	return fmt.Errorf("not implemented")
 }
 
+func (*mockClient) ListTree(_ context.Context, _, _, _ string) ([]string, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (*mockClient) TeamExists(_ context.Context, _, _ string) (bool, error) {
+	return false, fmt.Errorf("not implemented")
+}
+
+func (*mockClient) ListLanguages(_ context.Context, _, _ string) ([]string, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (*mockClient) ListOpenIssues(_ context.Context, _, _ string) ([]*ghclient.Issue, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (*mockClient) CreateIssue(_ context.Context, _, _, _, _ string) (*ghclient.Issue, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func TestReconcileAll(t *testing.T) {
 	t.Parallel()
 
@@ -142,6 +188,99 @@ func TestReconcileAll_SkipsArchived(t *testing.T) {
 	}
 }
 
+func TestReconcileAll_WaitsForCapacity(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	client.installations = []*ghclient.Installation{
+		{ID: 1, Account: "org1"},
+	}
+	client.installRepos[1] = []*ghclient.Repository{
+		{Owner: "org1", Name: "repo-a"},
+		{Owner: "org1", Name: "repo-b"},
+		{Owner: "org1", Name: "repo-c"},
+	}
+
+	// Nothing drains the queue, so the scheduler waits until its context
+	// ends and reports the repos that did not fit as dropped.
+	q := checker.NewQueue(1, slog.Default())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	s := NewScheduler(client, q, time.Hour, slog.Default(), true, true)
+
+	stats := s.reconcileAll(ctx)
+
+	want := reconcileStats{enqueued: 1, dropped: 2}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestReconcileAll_SkipsSuspendedInstallations(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	client.installations = []*ghclient.Installation{
+		{ID: 1, Account: "org1"},
+		{ID: 2, Account: "org2", Suspended: true},
+		{ID: 3, Account: "org3"},
+	}
+
+	for id := range int64(3) {
+		client.installRepos[id+1] = []*ghclient.Repository{{Owner: fmt.Sprintf("org%d", id+1), Name: "repo"}}
+	}
+
+	q := checker.NewQueue(100, slog.Default())
+
+	// Installation 3 was suspended by a webhook since the listing.
+	if _, err := q.SuspendInstallation(3); err != nil {
+		t.Fatalf("SuspendInstallation: %v", err)
+	}
+
+	s := NewScheduler(client, q, time.Hour, slog.Default(), true, true)
+
+	if stats := s.reconcileAll(context.Background()); stats.enqueued != 1 || stats.dropped != 0 {
+		t.Errorf("stats = %+v, want only installation 1 enqueued", stats)
+	}
+}
+
+func TestRequestReconcile(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	q := checker.NewQueue(100, slog.Default())
+	s := NewScheduler(client, q, time.Hour, slog.Default(), true, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go s.Start(ctx)
+
+	// The startup pass finds nothing; the installation appears afterwards,
+	// as after an unsuspend.
+	client.mu.Lock()
+	client.installRepos[7] = []*ghclient.Repository{
+		{Owner: "org7", Name: "repo-a"},
+		{Owner: "org7", Name: "repo-b"},
+	}
+	client.mu.Unlock()
+
+	if !s.RequestReconcile(7) {
+		t.Fatal("RequestReconcile should accept the request")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Len() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 jobs for installation 7, got %d", q.Len())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestStart_RunsOnStartup(t *testing.T) {
 	t.Parallel()
 
@@ -220,3 +359,144 @@ func TestReconcileAll_ListInstallationsError(t *testing.T) {
 		t.Errorf("expected 0 jobs on error, got %d", qLen)
 	}
 }
+
+func TestFirstRun(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.March, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		lastRun time.Time // zero writes no state file
+		want    time.Time
+	}{
+		{
+			name: "no state runs now",
+			want: now,
+		},
+		{
+			name:    "recent run waits for the schedule",
+			lastRun: now.Add(-time.Hour),
+			want:    now.Add(23 * time.Hour),
+		},
+		{
+			name:    "missed run runs now",
+			lastRun: now.Add(-48 * time.Hour),
+			want:    now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "scheduler.json")
+
+			if !tt.lastRun.IsZero() {
+				if err := saveLastRun(path, tt.lastRun); err != nil {
+					t.Fatalf("saveLastRun: %v", err)
+				}
+			}
+
+			s := NewScheduler(newMockClient(), checker.NewQueue(1, slog.Default()), 24*time.Hour, slog.Default(), true, true)
+			s.SetStatePath(path)
+
+			if got := s.firstRun(now); !got.Equal(tt.want) {
+				t.Errorf("firstRun = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstRun_Cron(t *testing.T) {
+	t.Parallel()
+
+	cron, err := schedule.ParseCron("0 2 * * mon-fri")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "scheduler.json")
+
+	// Friday's run happened; the restart on Saturday waits for Monday.
+	if err := saveLastRun(path, time.Date(2026, time.March, 6, 2, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("saveLastRun: %v", err)
+	}
+
+	s := NewScheduler(newMockClient(), checker.NewQueue(1, slog.Default()), time.Hour, slog.Default(), true, true)
+	s.SetSchedule(cron)
+	s.SetStatePath(path)
+
+	want := time.Date(2026, time.March, 9, 2, 0, 0, 0, time.UTC)
+	if got := s.firstRun(time.Date(2026, time.March, 7, 15, 0, 0, 0, time.UTC)); !got.Equal(want) {
+		t.Errorf("firstRun = %v, want %v", got, want)
+	}
+}
+
+func TestRun_RecordsCompleteRuns(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	client.installations = []*ghclient.Installation{{ID: 1, Account: "org1"}}
+	client.installRepos[1] = []*ghclient.Repository{{Owner: "org1", Name: "repo-a"}}
+
+	path := filepath.Join(t.TempDir(), "scheduler.json")
+
+	s := NewScheduler(client, checker.NewQueue(100, slog.Default()), time.Hour, slog.Default(), true, true)
+	s.SetStatePath(path)
+
+	// A pass that cannot list the installations is not recorded.
+	client.listInstallErr = fmt.Errorf("API error")
+	s.run(context.Background())
+
+	if last, err := loadLastRun(path); err != nil || !last.IsZero() {
+		t.Fatalf("loadLastRun after failed pass = %v, %v; want no run recorded", last, err)
+	}
+
+	client.listInstallErr = nil
+	start := time.Now()
+	s.run(context.Background())
+
+	last, err := loadLastRun(path)
+	if err != nil {
+		t.Fatalf("loadLastRun: %v", err)
+	}
+
+	if last.Before(start.Add(-time.Second)) || last.After(time.Now()) {
+		t.Errorf("last run = %v, want the start of the pass at %v", last, start)
+	}
+}
+
+func TestStart_WaitsAfterRecentRun(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient()
+	client.installations = []*ghclient.Installation{{ID: 1, Account: "org1"}}
+	client.installRepos[1] = []*ghclient.Repository{{Owner: "org1", Name: "repo-a"}}
+
+	path := filepath.Join(t.TempDir(), "scheduler.json")
+	if err := saveLastRun(path, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("saveLastRun: %v", err)
+	}
+
+	q := checker.NewQueue(100, slog.Default())
+
+	s := NewScheduler(client, q, 24*time.Hour, slog.Default(), true, true)
+	s.SetStatePath(path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-done
+
+	if qLen := q.Len(); qLen != 0 {
+		t.Errorf("expected no startup reconciliation after a recent run, got %d jobs", qLen)
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// state is what the scheduler keeps on disk between restarts.
+type state struct {
+	// LastRun is when the last complete reconciliation started.
+	LastRun time.Time `json:"lastRun"`
+}
+
+// loadLastRun returns the last run recorded at path, or the zero Time if
+// the file does not exist yet.
+func loadLastRun(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading scheduler state: %w", err)
+	}
+
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return time.Time{}, fmt.Errorf("decoding scheduler state %s: %w", path, err)
+	}
+
+	return st.LastRun, nil
+}
+
+// saveLastRun records t as the last run at path. The file is replaced
+// atomically, so a crash never leaves it half-written.
+func saveLastRun(path string, t time.Time) error {
+	data, err := json.Marshal(state{LastRun: t.UTC()})
+	if err != nil {
+		return fmt.Errorf("encoding scheduler state: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("creating scheduler state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("writing scheduler state: %w", err)
+	}
+
+	// Clean up after a failure; once renamed, there is nothing to remove.
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing scheduler state: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing scheduler state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing scheduler state: %w", err)
+	}
+
+	return nil
+}
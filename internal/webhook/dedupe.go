@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupe remembers the delivery IDs seen within a window so that deliveries
+// GitHub sends more than once are processed only once.
+type dedupe struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	order []claimed // oldest first
+}
+
+type claimed struct {
+	id string
+	at time.Time
+}
+
+func newDedupe(window time.Duration) *dedupe {
+	return &dedupe{
+		window: window,
+		now:    time.Now,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// claim records id and reports whether it was not already seen within the
+// window.
+func (d *dedupe) claim(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+
+	// Forget IDs claimed more than a window ago. An ID released and claimed
+	// again since has a newer time in seen and is kept.
+	for len(d.order) > 0 && now.Sub(d.order[0].at) >= d.window {
+		if at, ok := d.seen[d.order[0].id]; ok && at.Equal(d.order[0].at) {
+			delete(d.seen, d.order[0].id)
+		}
+
+		d.order = d.order[1:]
+	}
+
+	if _, ok := d.seen[id]; ok {
+		return false
+	}
+
+	d.seen[id] = now
+	d.order = append(d.order, claimed{id: id, at: now})
+
+	return true
+}
+
+// release forgets id, so a redelivery of a delivery that failed is
+// processed again.
+func (d *dedupe) release(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.seen, id)
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupe(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	d := newDedupe(time.Hour)
+	d.now = func() time.Time { return now }
+
+	if !d.claim("a") {
+		t.Fatal("first delivery of a should be claimed")
+	}
+
+	if d.claim("a") {
+		t.Error("redelivery of a within the window should be dropped")
+	}
+
+	d.release("a")
+
+	if !d.claim("a") {
+		t.Error("released delivery a should be claimed again")
+	}
+
+	now = now.Add(30 * time.Minute)
+
+	if !d.claim("b") {
+		t.Fatal("first delivery of b should be claimed")
+	}
+
+	now = now.Add(45 * time.Minute)
+
+	if !d.claim("a") {
+		t.Error("a should be forgotten once the window has passed")
+	}
+
+	if d.claim("b") {
+		t.Error("b is still within the window and should be dropped")
+	}
+
+	if len(d.order) != 2 {
+		t.Errorf("expired entries should be trimmed, have %d", len(d.order))
+	}
+}
@@ -2,92 +2,414 @@
 package webhook
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	gh "github.com/google/go-github/v68/github"
 
 	"github.com/donaldgifford/repo-guardian/internal/checker"
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
 	"github.com/donaldgifford/repo-guardian/internal/metrics"
+	"github.com/donaldgifford/repo-guardian/internal/repoconfig"
 )
 
+// ErrPayloadStoreDisabled is returned when replaying a delivery without a
+// PayloadStore configured.
+var ErrPayloadStoreDisabled = errors.New("webhook payload store is disabled")
+
+// Reconciler checks every repository of an installation, e.g. the
+// scheduler.
+type Reconciler interface {
+	// RequestReconcile schedules the reconciliation without blocking, and
+	// reports false if it cannot take the request.
+	RequestReconcile(installationID int64) bool
+}
+
 // Handler handles incoming GitHub webhook events and enqueues repo check jobs.
 type Handler struct {
-	webhookSecret []byte
-	queue         *checker.Queue
-	logger        *slog.Logger
+	queue      *checker.Queue
+	engine     *checker.Engine
+	client     ghclient.Client
+	reconciler Reconciler
+	logger     *slog.Logger
+	dedupe     *dedupe
+	payloads   *PayloadStore
+
+	// secrets are the accepted webhook secrets, current first.
+	secretsMu sync.RWMutex
+	secrets   [][]byte
+
+	// intake holds accepted deliveries until a worker handles them. It is
+	// nil until Start and closed by Stop; mu guards both.
+	mu      sync.RWMutex
+	intake  chan intakeItem
+	stopped bool
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// NewHandler creates a new webhook Handler. Deliveries signed with any of
+// webhookSecrets are accepted; list the current secret first and keep the
+// previous one while a rotation rolls out. engine and client handle events
+// about repo-guardian's own pull requests.
+func NewHandler(
+	webhookSecrets []string,
+	queue *checker.Queue,
+	engine *checker.Engine,
+	client ghclient.Client,
+	logger *slog.Logger,
+) *Handler {
+	h := &Handler{
+		queue:  queue,
+		engine: engine,
+		client: client,
+		logger: logger,
+	}
+
+	h.SetSecrets(webhookSecrets)
+
+	return h
 }
 
-// NewHandler creates a new webhook Handler.
-func NewHandler(webhookSecret string, queue *checker.Queue, logger *slog.Logger) *Handler {
-	return &Handler{
-		webhookSecret: []byte(webhookSecret),
-		queue:         queue,
-		logger:        logger,
+// SetDedupeWindow makes the handler drop deliveries whose X-GitHub-Delivery
+// ID it already saw within window. Zero disables deduplication. It must be
+// called before the handler serves requests.
+func (h *Handler) SetDedupeWindow(window time.Duration) {
+	h.dedupe = nil
+	if window > 0 {
+		h.dedupe = newDedupe(window)
 	}
 }
 
-// ServeHTTP implements http.Handler for GitHub webhook events.
+// SetReconciler sets what reconciles an installation after it is
+// unsuspended. Without one, unsuspending only resumes the installation. It
+// must be called before Start.
+func (h *Handler) SetReconciler(r Reconciler) {
+	h.reconciler = r
+}
+
+// SetPayloadStore makes the handler keep validated payloads in store so
+// they can be replayed. It must be called before the handler serves
+// requests.
+func (h *Handler) SetPayloadStore(store *PayloadStore) {
+	h.payloads = store
+}
+
+// ServeHTTP implements http.Handler for GitHub webhook events. It validates
+// and parses the delivery, hands it to the intake and answers 202 Accepted;
+// the event is handled afterwards by the intake workers. When the intake is
+// full it answers 503 with Retry-After.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	payload, err := gh.ValidatePayload(r, h.webhookSecret)
+	eventType := gh.WebHookType(r)
+	deliveryID := gh.DeliveryID(r)
+	replayed := isReplay(r.Context())
+
+	payload, err := h.readPayload(r, replayed)
 	if err != nil {
 		h.logger.Warn("invalid webhook payload", "error", err)
+		metrics.WebhookRejectedTotal.WithLabelValues("invalid_signature").Inc()
 		http.Error(w, "invalid payload", http.StatusUnauthorized)
 
 		return
 	}
 
-	event, err := gh.ParseWebHook(gh.WebHookType(r), payload)
+	// Parse before claiming the delivery ID, so a rejected delivery does not
+	// block its redelivery.
+	event, err := gh.ParseWebHook(eventType, payload)
 	if err != nil {
 		h.logger.Error("failed to parse webhook", "error", err)
+		metrics.WebhookRejectedTotal.WithLabelValues("bad_payload").Inc()
 		http.Error(w, "bad request", http.StatusBadRequest)
 
 		return
 	}
 
-	eventType := gh.WebHookType(r)
+	if replayed {
+		h.logger.Info("replaying webhook delivery", "delivery_id", deliveryID, "event_type", eventType)
+		metrics.WebhookReplayedTotal.WithLabelValues(eventType).Inc()
+	} else {
+		if h.dedupe != nil && deliveryID != "" && !h.dedupe.claim(deliveryID) {
+			h.logger.Info("dropping duplicate webhook delivery", "delivery_id", deliveryID, "event_type", eventType)
+			metrics.WebhookDuplicatesTotal.WithLabelValues(eventType).Inc()
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		h.savePayload(deliveryID, eventType, payload)
+	}
+
 	metrics.WebhookReceivedTotal.WithLabelValues(eventType).Inc()
 
+	if !handled(event) {
+		h.logger.Debug("ignoring unhandled event type", "type", eventType)
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	item := intakeItem{deliveryID: deliveryID, eventType: eventType, event: event, replayed: replayed}
+
+	if reason := h.accept(item); reason != "" {
+		h.logger.Warn("rejecting webhook delivery", "delivery_id", deliveryID, "event_type", eventType, "reason", reason)
+		metrics.WebhookRejectedTotal.WithLabelValues(reason).Inc()
+
+		// Let GitHub's redelivery of this delivery through.
+		h.forget(item)
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(intakeRetryAfter.Seconds())))
+		http.Error(w, "webhook intake unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	metrics.WebhookAcceptedTotal.WithLabelValues(eventType).Inc()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// readPayload returns the request body, validating its signature unless the
+// request is a replay of a stored delivery.
+func (h *Handler) readPayload(r *http.Request, replayed bool) ([]byte, error) {
+	if replayed {
+		return io.ReadAll(r.Body)
+	}
+
+	return h.validatePayload(r)
+}
+
+// savePayload keeps a validated payload for replay. Failing to store it does
+// not fail the delivery.
+func (h *Handler) savePayload(deliveryID, eventType string, payload []byte) {
+	if h.payloads == nil || deliveryID == "" {
+		return
+	}
+
+	err := h.payloads.Save(Delivery{
+		ID:         deliveryID,
+		Event:      eventType,
+		ReceivedAt: time.Now(),
+		Payload:    payload,
+	})
+	if err != nil {
+		h.logger.Warn("failed to store webhook payload", "delivery_id", deliveryID, "error", err)
+		metrics.ErrorsTotal.WithLabelValues("store_webhook_payload").Inc()
+	}
+}
+
+// handled reports whether the handler acts on event.
+func handled(event any) bool {
+	switch event.(type) {
+	case *gh.RepositoryEvent,
+		*gh.InstallationRepositoriesEvent,
+		*gh.InstallationEvent,
+		*gh.PullRequestEvent,
+		*gh.PushEvent:
+		return true
+	default:
+		return false
+	}
+}
+
+// handle acts on an accepted event.
+func (h *Handler) handle(ctx context.Context, event any) error {
 	switch e := event.(type) {
 	case *gh.RepositoryEvent:
-		h.handleRepositoryEvent(e)
+		return h.handleRepositoryEvent(ctx, e)
 	case *gh.InstallationRepositoriesEvent:
-		h.handleInstallationRepositoriesEvent(e)
+		return h.handleInstallationRepositoriesEvent(e)
 	case *gh.InstallationEvent:
-		h.handleInstallationEvent(e)
+		return h.handleInstallationEvent(e)
+	case *gh.PullRequestEvent:
+		return h.handlePullRequestEvent(ctx, e)
+	case *gh.PushEvent:
+		return h.handlePushEvent(e)
 	default:
-		h.logger.Debug("ignoring unhandled event type", "type", eventType)
-		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
 
-		return
+// Deliveries lists the stored deliveries, newest first, without their
+// payloads.
+func (h *Handler) Deliveries() ([]Delivery, error) {
+	if h.payloads == nil {
+		return nil, ErrPayloadStoreDisabled
 	}
 
-	w.WriteHeader(http.StatusOK)
+	return h.payloads.List(), nil
 }
 
-func (h *Handler) handleRepositoryEvent(e *gh.RepositoryEvent) {
-	if e.GetAction() != "created" {
-		h.logger.Debug("ignoring repository event", "action", e.GetAction())
-		return
+// Delivery returns the stored delivery with the given ID, including its
+// payload.
+func (h *Handler) Delivery(id string) (Delivery, error) {
+	if h.payloads == nil {
+		return Delivery{}, ErrPayloadStoreDisabled
+	}
+
+	return h.payloads.Get(id)
+}
+
+// replayKey marks a request built by ReplayRequest.
+type replayKey struct{}
+
+func isReplay(ctx context.Context) bool {
+	replayed, _ := ctx.Value(replayKey{}).(bool)
+	return replayed
+}
+
+// ReplayRequest builds a request that feeds the stored delivery id back
+// through ServeHTTP. The request is trusted: its signature is not checked
+// and it bypasses deduplication. Only a context value marks it, so a request
+// from the network can never pass as a replay.
+func (h *Handler) ReplayRequest(ctx context.Context, id string) (*http.Request, error) {
+	if h.payloads == nil {
+		return nil, ErrPayloadStoreDisabled
+	}
+
+	d, err := h.payloads.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, replayKey{}, true)
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/webhooks/github", bytes.NewReader(d.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("building replay request: %w", err)
 	}
 
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(gh.EventTypeHeader, d.Event)
+	r.Header.Set(gh.DeliveryIDHeader, d.ID)
+
+	return r, nil
+}
+
+// handleRepositoryEvent maps repository lifecycle actions to engine work:
+//   - created, unarchived, publicized, privatized: check the repository now.
+//   - renamed, transferred: drop the job pending under the old name or owner
+//     and check the repository under the new one.
+//   - transferred to an account other than the installation's: drop the
+//     pending job, close repo-guardian's open PRs and delete their branches.
+//   - archived: drop the pending job. An archived repository is read-only,
+//     so its PRs and branches are left alone; unarchiving checks it again,
+//     which updates a PR that is still open.
+//   - deleted: drop the pending job; the repository takes its PRs and
+//     branches with it.
+func (h *Handler) handleRepositoryEvent(ctx context.Context, e *gh.RepositoryEvent) error {
 	repo := e.GetRepo()
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
 	installID := e.GetInstallation().GetID()
 
-	h.logger.Info("repository created event",
-		"owner", repo.GetOwner().GetLogin(),
-		"repo", repo.GetName(),
+	log := h.logger.With(
+		"action", e.GetAction(),
+		"owner", owner,
+		"repo", name,
 		"installation_id", installID,
 	)
 
-	h.enqueue(repo.GetOwner().GetLogin(), repo.GetName(), installID)
+	switch e.GetAction() {
+	case "created", "unarchived", "publicized", "privatized":
+		log.Info("repository event, checking repository")
+	case "renamed":
+		oldName := e.GetChanges().GetRepo().GetName().GetFrom()
+		log.Info("repository renamed, checking repository", "old_name", oldName)
+		h.discard(log, owner, oldName, installID)
+	case "transferred":
+		from := e.GetChanges().GetOwner().GetOwnerInfo()
+		oldOwner := cmp.Or(from.GetOrg().GetLogin(), from.GetUser().GetLogin())
+		h.discardRepo(log, oldOwner, name)
+
+		if account := e.GetInstallation().GetAccount().GetLogin(); account != "" && !strings.EqualFold(account, owner) {
+			log.Info("repository transferred out of the installation, withdrawing PRs", "old_owner", oldOwner)
+			return h.withdraw(ctx, log, owner, name, installID)
+		}
+
+		log.Info("repository transferred, checking repository", "old_owner", oldOwner)
+	case "archived", "deleted":
+		log.Info("repository archived or deleted, dropping pending work")
+		h.discard(log, owner, name, installID)
+
+		return nil
+	default:
+		log.Debug("ignoring repository event")
+		return nil
+	}
+
+	return h.enqueue(owner, name, installID)
+}
+
+// withdraw closes repo-guardian's open PRs in a repository it no longer looks
+// after and deletes their branches.
+func (h *Handler) withdraw(ctx context.Context, log *slog.Logger, owner, repo string, installationID int64) error {
+	client, err := h.client.CreateInstallationClient(ctx, installationID)
+	if err != nil {
+		log.Error("failed to create installation client", "error", err)
+		metrics.ErrorsTotal.WithLabelValues("create_install_client").Inc()
+
+		return fmt.Errorf("creating installation client: %w", err)
+	}
+
+	if err := h.engine.WithdrawPRs(ctx, client, owner, repo); err != nil {
+		log.Error("failed to withdraw PRs", "error", err)
+		metrics.ErrorsTotal.WithLabelValues("withdraw_prs").Inc()
+
+		return fmt.Errorf("withdrawing PRs: %w", err)
+	}
+
+	return nil
+}
+
+// discard drops the pending job for a repository, if any.
+func (h *Handler) discard(log *slog.Logger, owner, repo string, installationID int64) {
+	if owner == "" || repo == "" {
+		return
+	}
+
+	dropped, err := h.queue.Discard(owner, repo, installationID)
+	if err != nil {
+		log.Error("failed to discard pending job", "pending_owner", owner, "pending_repo", repo, "error", err)
+		return
+	}
+
+	if dropped {
+		log.Info("discarded pending job", "pending_owner", owner, "pending_repo", repo)
+	}
+}
+
+// discardRepo drops the pending jobs for a repository under any
+// installation. A repository transferred between accounts may have been
+// queued by the installation it left.
+func (h *Handler) discardRepo(log *slog.Logger, owner, repo string) {
+	if owner == "" || repo == "" {
+		return
+	}
+
+	dropped, err := h.queue.DiscardRepo(owner, repo)
+	if err != nil {
+		log.Error("failed to discard pending jobs", "pending_owner", owner, "pending_repo", repo, "error", err)
+		return
+	}
+
+	if dropped > 0 {
+		log.Info("discarded pending jobs", "pending_owner", owner, "pending_repo", repo, "dropped", dropped)
+	}
 }
 
-func (h *Handler) handleInstallationRepositoriesEvent(e *gh.InstallationRepositoriesEvent) {
+func (h *Handler) handleInstallationRepositoriesEvent(e *gh.InstallationRepositoriesEvent) error {
 	if e.GetAction() != "added" {
 		h.logger.Debug("ignoring installation_repositories event", "action", e.GetAction())
-		return
+		return nil
 	}
 
 	installID := e.GetInstallation().GetID()
@@ -97,35 +419,213 @@ func (h *Handler) handleInstallationRepositoriesEvent(e *gh.InstallationReposito
 		"installation_id", installID,
 	)
 
+	var errs []error
+
 	for _, repo := range e.RepositoriesAdded {
-		h.enqueue(extractOwner(repo.GetFullName()), repo.GetName(), installID)
+		errs = append(errs, h.enqueue(extractOwner(repo.GetFullName()), repo.GetName(), installID))
 	}
+
+	return errors.Join(errs...)
 }
 
-func (h *Handler) handleInstallationEvent(e *gh.InstallationEvent) {
-	if e.GetAction() != "created" {
+// handleInstallationEvent checks the repositories of a new installation.
+// When an installation is deleted or suspended its pending jobs are dropped
+// and its cached token is forgotten. A suspended installation is skipped
+// until it is unsuspended, which reconciles all of its repositories.
+func (h *Handler) handleInstallationEvent(e *gh.InstallationEvent) error {
+	installID := e.GetInstallation().GetID()
+
+	switch e.GetAction() {
+	case "created":
+	case "deleted", "suspend":
+		return h.suspendInstallation(e.GetAction(), installID)
+	case "unsuspend":
+		return h.resumeInstallation(installID)
+	default:
 		h.logger.Debug("ignoring installation event", "action", e.GetAction())
-		return
+		return nil
 	}
 
-	installID := e.GetInstallation().GetID()
-
 	h.logger.Info("new installation created",
 		"count", len(e.Repositories),
 		"installation_id", installID,
 	)
 
+	var errs []error
+
 	for _, repo := range e.Repositories {
-		h.enqueue(extractOwner(repo.GetFullName()), repo.GetName(), installID)
+		errs = append(errs, h.enqueue(extractOwner(repo.GetFullName()), repo.GetName(), installID))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *Handler) suspendInstallation(action string, installationID int64) error {
+	var (
+		dropped int
+		err     error
+	)
+
+	if action == "deleted" {
+		dropped, err = h.queue.RemoveInstallation(installationID)
+	} else {
+		dropped, err = h.queue.SuspendInstallation(installationID)
+	}
+
+	h.client.ForgetInstallation(installationID)
+
+	h.logger.Info("installation removed or suspended, dropping pending work",
+		"action", action,
+		"installation_id", installationID,
+		"dropped", dropped,
+	)
+
+	if err != nil {
+		h.logger.Error("failed to drop pending jobs", "installation_id", installationID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (h *Handler) resumeInstallation(installationID int64) error {
+	h.queue.ResumeInstallation(installationID)
+
+	if h.reconciler == nil {
+		h.logger.Warn("installation unsuspended, but no reconciler is set; its repositories are checked on the next event",
+			"installation_id", installationID,
+		)
+
+		return nil
+	}
+
+	if !h.reconciler.RequestReconcile(installationID) {
+		h.logger.Error("too many reconciliations pending, not reconciling unsuspended installation",
+			"installation_id", installationID,
+		)
+
+		return fmt.Errorf("requesting reconciliation of installation %d: too many pending", installationID)
+	}
+
+	h.logger.Info("installation unsuspended, reconciling", "installation_id", installationID)
+
+	return nil
+}
+
+// handlePullRequestEvent tracks merges and declines of repo-guardian's own
+// PRs. PRs from forks are ignored even if their branch name matches.
+func (h *Handler) handlePullRequestEvent(ctx context.Context, e *gh.PullRequestEvent) error {
+	pr := e.GetPullRequest()
+	head := pr.GetHead()
+
+	_, managed := checker.ManagedBranchKind(head.GetRef())
+	if e.GetAction() != "closed" || !managed || head.GetRepo().GetID() != e.GetRepo().GetID() {
+		h.logger.Debug("ignoring pull_request event", "action", e.GetAction(), "branch", head.GetRef())
+		return nil
+	}
+
+	owner, repo := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	installID := e.GetInstallation().GetID()
+
+	client, err := h.client.CreateInstallationClient(ctx, installID)
+	if err != nil {
+		h.logger.Error("failed to create installation client",
+			"installation_id", installID,
+			"error", err,
+		)
+		metrics.ErrorsTotal.WithLabelValues("create_install_client").Inc()
+
+		return fmt.Errorf("creating installation client: %w", err)
+	}
+
+	closed := &ghclient.PullRequest{
+		Number:   pr.GetNumber(),
+		Title:    pr.GetTitle(),
+		Head:     head.GetRef(),
+		State:    pr.GetState(),
+		Body:     pr.GetBody(),
+		Merged:   pr.GetMerged(),
+		ClosedAt: pr.GetClosedAt().Time,
+	}
+
+	for _, label := range pr.Labels {
+		closed.Labels = append(closed.Labels, label.GetName())
+	}
+
+	if err := h.engine.HandleClosedPR(ctx, client, owner, repo, closed); err != nil {
+		h.logger.Error("failed to handle closed PR",
+			"owner", owner,
+			"repo", repo,
+			"pr_number", closed.Number,
+			"error", err,
+		)
+		metrics.ErrorsTotal.WithLabelValues("pr_closed").Inc()
+
+		return fmt.Errorf("handling closed PR: %w", err)
 	}
+
+	return nil
+}
+
+// handlePushEvent re-checks custom properties when a push to the default
+// branch changes one of their inputs.
+func (h *Handler) handlePushEvent(e *gh.PushEvent) error {
+	repo := e.GetRepo()
+
+	if !h.engine.CustomPropertiesEnabled() ||
+		e.GetDeleted() ||
+		e.GetRef() != "refs/heads/"+repo.GetDefaultBranch() ||
+		!touchesPropertiesInputs(e) {
+		h.logger.Debug("ignoring push event", "repo", repo.GetFullName(), "ref", e.GetRef())
+		return nil
+	}
+
+	installID := e.GetInstallation().GetID()
+
+	h.logger.Info("properties inputs changed on default branch",
+		"owner", extractOwner(repo.GetFullName()),
+		"repo", repo.GetName(),
+		"installation_id", installID,
+	)
+
+	return h.enqueueKind(extractOwner(repo.GetFullName()), repo.GetName(), installID, checker.KindProperties)
+}
+
+// propertiesInputs are the files custom properties are derived from.
+var propertiesInputs = []string{"catalog-info.yaml", "catalog-info.yml", repoconfig.Path}
+
+// touchesPropertiesInputs reports whether any commit in the push added,
+// changed or removed a properties input. Payloads list at most 20 commits,
+// so a larger push is assumed to touch them.
+func touchesPropertiesInputs(e *gh.PushEvent) bool {
+	if e.GetSize() > len(e.Commits) {
+		return true
+	}
+
+	for _, c := range e.Commits {
+		for _, files := range [][]string{c.Added, c.Modified, c.Removed} {
+			for _, f := range files {
+				if slices.Contains(propertiesInputs, f) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (h *Handler) enqueue(owner, repo string, installationID int64) error {
+	return h.enqueueKind(owner, repo, installationID, checker.KindFull)
 }
 
-func (h *Handler) enqueue(owner, repo string, installationID int64) {
+func (h *Handler) enqueueKind(owner, repo string, installationID int64, kind checker.JobKind) error {
 	job := checker.RepoJob{
 		Owner:          owner,
 		Repo:           repo,
 		InstallationID: installationID,
 		Trigger:        checker.TriggerWebhook,
+		Kind:           kind,
 	}
 
 	if err := h.queue.Enqueue(job); err != nil {
@@ -134,7 +634,11 @@ func (h *Handler) enqueue(owner, repo string, installationID int64) {
 			"repo", repo,
 			"error", err,
 		)
+
+		return fmt.Errorf("enqueueing %s/%s: %w", owner, repo, err)
 	}
+
+	return nil
 }
 
 // extractOwner gets the owner from a "owner/repo" full name string.
@@ -2,18 +2,27 @@ package webhook
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	gh "github.com/google/go-github/v68/github"
 
 	"github.com/donaldgifford/repo-guardian/internal/checker"
+	ghclient "github.com/donaldgifford/repo-guardian/internal/github"
+	"github.com/donaldgifford/repo-guardian/internal/rules"
 )
 
 const testSecret = "test-secret"
@@ -45,7 +54,7 @@ func TestHandleWebhook_RepositoryCreated(t *testing.T) {
 	t.Parallel()
 
 	q := checker.NewQueue(10, slog.Default())
-	h := NewHandler(testSecret, q, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
 
 	payload := &gh.RepositoryEvent{
 		Action: gh.Ptr("created"),
@@ -56,11 +65,10 @@ func TestHandleWebhook_RepositoryCreated(t *testing.T) {
 		Installation: &gh.Installation{ID: gh.Ptr(int64(123))},
 	}
 
-	rr := httptest.NewRecorder()
-	h.ServeHTTP(rr, makeRequest(t, "repository", payload))
+	rr := serve(t, h, makeRequest(t, "repository", payload))
 
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", rr.Code)
 	}
 }
 
@@ -68,7 +76,7 @@ func TestHandleWebhook_InstallationReposAdded(t *testing.T) {
 	t.Parallel()
 
 	q := checker.NewQueue(10, slog.Default())
-	h := NewHandler(testSecret, q, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
 
 	payload := &gh.InstallationRepositoriesEvent{
 		Action:       gh.Ptr("added"),
@@ -79,11 +87,10 @@ func TestHandleWebhook_InstallationReposAdded(t *testing.T) {
 		},
 	}
 
-	rr := httptest.NewRecorder()
-	h.ServeHTTP(rr, makeRequest(t, "installation_repositories", payload))
+	rr := serve(t, h, makeRequest(t, "installation_repositories", payload))
 
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", rr.Code)
 	}
 }
 
@@ -91,7 +98,7 @@ func TestHandleWebhook_InstallationCreated(t *testing.T) {
 	t.Parallel()
 
 	q := checker.NewQueue(10, slog.Default())
-	h := NewHandler(testSecret, q, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
 
 	payload := &gh.InstallationEvent{
 		Action:       gh.Ptr("created"),
@@ -101,11 +108,10 @@ func TestHandleWebhook_InstallationCreated(t *testing.T) {
 		},
 	}
 
-	rr := httptest.NewRecorder()
-	h.ServeHTTP(rr, makeRequest(t, "installation", payload))
+	rr := serve(t, h, makeRequest(t, "installation", payload))
 
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", rr.Code)
 	}
 }
 
@@ -113,7 +119,7 @@ func TestHandleWebhook_InvalidSignature(t *testing.T) {
 	t.Parallel()
 
 	q := checker.NewQueue(10, slog.Default())
-	h := NewHandler(testSecret, q, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
 
 	body := []byte(`{"action":"created"}`)
 	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
@@ -121,8 +127,7 @@ func TestHandleWebhook_InvalidSignature(t *testing.T) {
 	req.Header.Set("X-GitHub-Event", "repository")
 	req.Header.Set("X-Hub-Signature-256", "sha256=invalid")
 
-	rr := httptest.NewRecorder()
-	h.ServeHTTP(rr, req)
+	rr := serve(t, h, req)
 
 	if rr.Code != http.StatusUnauthorized {
 		t.Errorf("expected 401, got %d", rr.Code)
@@ -133,12 +138,11 @@ func TestHandleWebhook_UnsupportedEvent(t *testing.T) {
 	t.Parallel()
 
 	q := checker.NewQueue(10, slog.Default())
-	h := NewHandler(testSecret, q, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
 
 	payload := map[string]string{"action": "completed"}
 
-	rr := httptest.NewRecorder()
-	h.ServeHTTP(rr, makeRequest(t, "check_run", payload))
+	rr := serve(t, h, makeRequest(t, "check_run", payload))
 
 	if rr.Code != http.StatusNoContent {
 		t.Errorf("expected 204, got %d", rr.Code)
@@ -149,10 +153,10 @@ func TestHandleWebhook_IgnoredAction(t *testing.T) {
 	t.Parallel()
 
 	q := checker.NewQueue(10, slog.Default())
-	h := NewHandler(testSecret, q, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
 
 	payload := &gh.RepositoryEvent{
-		Action: gh.Ptr("deleted"),
+		Action: gh.Ptr("edited"),
 		Repo: &gh.Repository{
 			Name:  gh.Ptr("some-repo"),
 			Owner: &gh.User{Login: gh.Ptr("myorg")},
@@ -160,12 +164,658 @@ func TestHandleWebhook_IgnoredAction(t *testing.T) {
 		Installation: &gh.Installation{ID: gh.Ptr(int64(123))},
 	}
 
+	rr := serve(t, h, makeRequest(t, "repository", payload))
+
+	// Ignored actions are still accepted; the action is checked when the
+	// event is handled.
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", rr.Code)
+	}
+}
+
+func TestHandleWebhook_RepositoryLifecycle(t *testing.T) {
+	t.Parallel()
+
+	const current = "payments-api"
+
+	tests := []struct {
+		payload      string
+		seed         [2]string // owner, repo of a job pending before the event
+		wantPending  bool      // whether myorg/payments-api is pending after
+		wantWithdraw bool      // whether repo-guardian's PR is closed
+	}{
+		{payload: "repository_renamed.json", seed: [2]string{"myorg", "payments"}, wantPending: true},
+		{payload: "repository_transferred.json", seed: [2]string{"oldorg", current}, wantPending: true},
+		{payload: "repository_unarchived.json", wantPending: true},
+		{payload: "repository_privatized.json", wantPending: true},
+		{payload: "repository_archived.json", seed: [2]string{"myorg", current}},
+		{payload: "repository_deleted.json", seed: [2]string{"myorg", current}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.payload, func(t *testing.T) {
+			t.Parallel()
+
+			body, err := os.ReadFile(filepath.Join("testdata", tt.payload))
+			if err != nil {
+				t.Fatalf("reading payload: %v", err)
+			}
+
+			client := &prClient{
+				labeled: make(map[int][]string),
+				openPRs: []*ghclient.PullRequest{{Number: 3, Head: checker.BranchName, State: "open"}},
+			}
+			h, q := newEngineHandler(client, "")
+
+			if tt.seed[0] != "" {
+				seed := checker.RepoJob{Owner: tt.seed[0], Repo: tt.seed[1], InstallationID: 123, Trigger: checker.TriggerScheduler}
+				if err := q.Enqueue(seed); err != nil {
+					t.Fatalf("Enqueue: %v", err)
+				}
+			}
+
+			rr := serve(t, h, makeRequest(t, "repository", json.RawMessage(body)))
+
+			if rr.Code != http.StatusAccepted {
+				t.Errorf("expected 202, got %d", rr.Code)
+			}
+
+			// The job pending under the old name, owner, or for an archived or
+			// deleted repository is gone.
+			if tt.seed[0] != "" && (tt.seed[0] != "myorg" || tt.seed[1] != current) {
+				if dropped, _ := q.Discard(tt.seed[0], tt.seed[1], 123); dropped {
+					t.Errorf("job for %s/%s should have been discarded", tt.seed[0], tt.seed[1])
+				}
+			}
+
+			if pending, _ := q.Discard("myorg", current, 123); pending != tt.wantPending {
+				t.Errorf("myorg/%s pending = %v, want %v", current, pending, tt.wantPending)
+			}
+
+			if q.Len() != 0 {
+				t.Errorf("unexpected extra jobs: %d", q.Len())
+			}
+
+			if withdrawn := len(client.closed) == 1 && len(client.deletedBranches) == 1; withdrawn != tt.wantWithdraw {
+				t.Errorf("closed PRs %v and deleted branches %v, want withdrawn = %v",
+					client.closed, client.deletedBranches, tt.wantWithdraw)
+			}
+		})
+	}
+}
+
+func TestHandleWebhook_RepositoryTransferredOut(t *testing.T) {
+	t.Parallel()
+
+	client := &prClient{
+		labeled: make(map[int][]string),
+		openPRs: []*ghclient.PullRequest{
+			{Number: 3, Head: checker.BranchName, State: "open"},
+			{Number: 4, Head: "feature/x", State: "open"},
+		},
+	}
+	h, q := newEngineHandler(client, "")
+
+	event := &gh.RepositoryEvent{
+		Action: gh.Ptr("transferred"),
+		Repo: &gh.Repository{
+			Name:  gh.Ptr("payments-api"),
+			Owner: &gh.User{Login: gh.Ptr("neworg")},
+		},
+		Changes: &gh.EditChange{
+			Owner: &gh.EditOwner{OwnerInfo: &gh.OwnerInfo{Org: &gh.User{Login: gh.Ptr("myorg")}}},
+		},
+		Installation: &gh.Installation{ID: gh.Ptr(int64(123)), Account: &gh.User{Login: gh.Ptr("myorg")}},
+	}
+
+	if rr := serve(t, h, makeRequest(t, "repository", event)); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rr.Code)
+	}
+
+	if len(client.closed) != 1 || client.closed[0] != 3 {
+		t.Errorf("closed PRs = %v, want only #3", client.closed)
+	}
+
+	if len(client.deletedBranches) != 1 || client.deletedBranches[0] != checker.BranchName {
+		t.Errorf("deleted branches = %v, want %s", client.deletedBranches, checker.BranchName)
+	}
+
+	if q.Len() != 0 {
+		t.Errorf("a repository transferred out should not be checked, got %d jobs", q.Len())
+	}
+}
+
+func TestHandleWebhook_RepositoryTransferredBetweenInstallations(t *testing.T) {
+	t.Parallel()
+
+	h, q := newEngineHandler(&prClient{labeled: make(map[int][]string)}, "")
+
+	// Queued by the installation the repository left.
+	if err := q.Enqueue(checker.RepoJob{Owner: "myorg", Repo: "payments-api", InstallationID: 999}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	event := &gh.RepositoryEvent{
+		Action: gh.Ptr("transferred"),
+		Repo: &gh.Repository{
+			Name:  gh.Ptr("payments-api"),
+			Owner: &gh.User{Login: gh.Ptr("neworg")},
+		},
+		Changes: &gh.EditChange{
+			Owner: &gh.EditOwner{OwnerInfo: &gh.OwnerInfo{Org: &gh.User{Login: gh.Ptr("myorg")}}},
+		},
+		Installation: &gh.Installation{ID: gh.Ptr(int64(123)), Account: &gh.User{Login: gh.Ptr("neworg")}},
+	}
+
+	if rr := serve(t, h, makeRequest(t, "repository", event)); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rr.Code)
+	}
+
+	if dropped, _ := q.Discard("myorg", "payments-api", 999); dropped {
+		t.Error("job queued by the old installation should have been discarded")
+	}
+
+	if pending, _ := q.Discard("neworg", "payments-api", 123); !pending {
+		t.Error("repository should be checked under its new owner")
+	}
+}
+
+func TestHandleWebhook_UnsuspendWithoutReconciler(t *testing.T) {
+	t.Parallel()
+
+	q := checker.NewQueue(10, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, &installClient{}, slog.Default())
+
+	for _, action := range []string{"suspend", "unsuspend"} {
+		event := &gh.InstallationEvent{
+			Action:       gh.Ptr(action),
+			Installation: &gh.Installation{ID: gh.Ptr(int64(123))},
+		}
+
+		if rr := serve(t, h, makeRequest(t, "installation", event)); rr.Code != http.StatusAccepted {
+			t.Errorf("%s: expected 202, got %d", action, rr.Code)
+		}
+	}
+
+	if q.InstallationSuspended(123) {
+		t.Error("unsuspend should resume installation 123")
+	}
+}
+
+// installClient records the installations whose credentials were dropped.
+// The embedded interface panics on any other method.
+type installClient struct {
+	ghclient.Client
+
+	mu        sync.Mutex
+	forgotten []int64
+}
+
+func (c *installClient) ForgetInstallation(installationID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.forgotten = append(c.forgotten, installationID)
+}
+
+// reconciler records reconciliation requests.
+type reconciler struct {
+	mu        sync.Mutex
+	requested []int64
+}
+
+func (r *reconciler) RequestReconcile(installationID int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requested = append(r.requested, installationID)
+
+	return true
+}
+
+func TestHandleWebhook_InstallationLifecycle(t *testing.T) {
+	t.Parallel()
+
+	q := checker.NewQueue(10, slog.Default())
+	client := &installClient{}
+	rec := &reconciler{}
+
+	h := NewHandler([]string{testSecret}, q, nil, client, slog.Default())
+	h.SetReconciler(rec)
+
+	for _, job := range []checker.RepoJob{
+		{Owner: "myorg", Repo: "a", InstallationID: 123, Trigger: checker.TriggerScheduler},
+		{Owner: "myorg", Repo: "b", InstallationID: 123, Trigger: checker.TriggerScheduler},
+		{Owner: "other", Repo: "c", InstallationID: 456, Trigger: checker.TriggerScheduler},
+	} {
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	send := func(action string, installationID int64) {
+		t.Helper()
+
+		event := &gh.InstallationEvent{
+			Action:       gh.Ptr(action),
+			Installation: &gh.Installation{ID: gh.Ptr(installationID)},
+		}
+
+		if rr := serve(t, h, makeRequest(t, "installation", event)); rr.Code != http.StatusAccepted {
+			t.Errorf("%s: expected 202, got %d", action, rr.Code)
+		}
+	}
+
+	send("suspend", 123)
+
+	if q.Len() != 1 || !q.InstallationSuspended(123) {
+		t.Errorf("suspend should drop installation 123's jobs and suspend it: pending %d", q.Len())
+	}
+
+	send("unsuspend", 123)
+
+	if q.InstallationSuspended(123) {
+		t.Error("unsuspend should resume installation 123")
+	}
+
+	send("deleted", 456)
+
+	if q.Len() != 0 || q.InstallationSuspended(456) {
+		t.Errorf("deleted should drop installation 456's jobs and forget it: pending %d", q.Len())
+	}
+
+	if !slices.Equal(client.forgotten, []int64{123, 456}) {
+		t.Errorf("forgotten = %v, want [123 456]", client.forgotten)
+	}
+
+	if !slices.Equal(rec.requested, []int64{123}) {
+		t.Errorf("reconcile requested for %v, want [123]", rec.requested)
+	}
+}
+
+// prClient records the calls made while handling pull_request events. The
+// embedded interface panics on any other method.
+type prClient struct {
+	ghclient.Client
+
+	mu              sync.Mutex
+	openPRs         []*ghclient.PullRequest
+	closed          []int
+	deletedBranches []string
+	labeled         map[int][]string
+}
+
+func (c *prClient) ListOpenPullRequests(_ context.Context, _, _ string) ([]*ghclient.PullRequest, error) {
+	return c.openPRs, nil
+}
+
+func (c *prClient) ClosePullRequest(_ context.Context, _, _ string, number int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = append(c.closed, number)
+
+	return nil
+}
+
+func (c *prClient) CreateInstallationClient(_ context.Context, _ int64) (ghclient.Client, error) {
+	return c, nil
+}
+
+func (c *prClient) DeleteBranch(_ context.Context, _, _, branch string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deletedBranches = append(c.deletedBranches, branch)
+
+	return nil
+}
+
+func (c *prClient) AddLabels(_ context.Context, _, _ string, number int, labels []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.labeled[number] = append(c.labeled[number], labels...)
+
+	return nil
+}
+
+func newEngineHandler(client ghclient.Client, customPropertiesMode string) (*Handler, *checker.Queue) {
+	engine := checker.NewEngine(
+		rules.NewRegistry(rules.DefaultRules), rules.NewTemplateStore(), slog.Default(),
+		true, true, false, customPropertiesMode, nil, 0,
+	)
+	q := checker.NewQueue(10, slog.Default())
+
+	return NewHandler([]string{testSecret}, q, engine, client, slog.Default()), q
+}
+
+func closedPREvent(branch string, merged bool, headRepoID int64) *gh.PullRequestEvent {
+	repo := &gh.Repository{ID: gh.Ptr(int64(1)), Name: gh.Ptr("repo"), Owner: &gh.User{Login: gh.Ptr("myorg")}}
+
+	return &gh.PullRequestEvent{
+		Action: gh.Ptr("closed"),
+		Repo:   repo,
+		PullRequest: &gh.PullRequest{
+			Number: gh.Ptr(7),
+			State:  gh.Ptr("closed"),
+			Merged: gh.Ptr(merged),
+			Head: &gh.PullRequestBranch{
+				Ref:  gh.Ptr(branch),
+				Repo: &gh.Repository{ID: gh.Ptr(headRepoID)},
+			},
+		},
+		Installation: &gh.Installation{ID: gh.Ptr(int64(123))},
+	}
+}
+
+func TestHandleWebhook_DuplicateDelivery(t *testing.T) {
+	t.Parallel()
+
+	q := checker.NewQueue(10, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
+	h.SetDedupeWindow(time.Hour)
+
+	payload := &gh.RepositoryEvent{
+		Action:       gh.Ptr("created"),
+		Repo:         &gh.Repository{Name: gh.Ptr("new-repo"), Owner: &gh.User{Login: gh.Ptr("myorg")}},
+		Installation: &gh.Installation{ID: gh.Ptr(int64(123))},
+	}
+
+	send := func(deliveryID string, want int) {
+		t.Helper()
+
+		req := makeRequest(t, "repository", payload)
+		req.Header.Set("X-GitHub-Delivery", deliveryID)
+
+		if rr := serve(t, h, req); rr.Code != want {
+			t.Errorf("delivery %s: expected %d, got %d", deliveryID, want, rr.Code)
+		}
+	}
+
+	send("delivery-1", http.StatusAccepted)
+
+	// Take the job so that a job from the redelivery would be visible.
+	if dropped, _ := q.Discard("myorg", "new-repo", 123); !dropped {
+		t.Fatal("first delivery should enqueue")
+	}
+
+	send("delivery-1", http.StatusOK)
+
+	if q.Len() != 0 {
+		t.Errorf("duplicate delivery should not enqueue, got %d jobs", q.Len())
+	}
+
+	send("delivery-2", http.StatusAccepted)
+
+	if q.Len() != 1 {
+		t.Errorf("new delivery should enqueue, got %d jobs", q.Len())
+	}
+}
+
+func TestHandleWebhook_BadPayloadNotDeduplicated(t *testing.T) {
+	t.Parallel()
+
+	q := checker.NewQueue(10, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
+	h.SetDedupeWindow(time.Hour)
+
+	bad := makeRequest(t, "repository", json.RawMessage(`"not an event"`))
+	bad.Header.Set("X-GitHub-Delivery", "delivery-1")
+
+	if rr := serve(t, h, bad); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+
+	// A rejected delivery does not claim its ID, so a redelivery with the
+	// same ID is handled.
+	good := makeRequest(t, "repository", &gh.RepositoryEvent{
+		Action:       gh.Ptr("created"),
+		Repo:         &gh.Repository{Name: gh.Ptr("new-repo"), Owner: &gh.User{Login: gh.Ptr("myorg")}},
+		Installation: &gh.Installation{ID: gh.Ptr(int64(123))},
+	})
+	good.Header.Set("X-GitHub-Delivery", "delivery-1")
+
+	if rr := serve(t, h, good); rr.Code != http.StatusAccepted {
+		t.Errorf("expected redelivery to be accepted, got %d", rr.Code)
+	}
+}
+
+func TestHandleWebhook_Replay(t *testing.T) {
+	t.Parallel()
+
+	store, err := OpenPayloadStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("OpenPayloadStore: %v", err)
+	}
+
+	q := checker.NewQueue(10, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
+	h.SetDedupeWindow(time.Hour)
+	h.SetPayloadStore(store)
+
+	body, err := os.ReadFile(filepath.Join("testdata", "repository_unarchived.json"))
+	if err != nil {
+		t.Fatalf("reading payload: %v", err)
+	}
+
+	req := makeRequest(t, "repository", json.RawMessage(body))
+	req.Header.Set("X-GitHub-Delivery", "delivery-1")
+	serve(t, h, req)
+
+	if dropped, _ := q.Discard("myorg", "payments-api", 123); !dropped {
+		t.Fatal("expected the delivery to enqueue a job")
+	}
+
+	deliveries, err := h.Deliveries()
+	if err != nil || len(deliveries) != 1 || deliveries[0].ID != "delivery-1" || deliveries[0].Event != "repository" {
+		t.Fatalf("unexpected stored deliveries: %+v, %v", deliveries, err)
+	}
+
+	// The replay carries no signature and reuses a delivery ID already seen.
+	replay, err := h.ReplayRequest(t.Context(), "delivery-1")
+	if err != nil {
+		t.Fatalf("ReplayRequest: %v", err)
+	}
+
+	rr := serve(t, h, replay)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", rr.Code)
+	}
+
+	if q.Len() != 1 {
+		t.Errorf("expected replay to enqueue a job, got %d", q.Len())
+	}
+
+	// A request from the network without a signature is still refused.
+	forged := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+	forged.Header.Set("X-GitHub-Event", "repository")
+
+	rr = serve(t, h, forged)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for unsigned request, got %d", rr.Code)
+	}
+
+	if _, err := h.ReplayRequest(t.Context(), "missing"); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("expected ErrDeliveryNotFound, got %v", err)
+	}
+}
+
+func TestHandleWebhook_IntakeFull(t *testing.T) {
+	t.Parallel()
+
+	q := checker.NewQueue(10, slog.Default())
+	h := NewHandler([]string{testSecret}, q, nil, nil, slog.Default())
+	h.SetDedupeWindow(time.Hour)
+
+	// An intake with room for one delivery and no workers to drain it.
+	h.Start(t.Context(), 1, 0)
+
+	send := func(deliveryID string) *httptest.ResponseRecorder {
+		body, err := os.ReadFile(filepath.Join("testdata", "repository_unarchived.json"))
+		if err != nil {
+			t.Fatalf("reading payload: %v", err)
+		}
+
+		req := makeRequest(t, "repository", json.RawMessage(body))
+		req.Header.Set("X-GitHub-Delivery", deliveryID)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	if rr := send("delivery-1"); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rr.Code)
+	}
+
+	rr := send("delivery-2")
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when the intake is full, got %d", rr.Code)
+	}
+
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	// The rejected delivery is not remembered, so its redelivery is
+	// accepted once there is room again.
+	h.Stop(t.Context())
+	h.Start(t.Context(), 1, 0)
+
+	if rr := send("delivery-2"); rr.Code != http.StatusAccepted {
+		t.Errorf("expected redelivery to be accepted, got %d", rr.Code)
+	}
+
+	h.Stop(t.Context())
+
+	if rr := send("delivery-3"); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after Stop, got %d", rr.Code)
+	}
+}
+
+// serve sends req through h and returns once the delivery, if accepted,
+// has been handled.
+func serve(t *testing.T, h *Handler, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	h.Start(t.Context(), 10, 1)
+
 	rr := httptest.NewRecorder()
-	h.ServeHTTP(rr, makeRequest(t, "repository", payload))
+	h.ServeHTTP(rr, req)
+
+	h.Stop(t.Context())
+
+	return rr
+}
+
+func TestHandleWebhook_PullRequestClosed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		event       *gh.PullRequestEvent
+		wantDeleted []string
+		wantLabeled bool
+	}{
+		{
+			name:        "merged",
+			event:       closedPREvent(checker.BranchName, true, 1),
+			wantDeleted: []string{checker.BranchName},
+		},
+		{
+			name:        "declined",
+			event:       closedPREvent(checker.PropertiesBranchName, false, 1),
+			wantLabeled: true,
+		},
+		{
+			name:  "other branch",
+			event: closedPREvent("feature/x", true, 1),
+		},
+		{
+			name:  "fork with matching branch",
+			event: closedPREvent(checker.BranchName, true, 2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &prClient{labeled: make(map[int][]string)}
+			h, _ := newEngineHandler(client, "")
+
+			rr := serve(t, h, makeRequest(t, "pull_request", tt.event))
+
+			if rr.Code != http.StatusAccepted {
+				t.Errorf("expected 202, got %d", rr.Code)
+			}
+
+			if !slices.Equal(client.deletedBranches, tt.wantDeleted) {
+				t.Errorf("deleted branches = %v, want %v", client.deletedBranches, tt.wantDeleted)
+			}
+
+			if got := slices.Contains(client.labeled[7], checker.PRLabel); got != tt.wantLabeled {
+				t.Errorf("labeled = %v, want %v", client.labeled[7], tt.wantLabeled)
+			}
+		})
+	}
+}
+
+func pushEvent(ref string, size int, commits ...*gh.HeadCommit) *gh.PushEvent {
+	return &gh.PushEvent{
+		Ref:  gh.Ptr(ref),
+		Size: gh.Ptr(size),
+		Repo: &gh.PushEventRepository{
+			Name:          gh.Ptr("repo"),
+			FullName:      gh.Ptr("myorg/repo"),
+			DefaultBranch: gh.Ptr("main"),
+		},
+		Commits:      commits,
+		Installation: &gh.Installation{ID: gh.Ptr(int64(123))},
+	}
+}
+
+func TestHandleWebhook_Push(t *testing.T) {
+	t.Parallel()
+
+	catalogChange := &gh.HeadCommit{Modified: []string{"README.md", "catalog-info.yaml"}}
+	configChange := &gh.HeadCommit{Added: []string{".github/repo-guardian.yml"}}
+	otherChange := &gh.HeadCommit{Modified: []string{"main.go"}}
+
+	tests := []struct {
+		name    string
+		mode    string
+		event   *gh.PushEvent
+		wantLen int
+	}{
+		{name: "catalog-info changed", mode: "api", event: pushEvent("refs/heads/main", 2, otherChange, catalogChange), wantLen: 1},
+		{name: "repo config added", mode: "api", event: pushEvent("refs/heads/main", 1, configChange), wantLen: 1},
+		{name: "unrelated files", mode: "api", event: pushEvent("refs/heads/main", 1, otherChange)},
+		{name: "truncated commit list", mode: "api", event: pushEvent("refs/heads/main", 30, otherChange), wantLen: 1},
+		{name: "other branch", mode: "api", event: pushEvent("refs/heads/feature", 1, catalogChange)},
+		{name: "properties disabled", event: pushEvent("refs/heads/main", 1, catalogChange)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h, q := newEngineHandler(nil, tt.mode)
+
+			rr := serve(t, h, makeRequest(t, "push", tt.event))
+
+			if rr.Code != http.StatusAccepted {
+				t.Errorf("expected 202, got %d", rr.Code)
+			}
 
-	// Ignored actions still return 200 (event was handled, just not actionable).
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+			if q.Len() != tt.wantLen {
+				t.Errorf("expected %d jobs enqueued, got %d", tt.wantLen, q.Len())
+			}
+		})
 	}
 }
 
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+)
+
+// intakeRetryAfter is the Retry-After sent when the intake is saturated.
+const intakeRetryAfter = 30 * time.Second
+
+// Reasons a validated delivery is rejected by the intake.
+const (
+	rejectIntakeFull = "intake_full"
+	rejectStopped    = "stopped"
+)
+
+// intakeItem is an accepted delivery waiting to be handled.
+type intakeItem struct {
+	deliveryID string
+	eventType  string
+	event      any
+	replayed   bool
+}
+
+// Start creates an intake holding up to size accepted deliveries and starts
+// workers goroutines handling them. Until Start is called, and after Stop,
+// deliveries are rejected with 503. Deliveries being handled when ctx is
+// canceled run to completion; Stop waits for them.
+func (h *Handler) Start(ctx context.Context, size, workers int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.intake = make(chan intakeItem, size)
+	h.stopped = false
+	ctx, h.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	for range workers {
+		h.workers.Add(1)
+
+		go h.work(ctx, h.intake)
+	}
+}
+
+// Stop stops accepting deliveries and returns once the ones already accepted
+// have been handled. If ctx ends first, deliveries being handled are
+// canceled and the rest are dropped, left for GitHub to redeliver. Call it
+// after the HTTP server has shut down and before the work queue is stopped,
+// so accepted deliveries still reach the queue.
+func (h *Handler) Stop(ctx context.Context) {
+	h.mu.Lock()
+
+	if h.intake != nil && !h.stopped {
+		close(h.intake)
+	}
+
+	h.stopped = true
+	intake, cancel := h.intake, h.cancel
+	h.mu.Unlock()
+
+	finished := make(chan struct{})
+
+	go func() {
+		h.workers.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return
+	case <-ctx.Done():
+	}
+
+	h.logger.Warn("webhook intake drain timed out, dropping remaining deliveries", "remaining", len(intake))
+	cancel()
+	<-finished
+}
+
+// accept hands item to the intake without blocking. It returns the reason
+// when the intake cannot take it, or "".
+func (h *Handler) accept(item intakeItem) string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.intake == nil || h.stopped {
+		return rejectStopped
+	}
+
+	select {
+	case h.intake <- item:
+		metrics.WebhookIntakeDepth.Set(float64(len(h.intake)))
+		return ""
+	default:
+		return rejectIntakeFull
+	}
+}
+
+// forget lets a redelivery of item through deduplication, because it was
+// rejected or failed.
+func (h *Handler) forget(item intakeItem) {
+	if h.dedupe != nil && !item.replayed && item.deliveryID != "" {
+		h.dedupe.release(item.deliveryID)
+	}
+}
+
+func (h *Handler) work(ctx context.Context, intake <-chan intakeItem) {
+	defer h.workers.Done()
+
+	for item := range intake {
+		metrics.WebhookIntakeDepth.Set(float64(len(intake)))
+
+		if ctx.Err() != nil {
+			h.forget(item)
+			metrics.WebhookProcessedTotal.WithLabelValues(item.eventType, "dropped").Inc()
+
+			continue
+		}
+
+		outcome := "success"
+
+		if err := h.handle(ctx, item.event); err != nil {
+			outcome = "failed"
+
+			h.logger.Warn("failed to handle webhook delivery",
+				"delivery_id", item.deliveryID,
+				"event_type", item.eventType,
+				"error", err,
+			)
+			h.forget(item)
+		}
+
+		metrics.WebhookProcessedTotal.WithLabelValues(item.eventType, outcome).Inc()
+	}
+}
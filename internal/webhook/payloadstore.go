@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDeliveryNotFound is returned when a delivery is not in the PayloadStore.
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// deliveryIDPattern matches GitHub delivery GUIDs. IDs are used as file
+// names, so anything else is refused.
+var deliveryIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+
+// Delivery is a validated webhook delivery kept for debugging and replay.
+type Delivery struct {
+	ID         string          `json:"id"`
+	Event      string          `json:"event"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// PayloadStore keeps the raw payloads of the most recent validated
+// deliveries as one JSON file per delivery in a directory, deleting the
+// oldest once it holds more than its limit.
+type PayloadStore struct {
+	dir   string
+	limit int
+
+	mu    sync.Mutex
+	index []Delivery // oldest first, without payloads
+}
+
+// OpenPayloadStore opens or creates the payload directory at dir and indexes
+// the deliveries already in it, keeping at most limit of them.
+func OpenPayloadStore(dir string, limit int) (*PayloadStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating payload directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading payload directory %s: %w", dir, err)
+	}
+
+	s := &PayloadStore{dir: dir, limit: max(limit, 1)}
+
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || !deliveryIDPattern.MatchString(id) {
+			continue
+		}
+
+		d, err := s.read(id)
+		if err != nil {
+			return nil, err
+		}
+
+		d.Payload = nil
+		s.index = append(s.index, d)
+	}
+
+	slices.SortFunc(s.index, func(a, b Delivery) int {
+		return a.ReceivedAt.Compare(b.ReceivedAt)
+	})
+
+	if err := s.prune(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Save stores d, replacing any delivery with the same ID, and deletes the
+// oldest deliveries beyond the limit.
+func (s *PayloadStore) Save(d Delivery) error {
+	if !deliveryIDPattern.MatchString(d.ID) {
+		return fmt.Errorf("invalid delivery ID %q", d.ID)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("encoding delivery: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.WriteFile(s.path(d.ID), data, 0o600); err != nil {
+		return fmt.Errorf("storing delivery %s: %w", d.ID, err)
+	}
+
+	s.index = slices.DeleteFunc(s.index, func(e Delivery) bool { return e.ID == d.ID })
+
+	d.Payload = nil
+	s.index = append(s.index, d)
+
+	return s.prune()
+}
+
+// List returns the stored deliveries without their payloads, newest first.
+func (s *PayloadStore) List() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := slices.Clone(s.index)
+	slices.Reverse(list)
+
+	return list
+}
+
+// Get returns the stored delivery with the given ID, including its payload.
+func (s *PayloadStore) Get(id string) (Delivery, error) {
+	if !deliveryIDPattern.MatchString(id) {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(id)
+}
+
+func (s *PayloadStore) read(id string) (Delivery, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+
+	if err != nil {
+		return Delivery{}, fmt.Errorf("reading delivery %s: %w", id, err)
+	}
+
+	var d Delivery
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Delivery{}, fmt.Errorf("decoding delivery %s: %w", id, err)
+	}
+
+	return d, nil
+}
+
+// prune deletes the oldest deliveries beyond the limit. s.mu must be held,
+// or s not yet shared.
+func (s *PayloadStore) prune() error {
+	for len(s.index) > s.limit {
+		if err := os.Remove(s.path(s.index[0].ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("deleting delivery %s: %w", s.index[0].ID, err)
+		}
+
+		s.index = s.index[1:]
+	}
+
+	return nil
+}
+
+func (s *PayloadStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
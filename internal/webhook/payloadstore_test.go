@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPayloadStore_KeepsNewestDeliveries(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	store, err := OpenPayloadStore(dir, 2)
+	if err != nil {
+		t.Fatalf("OpenPayloadStore: %v", err)
+	}
+
+	start := time.Unix(1700000000, 0).UTC()
+
+	for i, id := range []string{"d-1", "d-2", "d-3"} {
+		d := Delivery{
+			ID:         id,
+			Event:      "repository",
+			ReceivedAt: start.Add(time.Duration(i) * time.Minute),
+			Payload:    json.RawMessage(`{"action":"created"}`),
+		}
+		if err := store.Save(d); err != nil {
+			t.Fatalf("Save %s: %v", id, err)
+		}
+	}
+
+	if _, err := store.Get("d-1"); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("oldest delivery should be deleted, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "d-1.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("oldest delivery file should be deleted, got %v", err)
+	}
+
+	got, err := store.Get("d-3")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if got.Event != "repository" || string(got.Payload) != `{"action":"created"}` {
+		t.Errorf("unexpected delivery: %+v", got)
+	}
+
+	// Reopening indexes the files on disk, newest first.
+	reopened, err := OpenPayloadStore(dir, 2)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+
+	list := reopened.List()
+	if len(list) != 2 || list[0].ID != "d-3" || list[1].ID != "d-2" || list[0].Payload != nil {
+		t.Errorf("unexpected list: %+v", list)
+	}
+}
+
+func TestPayloadStore_RejectsUnsafeIDs(t *testing.T) {
+	t.Parallel()
+
+	store, err := OpenPayloadStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("OpenPayloadStore: %v", err)
+	}
+
+	if err := store.Save(Delivery{ID: "../escape", Payload: json.RawMessage(`{}`)}); err == nil {
+		t.Error("expected error saving a delivery with a path in its ID")
+	}
+
+	if _, err := store.Get("../escape"); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("expected ErrDeliveryNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v68/github"
+
+	"github.com/donaldgifford/repo-guardian/internal/metrics"
+)
+
+// errNoSecrets is returned when validating a delivery without any secret
+// configured. go-github skips validation for an empty secret, so this must
+// never fall through to it.
+var errNoSecrets = errors.New("no webhook secret configured")
+
+// SetSecrets replaces the secrets deliveries are validated against, current
+// first, and reports whether they changed. Empty entries are ignored. It is
+// safe to call while the handler serves requests.
+func (h *Handler) SetSecrets(secrets []string) bool {
+	keys := make([][]byte, 0, len(secrets))
+
+	for _, secret := range secrets {
+		if secret != "" {
+			keys = append(keys, []byte(secret))
+		}
+	}
+
+	h.secretsMu.Lock()
+	defer h.secretsMu.Unlock()
+
+	if slices.EqualFunc(h.secrets, keys, bytes.Equal) {
+		return false
+	}
+
+	h.secrets = keys
+
+	return true
+}
+
+// validatePayload checks the request signature against each secret in turn
+// and returns the payload. The index of the secret that matched is counted
+// in a metric, so an old secret can be retired once it stops matching.
+func (h *Handler) validatePayload(r *http.Request) ([]byte, error) {
+	h.secretsMu.RLock()
+	secrets := h.secrets
+	h.secretsMu.RUnlock()
+
+	if len(secrets) == 0 {
+		return nil, errNoSecrets
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+
+	for i, secret := range secrets {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		payload, verr := gh.ValidatePayload(r, secret)
+		if verr == nil {
+			metrics.WebhookSecretMatchedTotal.WithLabelValues(strconv.Itoa(i)).Inc()
+			return payload, nil
+		}
+
+		err = verr
+	}
+
+	return nil, err
+}
+
+// ReadSecretsFile reads webhook secrets from path, one per line, current
+// first. Blank lines are ignored.
+func ReadSecretsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading webhook secrets file: %w", err)
+	}
+
+	var secrets []string
+
+	for line := range strings.Lines(string(data)) {
+		if secret := strings.TrimSpace(line); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("webhook secrets file %s is empty", path)
+	}
+
+	return secrets, nil
+}
+
+// WatchSecretsFile re-reads the secrets file at path every interval until
+// ctx is canceled and applies it when it changed. Kubernetes updates mounted
+// Secrets in place, so a rotation is picked up without a restart. A file
+// that cannot be read leaves the current secrets in effect.
+func (h *Handler) WatchSecretsFile(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		secrets, err := ReadSecretsFile(path)
+		if err != nil {
+			h.logger.Error("failed to reload webhook secrets", "path", path, "error", err)
+			metrics.ErrorsTotal.WithLabelValues("reload_webhook_secrets").Inc()
+
+			continue
+		}
+
+		if !h.SetSecrets(secrets) {
+			continue
+		}
+
+		h.logger.Info("reloaded webhook secrets", "path", path, "count", len(secrets))
+	}
+}
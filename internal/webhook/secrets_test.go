@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/donaldgifford/repo-guardian/internal/checker"
+)
+
+// signedRequest builds a push delivery signed with secret, or unsigned when
+// secret is empty.
+func signedRequest(secret string) *http.Request {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "push")
+
+	if secret != "" {
+		req.Header.Set("X-Hub-Signature-256", signPayload(body, secret))
+	}
+
+	return req
+}
+
+func TestValidatePayload_Secrets(t *testing.T) {
+	t.Parallel()
+
+	h := NewHandler([]string{"current", "", "previous"}, checker.NewQueue(10, slog.Default()), nil, nil, slog.Default())
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "current secret", secret: "current"},
+		{name: "previous secret", secret: "previous"},
+		{name: "unknown secret", secret: "other", wantErr: true},
+		{name: "unsigned", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		payload, err := h.validatePayload(signedRequest(tt.secret))
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if !tt.wantErr && string(payload) != `{"ref":"refs/heads/main"}` {
+			t.Errorf("%s: payload = %q", tt.name, payload)
+		}
+	}
+
+	// Retiring the previous secret refuses deliveries still signed with it.
+	h.SetSecrets([]string{"current"})
+
+	if _, err := h.validatePayload(signedRequest("previous")); err == nil {
+		t.Error("expected retired secret to be refused")
+	}
+
+	// With no secret at all, nothing validates, not even unsigned payloads.
+	h.SetSecrets(nil)
+
+	if _, err := h.validatePayload(signedRequest("")); err == nil {
+		t.Error("expected unsigned delivery to be refused without secrets")
+	}
+}
+
+func TestReadSecretsFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secrets")
+
+	if err := os.WriteFile(path, []byte("current\n\n  previous  \n"), 0o600); err != nil {
+		t.Fatalf("writing secrets: %v", err)
+	}
+
+	got, err := ReadSecretsFile(path)
+	if err != nil {
+		t.Fatalf("ReadSecretsFile: %v", err)
+	}
+
+	if !slices.Equal(got, []string{"current", "previous"}) {
+		t.Errorf("secrets = %q, want [current previous]", got)
+	}
+
+	if err := os.WriteFile(path, []byte("\n"), 0o600); err != nil {
+		t.Fatalf("writing secrets: %v", err)
+	}
+
+	if _, err := ReadSecretsFile(path); err == nil {
+		t.Error("expected error for an empty secrets file")
+	}
+}
+
+func TestWatchSecretsFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secrets")
+
+	if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+		t.Fatalf("writing secrets: %v", err)
+	}
+
+	h := NewHandler([]string{"old"}, checker.NewQueue(10, slog.Default()), nil, nil, slog.Default())
+
+	go h.WatchSecretsFile(t.Context(), path, 10*time.Millisecond)
+
+	// Rotate: the new secret becomes current and the old one stays valid.
+	if err := os.WriteFile(path, []byte("new\nold\n"), 0o600); err != nil {
+		t.Fatalf("writing secrets: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		if _, err := h.validatePayload(signedRequest("new")); err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("rotated secret was not picked up")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := h.validatePayload(signedRequest("old")); err != nil {
+		t.Errorf("previous secret should still validate: %v", err)
+	}
+}